@@ -1,264 +1,333 @@
 package main
 
 import (
-	"bufio"
-	"bytes"
-	"crypto/md5"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"math"
+	"math/bits"
 	"os"
+	"os/signal"
+	"path/filepath"
 	"regexp"
+	"slices"
 	"strconv"
-)
-
-type ErrorMsg string
+	"strings"
+	"syscall"
+	"time"
 
-const (
-	InvalidByteSizeFormat       ErrorMsg = "Invalid byte size format"
-	YouMustSpecifyOnlyOneOption ErrorMsg = "You must specify only one option"
-	CannotDetermineFileSize     ErrorMsg = "Cannot determine file size"
-	OverflowHasOccured          ErrorMsg = "Overflow has occured"
-	InvalidSplitSize            ErrorMsg = "Invalid split size"
-	InvalidIndex                ErrorMsg = "invalid index"
-	DefaultCount                uint64   = 1000
+	"split/split"
 )
 
-func parseByteSize(input string) (uint64, error) {
-	re := regexp.MustCompile(`^(\d+)([KMGTPkm]i?B?)?$`)
+// -n に指定された値を解析する
+// N はバイト単位で、l/N は行の途中で区切らずに、L/N は行数が均等になるように、
+// r/N は行を順番に振り分けて N 個のファイルに分割する
+// K/N は N 個に分割したうちの K 番目だけを取り出し、その番号を chunk として返す
+func parseChunkCount(input string) (splitType split.SplitType, chunk uint64, count uint64, err error) {
+	re := regexp.MustCompile(`^(?:([lLr])/|(\d+)/)?(\d+)$`)
 	matches := re.FindStringSubmatch(input)
-	if len(matches) != 3 {
-		return 0, fmt.Errorf("%s: %s", InvalidByteSizeFormat, input)
+	if len(matches) != 4 {
+		return 0, 0, 0, fmt.Errorf("%w: %s", split.InvalidNumberOfChunks, input)
 	}
 
-	size, err := strconv.ParseUint(matches[1], 10, 64)
+	count, err = strconv.ParseUint(matches[3], 10, 64)
 	if err != nil {
-		return 0, err
+		return 0, 0, 0, err
 	}
 
-	unitVal := uint64(1)
-
-	unit := matches[2]
-	switch unit {
-	case "KB", "kB":
-		unitVal *= 1000
-	case "MB", "mB":
-		unitVal *= 1000 * 1000
-	case "GB":
-		unitVal *= 1000 * 1000 * 1000
-	case "TB":
-		unitVal *= 1000 * 1000 * 1000 * 1000
-	case "PB":
-		unitVal *= 1000 * 1000 * 1000 * 1000 * 1000
-	case "K", "k", "KiB", "kiB":
-		unitVal *= 1024
-	case "M", "m", "MiB", "miB":
-		unitVal *= 1024 * 1024
-	case "G", "GiB":
-		unitVal *= 1024 * 1024 * 1024
-	case "T", "TiB":
-		unitVal *= 1024 * 1024 * 1024 * 1024
-	case "P", "PiB":
-		unitVal *= 1024 * 1024 * 1024 * 1024 * 1024
-	case "Ki", "Mi", "Gi", "Ti", "Pi", "ki", "mi":
-		return 0, fmt.Errorf("%s: %s", InvalidByteSizeFormat, input)
+	if matches[2] != "" {
+		chunk, err = strconv.ParseUint(matches[2], 10, 64)
+		if err != nil {
+			return 0, 0, 0, err
+		}
+		if chunk < 1 || chunk > count {
+			return 0, 0, 0, fmt.Errorf("%w: %s", split.InvalidChunkNumber, input)
+		}
 	}
 
-	result := size * unitVal
-
-	if result/unitVal != size {
-		return 0, fmt.Errorf("%s", OverflowHasOccured)
+	switch matches[1] {
+	case "l":
+		return split.ByLineFiles, 0, count, nil
+	case "L":
+		return split.ByBalancedLines, 0, count, nil
+	case "r":
+		return split.ByRoundRobin, 0, count, nil
 	}
+	return split.ByFiles, chunk, count, nil
+}
 
-	return result, nil
+// -t に指定された区切り文字を解析する
+// 1バイトの文字のほか、\0 などのエスケープを受け付ける
+func parseSeparator(input string) (byte, error) {
+	switch input {
+	case `\0`:
+		return 0, nil
+	case `\t`:
+		return '\t', nil
+	case `\n`:
+		return '\n', nil
+	case `\\`:
+		return '\\', nil
+	}
+	if len(input) != 1 {
+		return 0, fmt.Errorf("%w: %q", split.InvalidSeparator, input)
+	}
+	return input[0], nil
 }
 
-type SplitType int
+// --separator に指定された区切りを解析する
+// 複数バイトの文字列のほか、\r、\n、\t、\0、\\ のエスケープを受け付ける
+func parseMultiByteSeparator(input string) ([]byte, error) {
+	escapes := map[byte]byte{'r': '\r', 'n': '\n', 't': '\t', '0': 0, '\\': '\\'}
+	separator := []byte{}
+	for i := 0; i < len(input); i++ {
+		if input[i] != '\\' {
+			separator = append(separator, input[i])
+			continue
+		}
+		i++
+		if i >= len(input) {
+			return nil, fmt.Errorf("%w: %q", split.InvalidSeparator, input)
+		}
+		escaped, ok := escapes[input[i]]
+		if !ok {
+			return nil, fmt.Errorf("%w: %q", split.InvalidSeparator, input)
+		}
+		separator = append(separator, escaped)
+	}
+	if len(separator) == 0 {
+		return nil, fmt.Errorf("%w: %q", split.InvalidSeparator, input)
+	}
+	return separator, nil
+}
 
-const (
-	ByBytes SplitType = iota
-	ByLines
-	ByFiles
-)
+// --numeric-suffixes[=FROM] のように値を省略可能なフラグ
+// base が0の場合は FROM を10進数として扱う
+type numericSuffixesFlag struct {
+	set   bool
+	start uint64
+	base  int
+}
 
-type Splitter struct {
-	splitType    SplitType
-	count        uint64
-	reader       io.Reader
-	outputPrefix string
+func (f *numericSuffixesFlag) String() string {
+	return strconv.FormatUint(f.start, f.radix())
 }
 
-func NewSplitter(splitType SplitType, count uint64, reader io.Reader, outputPrefix string) *Splitter {
-	return &Splitter{
-		splitType:    splitType,
-		count:        count,
-		reader:       reader,
-		outputPrefix: outputPrefix,
+func (f *numericSuffixesFlag) radix() int {
+	if f.base == 0 {
+		return 10
 	}
+	return f.base
 }
 
-func (s *Splitter) Split() error {
-	switch s.splitType {
-	case ByBytes:
-		return s.splitByByte()
-	case ByLines:
-		return s.splitByLine()
-	case ByFiles:
-		return s.splitByFile()
+func (f *numericSuffixesFlag) Set(value string) error {
+	f.set = true
+	if value == "true" {
+		return nil
 	}
-
-	return fmt.Errorf("%s", InvalidSplitSize)
+	start, err := strconv.ParseUint(value, f.radix(), 64)
+	if err != nil {
+		return fmt.Errorf("%w: %s", split.InvalidSuffixStart, value)
+	}
+	f.start = start
+	return nil
 }
 
-func (s *Splitter) splitByByte() error {
-	buffer := make([]byte, s.count)
-	for i := uint64(0); ; i++ {
-		n, err := s.reader.Read(buffer)
-		if err != nil {
-			if err == io.EOF {
-				break
-			} else {
-				return err
-			}
-		}
+func (f *numericSuffixesFlag) IsBoolFlag() bool {
+	return true
+}
 
-		outputFile, err := s.createOutputFile(i)
+// --sizes=1M,10M のようにカンマで区切ったサイズの一覧を解析する
+func parseSizes(input string, units split.UnitSystem) ([]uint64, error) {
+	sizes := []uint64{}
+	for _, element := range strings.Split(input, ",") {
+		size, err := split.ParseByteSizeWith(element, units)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		defer outputFile.Close()
-
-		if _, err := outputFile.Write(buffer[:n]); err != nil {
-			return err
+		if size == 0 {
+			return nil, fmt.Errorf("%w: %s", split.InvalidSplitSize, element)
 		}
+		sizes = append(sizes, size)
 	}
-	return nil
+	return sizes, nil
 }
 
-func (s *Splitter) splitByLine() error {
-	fileIndex := uint64(0)
-	lineCount := uint64(0)
-	outputFile, err := s.createOutputFile(uint64(fileIndex))
+// --percent で、シーク可能な reader の現在位置から末尾までを percent% ずつに分けた出力ファイルごとのバイト数を求める
+// ファイル数は入力のサイズによらず 100/percent (切り捨て) とし、i 番目のファイルは入力の i*percent% の位置で終える
+// percent% に満たない端数は最後のファイルに含めるため、33 の場合は 33%, 33%, 34% の3つのファイルとなる
+// 区切りの位置はそれぞれ切り捨てて求め、1バイト未満の誤差を積み重ねない
+// percent% が1バイトに満たない場合は1つのファイルとする
+func percentSizes(reader io.Reader, percent uint64) ([]uint64, error) {
+	if percent == 0 || percent > 100 {
+		return nil, fmt.Errorf("%w: %d", split.InvalidPercent, percent)
+	}
+	seeker, ok := reader.(io.Seeker)
+	if !ok {
+		return nil, split.CannotDetermineFileSize
+	}
+	current, err := seeker.Seek(0, io.SeekCurrent)
 	if err != nil {
-		return err
+		return nil, split.CannotDetermineFileSize
+	}
+	end, err := seeker.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
 	}
-	defer outputFile.Close()
+	if _, err := seeker.Seek(current, io.SeekStart); err != nil {
+		return nil, err
+	}
+	size := uint64(end - current)
 
-	buffer := bufio.NewReader(s.reader)
-	for i := 0; ; i++ {
-		line, err := buffer.ReadBytes('\n')
-		if err != nil {
-			if err == io.EOF {
-				outputFile.Write(line)
-				break
-			} else {
-				return err
-			}
-		}
+	// 入力の先頭から i*percent% の位置
+	// i*percent は100以下のため、積の上位の桁は100未満となり割り算で溢れない
+	boundary := func(i uint64) uint64 {
+		hi, lo := bits.Mul64(size, i*percent)
+		quotient, _ := bits.Div64(hi, lo, 100)
+		return quotient
+	}
+	if boundary(1) == 0 {
+		return []uint64{max(size, 1)}, nil
+	}
+	fileCount := 100 / percent
+	sizes := make([]uint64, 0, fileCount)
+	start := uint64(0)
+	for i := uint64(1); i < fileCount; i++ {
+		end := boundary(i)
+		sizes = append(sizes, end-start)
+		start = end
+	}
+	return append(sizes, size-start), nil
+}
 
-		if _, err := outputFile.Write(line); err != nil {
-			return err
-		}
-		lineCount++
+// --line-ending の値を解析する
+func parseLineEnding(input string) (split.LineEnding, error) {
+	switch input {
+	case "lf":
+		return split.LineEndingLF, nil
+	case "crlf":
+		return split.LineEndingCRLF, nil
+	case "auto":
+		return split.LineEndingAuto, nil
+	}
+	return 0, fmt.Errorf("%w: %s", split.InvalidLineEnding, input)
+}
 
-		if lineCount%s.count == 0 {
-			outputFile.Close()
-			fileIndex++
-			outputFile, err = s.createOutputFile(fileIndex)
-			if err != nil {
-				return err
-			}
+// 指定された分割方法の数を数える
+func countSplitModes(modes ...bool) int {
+	count := 0
+	for _, mode := range modes {
+		if mode {
+			count++
 		}
 	}
+	return count
+}
+
+// nopWriteCloser は Close で何もしない io.WriteCloser
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
 	return nil
 }
 
-func (s *Splitter) splitByFile() error {
-	fileBuf := new(bytes.Buffer)
-	fileSize, err := io.Copy(fileBuf, s.reader)
-	if err != nil {
-		return err
-	}
-	byteCount := uint64(fileSize) / s.count
-	byteRemain := uint64(fileSize) % s.count
-	buffer := make([]byte, byteCount)
-	for i := uint64(0); i < s.count; i++ {
-		if i == s.count-1 {
-			buffer = make([]byte, byteCount+byteRemain)
-		}
-		n, err := fileBuf.Read(buffer)
-		if err != nil {
-			if err == io.EOF {
-				break
-			} else {
-				return err
-			}
-		}
+// 終了ステータス
+const (
+	// 正常に終了した
+	ExitSuccess = 0
+	// 入出力などの実行時のエラーで終了した
+	ExitFailure = 1
+	// コマンドライン引数の誤りで終了した
+	ExitUsage = 2
+)
 
-		outputFile, err := s.createOutputFile(i)
-		if err != nil {
-			return err
-		}
-		defer outputFile.Close()
+// 分割方法が指定されていない場合に用いる、出力ファイルごとのバイト数を指定する環境変数
+const DefaultBytesEnv = "SPLIT_DEFAULT_BYTES"
 
-		if _, err := outputFile.Write(buffer[:n]); err != nil {
-			return err
-		}
-	}
+// --rotate-interval で出力ファイル名に用いる、作成した時刻の形式
+const RotateTimestampLayout = "20060102T150405"
 
-	return nil
+// --follow で入力の末尾に達してから読み直すまでの間隔
+var followInterval = split.DefaultFollowInterval
+
+// usageError はコマンドライン引数の誤りを表す
+type usageError struct {
+	err error
 }
 
-func (s *Splitter) createOutputFile(index uint64) (*os.File, error) {
-	outputFileName, err := genFileName(s.outputPrefix, index, s.count, s.splitType)
-	if err != nil {
-		return nil, err
-	}
+func (e *usageError) Error() string {
+	return e.err.Error()
+}
 
-	outputFile, err := os.Create(outputFileName)
-	if err != nil {
-		return outputFile, err
-	}
-	return outputFile, nil
+func (e *usageError) Unwrap() error {
+	return e.err
 }
 
-// 生成されるファイル名の命名規則
-func genFileName(prefix string, index uint64, fileCount uint64, splitType SplitType) (string, error) {
-	if splitType == ByFiles && index+1 > fileCount {
-		return "", fmt.Errorf("%s", InvalidIndex)
-	}
-	if prefix == "" {
-		prefix = "x"
-	}
-	if splitType != ByFiles {
-		fileCount = 26
-		for i := 1; i < 14; i++ {
-			if uint64(math.Pow(26, float64(i)))-26 <= index && index < uint64(math.Pow(26, float64(i+1)))-26 {
-				index -= uint64(math.Pow(26, float64(i))) - 26
-				fileCount = uint64(math.Pow(26, float64(i))) + 1
-				for j := 1; j < i; j++ {
-					prefix += "z"
-				}
-				break
-			}
-		}
+// 入力を読む前に分かる、オプションや引数の指定の誤りを表すエラー
+// これらは ExitUsage とし、入出力の失敗などの ExitFailure と区別する
+var usageErrors = []error{
+	split.YouMustSpecifyOnlyOneOption,
+	split.InvalidByteSizeFormat,
+	split.OverflowHasOccured,
+	split.InvalidSplitSize,
+	split.InvalidNumberOfChunks,
+	split.InvalidChunkNumber,
+	split.InvalidSeparator,
+	split.StdoutRequiresSingleOutput,
+	split.InvalidSuffixLength,
+	split.InvalidSuffixStart,
+	split.InvalidSuffixSeparator,
+	split.InvalidSuffixAlphabet,
+	split.InvalidAdditionalSuffix,
+	split.SuffixStartTooLarge,
+	split.InvalidOutputPattern,
+	split.InvalidLineEnding,
+	split.InvalidChecksumAlgorithm,
+	split.ChecksumRequiresFiles,
+	split.InvalidPattern,
+	split.InvalidRanges,
+	split.InvalidRecordMarker,
+	split.InvalidRotateInterval,
+	split.InvalidTimestampLayout,
+	split.InvalidPercent,
+	split.InvalidDecompression,
+	split.FollowRequiresSingleFile,
+	split.FollowWithChunks,
+	split.ManifestRequiresByteSplit,
+	split.InvalidNumberOfArguments,
+	split.SuppressRequiresPattern,
+	split.MaxLineBytesRequiresLines,
+	split.TotalSizeRequiresChunks,
+	split.JoinOutputIsInput,
+	split.RangesNotContiguous,
+	split.UTF8SafeRequiresByteSplit,
+	split.MinChunkRequiresChunks,
+	split.TrailerFileRequiresBytes,
+	split.TrailingNewlineNeedsLines,
+}
+
+// Run が返したエラーに対応する終了ステータスを求める
+// 引数の誤りは errors.Is で判定するため、詳細を付加したエラーも ExitUsage となる
+func exitCode(err error) int {
+	if err == nil || errors.Is(err, flag.ErrHelp) {
+		return ExitSuccess
 	}
-	tmp := ""
-	if fileCount < 27 {
-		tmp = fmt.Sprintf("%c", 'a'+(index%26))
-		index /= 26
+	var usageErr *usageError
+	if errors.As(err, &usageErr) {
+		return ExitUsage
 	}
-	fileCountF := float64(fileCount)
-	for fileCountF >= 1 {
-		tmp = fmt.Sprintf("%c", 'a'+(index%26)) + tmp
-		if fileCountF/26 == 1 {
-			break
+	for _, target := range usageErrors {
+		if errors.Is(err, target) {
+			return ExitUsage
 		}
-		fileCountF /= 26
-		index /= 26
 	}
-	return prefix + tmp, nil
+	return ExitFailure
 }
 
 type CLI struct {
@@ -268,44 +337,435 @@ type CLI struct {
 }
 
 func (cli *CLI) Run(args []string) error {
-	splitFlag := flag.NewFlagSet(args[0], flag.ExitOnError)
+	splitFlag := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	splitFlag.SetOutput(cli.Stderr)
 	splitFlag.Usage = func() {
-		fmt.Fprintf(cli.Stderr, "Usage: %s [options...] <file> [prefix]\n", args[0])
+		fmt.Fprintf(cli.Stderr, "Usage: %s [options...] <file>... [prefix]\n", args[0])
 		splitFlag.PrintDefaults()
+		fmt.Fprintf(cli.Stderr, "\nEnvironment:\n  %s\n    \tBytes per output file when no split mode is given (options take precedence)\n", DefaultBytesEnv)
 	}
 	// コマンドライン引数
 	byteCountStr := splitFlag.String("b", "0", "Bytes per output file")
 	lineCountP := splitFlag.Uint64("l", 0, "Number of lines per output file")
-	fileCountP := splitFlag.Uint64("n", 0, "Number of output files")
+	lineByteCountStr := "0"
+	splitFlag.StringVar(&lineByteCountStr, "C", "0", "Maximum bytes of lines per output file")
+	splitFlag.StringVar(&lineByteCountStr, "line-bytes", "0", "Maximum bytes of lines per output file")
+	fileCountStr := splitFlag.String("n", "0", "Number of output files (N, l/N, L/N or r/N)")
+	suffixLength := 0
+	splitFlag.IntVar(&suffixLength, "a", 0, "Generate suffixes of length N (default 2)")
+	splitFlag.IntVar(&suffixLength, "suffix-length", 0, "Generate suffixes of length N (default 2)")
+	numericSuffixes := splitFlag.Bool("d", false, "Use numeric suffixes instead of alphabetic")
+	numericSuffixesFrom := &numericSuffixesFlag{}
+	splitFlag.Var(numericSuffixesFrom, "numeric-suffixes", "Same as -d, but allow setting the start value (--numeric-suffixes=FROM)")
+	hexSuffixes := splitFlag.Bool("x", false, "Use hex suffixes instead of alphabetic")
+	hexSuffixesFrom := &numericSuffixesFlag{base: 16}
+	splitFlag.Var(hexSuffixesFrom, "hex-suffixes", "Same as -x, but allow setting the start value (--hex-suffixes=FROM)")
+	suffixAlphabet := splitFlag.String("suffix-alphabet", "", "Use the characters of ALPHABET in order for suffixes instead of a-z")
+	suffixStart := splitFlag.String("suffix-start", "", "Start alphabetic suffixes at START (e.g. ba), written with --suffix-alphabet if given")
+	additionalSuffix := splitFlag.String("additional-suffix", "", "Append an additional SUFFIX to file names")
+	prefix := ""
+	splitFlag.StringVar(&prefix, "p", "", "Use PREFIX for output file names and treat every argument as an input file")
+	splitFlag.StringVar(&prefix, "prefix", "", "Use PREFIX for output file names and treat every argument as an input file")
+	prefixFromInput := splitFlag.Bool("prefix-from-input", false, "Use the base name of the input file followed by '.' (or --suffix-separator) as PREFIX; ignored for standard input")
+	outputPattern := splitFlag.String("output-pattern", "", "Name output files by PATTERN, where %d is the file number (e.g. chunk-%03d.dat) and %s the default suffix, instead of PREFIX and suffixes")
+	suffixSeparator := splitFlag.String("suffix-separator", "", "Insert SEP between the prefix and the suffix")
+	decompress := splitFlag.String("decompress", "", "Decompress the input with FORMAT (gzip) before splitting")
+	gzipOutput := false
+	splitFlag.BoolVar(&gzipOutput, "z", false, "Compress each output file with gzip")
+	splitFlag.BoolVar(&gzipOutput, "gzip", false, "Compress each output file with gzip")
+	unbuffered := false
+	splitFlag.BoolVar(&unbuffered, "u", false, "Immediately copy input to output with -n r/...")
+	splitFlag.BoolVar(&unbuffered, "unbuffered", false, "Immediately copy input to output with -n r/...")
+	parallel := splitFlag.Int("parallel", 1, "With -b or -n and a seekable input, write up to K output files concurrently")
+	createRetries := splitFlag.Int("create-retries", 0, "Retry creating an output file up to N times on temporary errors")
+	maxFiles := splitFlag.Uint64("max-files", 0, "Stop with an error instead of creating more than N output files (0 means unlimited; setting it is recommended in scripts)")
+	createDirs := splitFlag.Bool("create-dirs", false, "Create the directories in PREFIX if they do not exist")
+	noCleanup := splitFlag.Bool("no-cleanup", false, "Keep the output files created before an error")
+	checksums := splitFlag.Bool("checksums", false, "Write the checksum of each output file to PREFIX.ALGO")
+	checksumAlgo := splitFlag.String("checksum-algo", split.ChecksumMD5, "Use ALGO (md5 or sha256) for --checksums")
+	atomic := splitFlag.Bool("atomic", false, "Write each output file to a temporary file and rename it when complete")
+	noClobber := splitFlag.Bool("no-clobber", false, "Do not overwrite existing output files")
+	manifestPath := splitFlag.String("manifest", "", "With -b, -n N, --sizes or --percent, write each output file and the input byte range it covers to FILE as NAME<TAB>START<TAB>END")
+	jsonOutput := splitFlag.Bool("json", false, "Print the output files and their sizes as JSON to standard output after splitting")
+	summary := splitFlag.Bool("summary", false, "Print the number of files and bytes written to standard error")
+	verify := splitFlag.Bool("verify", false, "Check that the files PREFIX* joined in order match ORIGINAL (arguments: PREFIX ORIGINAL)")
+	join := splitFlag.Bool("join", false, "Join the files PREFIX* in suffix order into OUTPUT (arguments: PREFIX OUTPUT)")
+	dryRun := splitFlag.Bool("dry-run", false, "Print the names of the output files without creating them")
+	quiet := splitFlag.Bool("quiet", false, "Print nothing but errors; overrides --verbose and --summary")
+	verbose := splitFlag.Bool("verbose", false, "Print a diagnostic just before each output file is opened")
+	filter := splitFlag.String("filter", "", "Write to shell COMMAND; file name is $FILE")
+	percent := splitFlag.Uint64("percent", 0, "Put N% of the input size bytes per output file; the input must be seekable")
+	sizesStr := splitFlag.String("sizes", "", "Put SIZE1, SIZE2, ... bytes per output file, repeating the last SIZE")
+	inputOffsetStr := splitFlag.String("input-offset", "0", "Skip the first SIZE bytes of the input")
+	inputLimitStr := splitFlag.String("input-limit", "0", "Split at most SIZE bytes of the input after --input-offset; 0 means until the end")
+	minChunkStr := splitFlag.String("min-chunk", "0", "With -n N, create fewer than N files so that each file has at least SIZE bytes")
+	trailerBytesStr := splitFlag.String("trailer-bytes", "0", "Do not split the last SIZE bytes of a seekable input")
+	trailerFile := splitFlag.Bool("trailer-file", false, "With --trailer-bytes, write the excluded bytes to PREFIX.trailer")
+	totalSizeStr := splitFlag.String("total-size", "0", "With -n and a non-seekable input, treat the input as SIZE bytes instead of buffering it")
+	si := splitFlag.Bool("si", false, "Interpret every size unit as a power of 1000")
+	iec := splitFlag.Bool("iec", false, "Interpret every size unit as a power of 1024")
+	lineEndingStr := splitFlag.String("line-ending", "lf", "Count lines ending with auto, lf or crlf")
+	maxLineBytesStr := splitFlag.String("max-line-bytes", "0", "With -l, start a new file when a line exceeds SIZE bytes")
+	headerLines := splitFlag.Uint64("header-lines", 0, "Copy the first N lines of the input to the beginning of every output file")
+	utf8Safe := splitFlag.Bool("utf8-safe", false, "With -b, end each output file before a UTF-8 character that would be cut and start the next file with it")
+	ensureTrailingNewline := splitFlag.Bool("ensure-trailing-newline", false, "With -l, end the last output file with the record separator even if the input does not")
+	tarPath := splitFlag.String("tar", "", "Write the output files as entries of the tar archive FILE instead of creating them")
+	follow := splitFlag.Bool("follow", false, "Keep reading the input file as it grows until interrupted, like tail -f")
+	rotateIntervalStr := splitFlag.String("rotate-interval", "", "Start a new output file named by its creation time once DURATION has passed; combines with -l")
+	recordMarkerStr := splitFlag.String("record-marker", "", "Start a new record at each occurrence of the hex-encoded BYTES")
+	recordsPerFile := splitFlag.Uint64("records-per-file", split.DefaultCount, "With --record-marker, put N records per output file")
+	rangesStr := splitFlag.String("ranges", "", "Put lines FIRST-LAST of each FIRST-LAST:LABEL in a file named PREFIX followed by LABEL")
+	separatorPattern := splitFlag.String("separator-pattern", "", "Start a new output file at each line matching REGEX")
+	suppressMatched := splitFlag.Bool("suppress-matched", false, "Do not output lines matching --separator-pattern")
+	separatorStr := splitFlag.String("t", `\n`, "Use SEP instead of newline as the record separator")
+	multiByteSeparatorStr := splitFlag.String("separator", "", "Use the string SEP, which may be several bytes such as \\r\\n\\r\\n, as the record separator")
+	elideEmptyFiles := false
+	splitFlag.BoolVar(&elideEmptyFiles, "e", false, "Do not generate empty output files with -n")
+	splitFlag.BoolVar(&elideEmptyFiles, "elide-empty-files", false, "Do not generate empty output files with -n")
 
-	splitFlag.Parse(args[1:])
+	// 解析に失敗した場合は flag パッケージがエラーと使い方を出力する
+	if err := splitFlag.Parse(args[1:]); err != nil {
+		return &usageError{err: err}
+	}
+
+	// --quiet は情報を出力するフラグよりも優先する
+	// --dry-run や --json の出力は情報ではなく結果のため抑制しない
+	if *quiet {
+		*verbose = false
+		*summary = false
+	}
+
+	// 明示的に指定されたフラグ
+	setFlags := map[string]bool{}
+	splitFlag.Visit(func(f *flag.Flag) {
+		setFlags[f.Name] = true
+	})
+
+	if suffixLength < 0 {
+		return fmt.Errorf("%w: %d", split.InvalidSuffixLength, suffixLength)
+	}
+	// 10進数と16進数の接尾辞は同時に指定できない
+	numeric := *numericSuffixes || numericSuffixesFrom.set
+	hex := *hexSuffixes || hexSuffixesFrom.set
+	if numeric && hex {
+		return split.YouMustSpecifyOnlyOneOption
+	}
+	suffixFrom := numericSuffixesFrom
+	if hex {
+		suffixFrom = hexSuffixesFrom
+	}
+	width := split.DefaultSuffixLength
+	if suffixLength > 0 {
+		width = suffixLength
+	}
+	// 開始値は接尾辞の桁数に収まる必要がある
+	if suffixFrom.set {
+		if len(suffixFrom.String()) > width {
+			return fmt.Errorf("%w: %s", split.SuffixStartTooLarge, suffixFrom)
+		}
+	}
+	// 接尾辞の文字の並びは数字の接尾辞と同時に指定できない
+	alphabet := split.AlphabeticSuffixAlphabet
+	if setFlags["suffix-alphabet"] {
+		if numeric || hex {
+			return split.YouMustSpecifyOnlyOneOption
+		}
+		if err := split.ValidateSuffixAlphabet(*suffixAlphabet); err != nil {
+			return err
+		}
+		alphabet = *suffixAlphabet
+	}
+	// 英字の接尾辞の開始値は数字の接尾辞と同時に指定できない
+	suffixStartValue := suffixFrom.start
+	if setFlags["suffix-start"] {
+		if numeric || hex {
+			return split.YouMustSpecifyOnlyOneOption
+		}
+		start, err := split.ParseSuffix(*suffixStart, alphabet)
+		if err != nil {
+			return err
+		}
+		if len(*suffixStart) > width {
+			return fmt.Errorf("%w: %s", split.SuffixStartTooLarge, *suffixStart)
+		}
+		suffixStartValue = start
+	}
+
+	suffix := split.SuffixOptions{
+		Length:     suffixLength,
+		Numeric:    numeric,
+		Hex:        hex,
+		Alphabet:   *suffixAlphabet,
+		Start:      suffixStartValue,
+		Additional: *additionalSuffix,
+		Separator:  *suffixSeparator,
+	}
 
-	byteCount, err := parseByteSize(*byteCountStr)
+	// 分割は行わずに、出力ファイルを連結したものが元のファイルと一致するかを確認する
+	if *verify && *join {
+		return split.YouMustSpecifyOnlyOneOption
+	}
+	if *verify {
+		if splitFlag.NArg() != 2 {
+			return fmt.Errorf("%w: --verify requires PREFIX and ORIGINAL", split.InvalidNumberOfArguments)
+		}
+		verifyPrefix, original := splitFlag.Arg(0), splitFlag.Arg(1)
+		count, err := split.Verify(verifyPrefix, original, suffix)
+		if err != nil {
+			return err
+		}
+		if !*quiet {
+			fmt.Fprintf(cli.Stdout, "OK: %d files match %s\n", count, original)
+		}
+		return nil
+	}
+	// 分割は行わずに、出力ファイルを連結して元のファイルに戻す
+	// 連結先が "-" の場合は標準出力に書き込む
+	if *join {
+		if splitFlag.NArg() != 2 {
+			return fmt.Errorf("%w: --join requires PREFIX and OUTPUT", split.InvalidNumberOfArguments)
+		}
+		return cli.join(splitFlag.Arg(0), splitFlag.Arg(1), suffix, *noClobber)
+	}
+
+	separator, err := parseSeparator(*separatorStr)
 	if err != nil {
 		return err
 	}
+	var multiByteSeparator []byte
+	if setFlags["separator"] {
+		if setFlags["t"] {
+			return split.YouMustSpecifyOnlyOneOption
+		}
+		multiByteSeparator, err = parseMultiByteSeparator(*multiByteSeparatorStr)
+		if err != nil {
+			return err
+		}
+		// 1バイトの区切りは -t と同じく扱う
+		if len(multiByteSeparator) == 1 {
+			separator = multiByteSeparator[0]
+			multiByteSeparator = nil
+		}
+	}
+	lineEnding, err := parseLineEnding(*lineEndingStr)
+	if err != nil {
+		return err
+	}
+	// CRLF は改行を区切り文字とする場合に限る
+	if lineEnding != split.LineEndingLF && separator != '\n' {
+		return fmt.Errorf("%w: %s", split.InvalidLineEnding, *lineEndingStr)
+	}
+
+	// 単位の解釈は --si と --iec のどちらか一方で統一できる
+	if *si && *iec {
+		return split.YouMustSpecifyOnlyOneOption
+	}
+	units := split.UnitsDefault
+	if *si {
+		units = split.UnitsSI
+	} else if *iec {
+		units = split.UnitsIEC
+	}
+
+	// -b は既定値ではなく指定の有無で判定し、明示的な0は不正な値とする
+	byteMode := setFlags["b"]
+	byteCount, err := split.ParseByteSizeWith(*byteCountStr, units)
+	if err != nil {
+		return err
+	}
+	if byteMode && byteCount == 0 {
+		return fmt.Errorf("%w: %s", split.InvalidSplitSize, *byteCountStr)
+	}
+	lineByteCount, err := split.ParseByteSizeWith(lineByteCountStr, units)
+	if err != nil {
+		return err
+	}
+	if (setFlags["C"] || setFlags["line-bytes"]) && lineByteCount == 0 {
+		return fmt.Errorf("%w: %s", split.InvalidSplitSize, lineByteCountStr)
+	}
+	// -l 0 と -n 0 は未指定と区別し、既定の行数で分割せずにエラーとする
 	lineCount := *lineCountP
-	fileCount := *fileCountP
+	if setFlags["l"] && lineCount == 0 {
+		return fmt.Errorf("%w: %d", split.InvalidSplitSize, lineCount)
+	}
+	maxLineBytes, err := split.ParseByteSizeWith(*maxLineBytesStr, units)
+	if err != nil {
+		return err
+	}
+	totalSize, err := split.ParseByteSizeWith(*totalSizeStr, units)
+	if err != nil {
+		return err
+	}
+	minChunk, err := split.ParseByteSizeWith(*minChunkStr, units)
+	if err != nil {
+		return err
+	}
+	trailerBytes, err := split.ParseByteSizeWith(*trailerBytesStr, units)
+	if err != nil {
+		return err
+	}
+	if *trailerFile && trailerBytes == 0 {
+		return split.TrailerFileRequiresBytes
+	}
+	inputOffset, err := split.ParseByteSizeWith(*inputOffsetStr, units)
+	if err != nil {
+		return err
+	}
+	inputLimit, err := split.ParseByteSizeWith(*inputLimitStr, units)
+	if err != nil {
+		return err
+	}
+	fileSplitType, chunk, fileCount, err := parseChunkCount(*fileCountStr)
+	if err != nil {
+		return err
+	}
+	if setFlags["n"] && fileCount == 0 {
+		return fmt.Errorf("%w: %s", split.InvalidSplitSize, *fileCountStr)
+	}
 
 	// 複数の分割方法は指定不可
-	if byteCount > 0 && (lineCount > 0 || fileCount > 0) {
-		return fmt.Errorf("%s", YouMustSpecifyOnlyOneOption)
-	} else if lineCount > 0 && fileCount > 0 {
-		return fmt.Errorf("%s", YouMustSpecifyOnlyOneOption)
+	patternMode := setFlags["separator-pattern"]
+	sizesMode := setFlags["sizes"]
+	rangesMode := setFlags["ranges"]
+	recordMode := setFlags["record-marker"]
+	percentMode := setFlags["percent"]
+	splitModes := countSplitModes(byteMode, lineCount > 0, fileCount > 0, lineByteCount > 0, patternMode, sizesMode, rangesMode, recordMode, percentMode)
+	if splitModes > 1 {
+		return split.YouMustSpecifyOnlyOneOption
+	}
+	// 分割方法が指定されていない場合は環境変数のバイト数で分割する
+	// 優先順位はコマンドライン引数、環境変数、既定の行数 (split.DefaultCount) の順とする
+	// 時間による切り替えは行単位の分割とだけ組み合わせる
+	rotateMode := setFlags["rotate-interval"]
+	var rotateInterval time.Duration
+	if rotateMode {
+		rotateInterval, err = time.ParseDuration(*rotateIntervalStr)
+		if err != nil || rotateInterval <= 0 {
+			return fmt.Errorf("%w: %s", split.InvalidRotateInterval, *rotateIntervalStr)
+		}
+		if splitModes > 0 && lineCount == 0 {
+			return split.YouMustSpecifyOnlyOneOption
+		}
+	}
+	if value := os.Getenv(DefaultBytesEnv); splitModes == 0 && value != "" && !rotateMode {
+		byteCount, err = split.ParseByteSizeWith(value, units)
+		if err != nil {
+			return fmt.Errorf("%s: %w", DefaultBytesEnv, err)
+		}
+		if byteCount == 0 {
+			return fmt.Errorf("%w: %s=%s", split.InvalidSplitSize, DefaultBytesEnv, value)
+		}
+		byteMode = true
+	}
+	// 特定の分割方法でだけ用いるオプションは、他の分割方法では無視せずにエラーとする
+	// 分割方法の指定がない場合と --rotate-interval だけの場合も行単位で分割する
+	lineMode := lineCount > 0 || (splitModes == 0 && !byteMode)
+	if maxLineBytes > 0 && !lineMode {
+		return split.MaxLineBytesRequiresLines
+	}
+	if *ensureTrailingNewline && !lineMode {
+		return split.TrailingNewlineNeedsLines
+	}
+	if totalSize > 0 && (fileCount == 0 || (fileSplitType != split.ByFiles && fileSplitType != split.ByLineFiles)) {
+		return split.TotalSizeRequiresChunks
+	}
+	if *utf8Safe && !byteMode {
+		return split.UTF8SafeRequiresByteSplit
+	}
+	if minChunk > 0 && (fileCount == 0 || fileSplitType != split.ByFiles) {
+		return split.MinChunkRequiresChunks
+	}
+	var sizes []uint64
+	if sizesMode {
+		sizes, err = parseSizes(*sizesStr, units)
+		if err != nil {
+			return err
+		}
+	}
+	var ranges []split.LineRange
+	if rangesMode {
+		ranges, err = split.ParseLineRanges(*rangesStr)
+		if err != nil {
+			return err
+		}
+	}
+	var recordMarker []byte
+	if recordMode {
+		recordMarker, err = split.ParseRecordMarker(*recordMarkerStr)
+		if err != nil {
+			return err
+		}
+	} else if setFlags["records-per-file"] {
+		return fmt.Errorf("%w: --records-per-file requires --record-marker", split.InvalidRecordMarker)
+	}
+	var pattern *regexp.Regexp
+	if patternMode {
+		pattern, err = regexp.Compile(*separatorPattern)
+		if err != nil {
+			return fmt.Errorf("%w: %v", split.InvalidPattern, err)
+		}
+	} else if *suppressMatched {
+		return split.SuppressRequiresPattern
+	}
+
+	// 接頭辞は -p (--prefix)、最後の引数、既定の接頭辞の順に優先する
+	// -p が指定されていない場合、2つ以上の引数があれば最後の引数を接頭辞とする
+	// 接頭辞が未指定の場合と空文字列の場合は、どちらも split.GenFileName で既定の接頭辞となる
+	inputPaths := splitFlag.Args()
+	outputPrefix := ""
+	if setFlags["p"] || setFlags["prefix"] {
+		outputPrefix = prefix
+	} else if len(inputPaths) >= 2 {
+		outputPrefix = inputPaths[len(inputPaths)-1]
+		inputPaths = inputPaths[:len(inputPaths)-1]
+	}
+	if len(inputPaths) == 0 {
+		inputPaths = []string{"-"}
+	}
+	// "- myfile" は myfile を入力のつもりで書いた可能性があるため、接頭辞とした既存のファイルを知らせる
+	// 出力ファイルは myfile に接尾辞を付けた名前となり、myfile 自体は変更しない
+	positionalPrefix := !setFlags["p"] && !setFlags["prefix"] && len(splitFlag.Args()) >= 2
+	if positionalPrefix && outputPrefix != "" && slices.Contains(inputPaths, "-") && !*quiet {
+		if info, err := os.Stat(outputPrefix); err == nil && info.Mode().IsRegular() {
+			fmt.Fprintf(cli.Stderr, "warning: reading standard input and using the existing file '%s' as the prefix; use --prefix to make this explicit\n", outputPrefix)
+		}
+	}
+	// --prefix-from-input は入力ファイルの名前から接頭辞を求めるため、接頭辞の指定とは併用できない
+	// 出力ファイルは入力ファイルのディレクトリではなく、既定の接頭辞と同じく作業ディレクトリに作成する
+	if *prefixFromInput {
+		if setFlags["p"] || setFlags["prefix"] || len(splitFlag.Args()) >= 2 {
+			return split.YouMustSpecifyOnlyOneOption
+		}
+		if inputPath := inputPaths[0]; inputPath != "" && inputPath != "-" {
+			outputPrefix = filepath.Base(inputPath)
+			if !setFlags["suffix-separator"] {
+				outputPrefix += "."
+			}
+		}
 	}
 
-	var reader io.Reader
-	outputPrefix := "x"
+	// --output-pattern は出力ファイル名をすべて決めるため、接頭辞や時刻、ラベルによる名前とは併用できない
+	if setFlags["output-pattern"] {
+		if setFlags["p"] || setFlags["prefix"] || len(splitFlag.Args()) >= 2 || *prefixFromInput || rotateMode || rangesMode {
+			return split.YouMustSpecifyOnlyOneOption
+		}
+		if err := split.ValidateOutputPattern(*outputPattern); err != nil {
+			return err
+		}
+	}
 
-	// ファイルの指定がない場合やFILEが"-"の場合、 標準入力から読み込みを行う
-	if splitFlag.Arg(0) == "" || splitFlag.Arg(0) == "-" {
-		if fileCount > 0 {
-			return fmt.Errorf("%s", CannotDetermineFileSize)
+	// 複数のファイルが指定された場合は連結したものを分割する
+	// ファイルが "-" の場合、 標準入力から読み込みを行う
+	// 標準入力はサイズが分からないが、 -n の場合は split パッケージが一時ファイルに書き出して求める
+	// 出力ファイルの権限は入力ファイルの権限に合わせ、複数の場合はいずれの入力よりも広げない
+	// 標準入力のみの場合は os.Create と同じ 0666 とする
+	readers := make([]io.Reader, 0, len(inputPaths))
+	fileMode := os.FileMode(0)
+	for _, inputFilePath := range inputPaths {
+		if inputFilePath == "" || inputFilePath == "-" {
+			readers = append(readers, cli.Stdin)
+			continue
 		}
-		reader = cli.Stdin
-	} else {
-		inputFilePath := splitFlag.Arg(0)
-		outputPrefix = splitFlag.Arg(1)
 
 		inputFile, err := os.Open(inputFilePath)
 		if err != nil {
@@ -313,65 +773,485 @@ func (cli *CLI) Run(args []string) error {
 		}
 		defer inputFile.Close()
 
-		reader = inputFile
+		info, err := inputFile.Stat()
+		if err != nil {
+			return err
+		}
+		// ディレクトリは開けても読み込みで分かりにくいエラーになるため、先に確認する
+		if info.IsDir() {
+			return fmt.Errorf("%w: %s", split.InputIsDirectory, inputFilePath)
+		}
+		if info.Mode().IsRegular() {
+			if fileMode == 0 {
+				fileMode = info.Mode().Perm()
+			} else {
+				fileMode &= info.Mode().Perm()
+			}
+		}
+
+		readers = append(readers, inputFile)
 	}
 
-	splitter := NewSplitter(ByLines, DefaultCount, reader, outputPrefix)
+	// 単一のファイルはシーク可能なまま渡す
+	reader := readers[0]
+	if len(readers) > 1 {
+		reader = io.MultiReader(readers...)
+	}
+	// 追記を待ち続けるため、入力の末尾が分からない -n とは併用できない
+	// 割り込まれた場合は、それまでに読み込んだ内容を書き込んでから正常に終了する
+	if *follow {
+		if len(inputPaths) != 1 || inputPaths[0] == "" || inputPaths[0] == "-" {
+			return split.FollowRequiresSingleFile
+		}
+		if fileCount > 0 {
+			return split.FollowWithChunks
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		reader = split.NewFollowReader(ctx, reader, followInterval)
+	}
+	// 展開した内容を分割する
+	// 出力の圧縮 (-z) とは独立しているため、展開してから再び圧縮することもできる
+	switch *decompress {
+	case "":
+	case "gzip":
+		gzipReader, err := gzip.NewReader(reader)
+		if err != nil {
+			return fmt.Errorf("%w: %w", split.InvalidCompressedInput, err)
+		}
+		defer gzipReader.Close()
+		reader = gzipReader
+	default:
+		return fmt.Errorf("%w: %s", split.InvalidDecompression, *decompress)
+	}
+	reader, err = inputWindow(reader, inputOffset, inputLimit)
+	if err != nil {
+		return err
+	}
+	var trailer io.Reader
+	if trailerBytes > 0 {
+		reader, trailer, err = excludeTrailer(reader, trailerBytes)
+		if err != nil {
+			return err
+		}
+	}
+
+	// 割り込まれた場合は読み込みをやめるため、Splitter を作成する前に入力を割り込み可能にする
+	// --follow では割り込みを入力の終わりとして扱うため、分割は最後まで行う
+	ctx := context.Background()
+	if !*follow {
+		var stop context.CancelFunc
+		ctx, stop = signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		reader = interruptibleInput(ctx, reader)
+	}
 
-	if byteCount > 0 {
-		splitter = NewSplitter(ByBytes, byteCount, reader, outputPrefix)
+	splitter := split.NewSplitter(split.ByLines, split.DefaultCount, reader, outputPrefix)
+
+	if byteMode {
+		splitter = split.NewSplitter(split.ByBytes, byteCount, reader, outputPrefix)
 
 	} else if lineCount > 0 {
-		splitter = NewSplitter(ByLines, lineCount, reader, outputPrefix)
+		splitter = split.NewSplitter(split.ByLines, lineCount, reader, outputPrefix)
 
 	} else if fileCount > 0 {
-		splitter = NewSplitter(ByFiles, fileCount, reader, outputPrefix)
+		splitter = split.NewSplitter(fileSplitType, fileCount, reader, outputPrefix)
+
+	} else if lineByteCount > 0 {
+		splitter = split.NewSplitter(split.ByLineBytes, lineByteCount, reader, outputPrefix)
+
+	} else if patternMode {
+		splitter = split.NewPatternSplitter(pattern, reader, outputPrefix)
+
+	} else if sizesMode {
+		splitter = split.NewSizesSplitter(sizes, reader, outputPrefix)
+
+	} else if rangesMode {
+		splitter = split.NewRangesSplitter(ranges, reader, outputPrefix)
+
+	} else if recordMode {
+		splitter = split.NewRecordSplitter(recordMarker, *recordsPerFile, reader, outputPrefix)
+
+	} else if percentMode {
+		sizes, err := percentSizes(reader, *percent)
+		if err != nil {
+			return err
+		}
+		splitter = split.NewSizesSplitter(sizes, reader, outputPrefix)
+	}
+
+	// -l がない場合は時間だけで切り替える
+	if rotateMode {
+		if lineCount == 0 {
+			splitter = split.NewSplitter(split.ByLines, math.MaxUint64, reader, outputPrefix)
+		}
+		splitter.RotateInterval = rotateInterval
+		splitter.FileNamer = split.TimestampNamer(RotateTimestampLayout)
+	}
+
+	// 接頭辞が "-" の場合はファイルを作成せずに標準出力に書き込む
+	// 複数の出力を区別できないため、出力が1つに決まる分割方法に限る
+	if outputPrefix == "-" {
+		if !(fileCount == 1 && !byteMode && lineCount == 0) {
+			return split.StdoutRequiresSingleOutput
+		}
+		splitter.WriterFactory = func(name string) (io.WriteCloser, error) {
+			return nopWriteCloser{cli.Stdout}, nil
+		}
+	}
+
+	// tar のエントリは標準出力やコマンドへの書き込みとは併用できない
+	if *tarPath != "" && (outputPrefix == "-" || *filter != "") {
+		return split.YouMustSpecifyOnlyOneOption
+	}
+
+	splitter.Suffix = suffix
+	splitter.OutputPattern = *outputPattern
+	splitter.Gzip = gzipOutput
+	splitter.ElideEmptyFiles = elideEmptyFiles
+	splitter.Filter = *filter
+	splitter.FilterStdout = cli.Stdout
+	splitter.FilterStderr = cli.Stderr
+	splitter.Separator = separator
+	splitter.MultiByteSeparator = multiByteSeparator
+	splitter.SuppressMatched = *suppressMatched
+	splitter.EnsureTrailingNewline = *ensureTrailingNewline
+	splitter.UTF8Safe = *utf8Safe
+	splitter.HeaderLines = *headerLines
+	splitter.MaxLineBytes = maxLineBytes
+	splitter.LineEnding = lineEnding
+	splitter.TotalSize = totalSize
+	splitter.MinChunkSize = minChunk
+	if *checksums {
+		splitter.Checksum = *checksumAlgo
+	}
+	splitter.Unbuffered = unbuffered
+	splitter.FileMode = fileMode
+	splitter.NoCleanup = *noCleanup
+	splitter.CreateDirs = *createDirs
+	splitter.MaxFiles = *maxFiles
+	splitter.CreateRetries = *createRetries
+	splitter.Parallel = *parallel
+	splitter.NoClobber = *noClobber
+	// 標準出力に書き込む場合は名前を変更するファイルがない
+	// tar のエントリは閉じるまで書き込まないため、一時ファイルを経由する必要がない
+	splitter.Atomic = *atomic && outputPrefix != "-" && *tarPath == ""
+	if *verbose {
+		splitter.Verbose = cli.Stdout
+	}
+
+	// 出力ファイルを作成せずに、作成されるファイル名だけを出力する
+	if *dryRun {
+		names, err := splitter.OutputFileNames()
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			fmt.Fprintln(cli.Stdout, name)
+		}
+		return nil
+	}
+
+	// 入力の範囲を求められるのは、出力ファイルを順に連結すると入力となるバイト単位の分割方法に限る
+	if *manifestPath != "" {
+		switch splitter.SplitType() {
+		case split.ByBytes, split.ByFiles, split.BySizes:
+		default:
+			return split.ManifestRequiresByteSplit
+		}
+		if chunk > 0 || *headerLines > 0 {
+			return split.ManifestRequiresByteSplit
+		}
+	}
+
+	// JSON は標準出力に書き込むため、分割した内容や --verbose の出力を標準出力に書き込む場合とは併用できない
+	if *jsonOutput && (outputPrefix == "-" || chunk > 0 || *verbose) {
+		return split.YouMustSpecifyOnlyOneOption
+	}
+
+	// K/N の場合はファイルを作成せずに K 番目だけを標準出力に書き込む
+	if chunk > 0 {
+		return splitter.WriteChunk(cli.Stdout, chunk)
 	}
 
-	if err = splitter.Split(); err != nil {
+	// 進捗は出力ファイルを閉じるたびに累計で通知されるため、最後の値が合計となる
+	var bytesWritten, filesCreated uint64
+	if *summary {
+		splitter.Progress = func(written uint64, created uint64) {
+			bytesWritten, filesCreated = written, created
+		}
+	}
+
+	// 割り込まれた場合は、書き込み途中のファイルをそれまでの行で閉じてから失敗として終了する
+	splitter.KeepPartialOnCancel = !*follow
+	if *tarPath != "" {
+		err = splitToTar(ctx, splitter, *tarPath, fileMode, *noClobber, *noCleanup)
+	} else {
+		err = splitter.SplitContext(ctx)
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("%w: wrote %d files", split.Interrupted, len(splitter.Created()))
+		}
 		return err
 	}
 
+	if *manifestPath != "" {
+		if err := writeManifest(*manifestPath, splitter, inputOffset, *noClobber); err != nil {
+			return err
+		}
+	}
+	if *trailerFile && trailer != nil {
+		if err := writeTrailer(trailer, split.PrefixOrDefault(outputPrefix)+".trailer", fileMode, *noClobber); err != nil {
+			return err
+		}
+	}
+	// --min-chunk で減らした場合は、指定とファイル数が異なることを知らせる
+	if created := uint64(len(splitter.Created())); minChunk > 0 && splitter.SplitType() == split.ByFiles && created < fileCount && !*quiet {
+		fmt.Fprintf(cli.Stderr, "reduced the number of files from %d to %d for --min-chunk\n", fileCount, created)
+	}
+	if *summary {
+		fmt.Fprintf(cli.Stderr, "wrote %d files, %s total\n", filesCreated, formatByteSize(bytesWritten))
+	}
+	if *jsonOutput {
+		return writeJSONResult(cli.Stdout, splitter)
+	}
 	return nil
 }
 
-func main() {
-	cli := &CLI{
-		Stdout: os.Stdout,
-		Stderr: os.Stderr,
-		Stdin:  os.Stdin,
+// reader の先頭の offset バイトを読み飛ばし、その後の limit バイトだけを読み込む io.Reader を返す
+// limit が0の場合は末尾まで読み込む
+// ファイルのようなシーク可能な入力は、-n でサイズを求められるようにシーク可能なまま範囲を切り出す
+func inputWindow(reader io.Reader, offset uint64, limit uint64) (io.Reader, error) {
+	if offset == 0 && limit == 0 {
+		return reader, nil
 	}
+	if limit == 0 || limit > math.MaxInt64 {
+		limit = math.MaxInt64
+	}
+	offset = min(offset, math.MaxInt64)
 
-	if err := cli.Run(os.Args); err != nil {
-		panic(err)
+	if file, ok := reader.(interface {
+		io.ReaderAt
+		io.Seeker
+	}); ok {
+		current, err := file.Seek(0, io.SeekCurrent)
+		if err == nil {
+			size, err := file.Seek(0, io.SeekEnd)
+			if err != nil {
+				return nil, err
+			}
+			start := current + min(int64(offset), size-current)
+			return io.NewSectionReader(file, start, min(int64(limit), size-start)), nil
+		}
+	}
+
+	if _, err := io.CopyN(io.Discard, reader, int64(offset)); err != nil && err != io.EOF {
+		return nil, err
 	}
+	return io.LimitReader(reader, int64(limit)), nil
 }
 
-func getFileHash(filePath string) (string, error) {
-	file, err := os.Open(filePath)
+// シーク可能な reader の末尾の n バイトを除いた内容を読み込む io.Reader と、除いた n バイトを読み込む io.Reader を返す
+// 入力が n バイトに満たない場合はすべてを末尾として除く
+// 位置を指定して読めない入力では、末尾の io.Reader は分割の内容を読み終えてから読み込む必要がある
+func excludeTrailer(reader io.Reader, n uint64) (io.Reader, io.Reader, error) {
+	seeker, ok := reader.(io.Seeker)
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: --trailer-bytes requires a seekable input", split.CannotDetermineFileSize)
+	}
+	current, err := seeker.Seek(0, io.SeekCurrent)
 	if err != nil {
-		return "", err
+		return nil, nil, fmt.Errorf("%w: --trailer-bytes requires a seekable input", split.CannotDetermineFileSize)
 	}
-	defer file.Close()
+	end, err := seeker.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err := seeker.Seek(current, io.SeekStart); err != nil {
+		return nil, nil, err
+	}
+	bodySize := end - current - min(int64(min(n, math.MaxInt64)), end-current)
 
-	hash := md5.New()
-	if _, err := io.Copy(hash, file); err != nil {
-		return "", err
+	// -n でサイズを求められるように、位置を指定して読めるファイルはシーク可能なまま切り出す
+	if file, ok := reader.(io.ReaderAt); ok {
+		return io.NewSectionReader(file, current, bodySize), io.NewSectionReader(file, current+bodySize, end-current-bodySize), nil
 	}
+	return io.LimitReader(reader, bodySize), reader, nil
+}
 
-	return fmt.Sprintf("%x", hash.Sum(nil)), nil
+// trailer の内容を name のファイルに書き込む
+// 権限は出力ファイルと同じく fileMode とし、0の場合は 0666 とする
+// noClobber の場合は既存のファイルを上書きしない
+func writeTrailer(trailer io.Reader, name string, fileMode os.FileMode, noClobber bool) error {
+	trailerFile, err := split.CreateFile(name, fileMode, noClobber)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(trailerFile, trailer); err != nil {
+		trailerFile.Close()
+		return err
+	}
+	return trailerFile.Close()
 }
 
-func compareFileHashes(filePath1, filePath2 string) (bool, error) {
-	hash1, err := getFileHash(filePath1)
+// 出力ファイルを作成する代わりに、tarPath の tar アーカイブのエントリとして分割する
+// エントリの名前は出力ファイル名とし、失敗した場合は noCleanup でなければアーカイブを削除する
+// noClobber の場合は既存のアーカイブを上書きしない
+func splitToTar(ctx context.Context, splitter *split.Splitter, tarPath string, fileMode os.FileMode, noClobber bool, noCleanup bool) error {
+	tarFile, err := split.CreateFile(tarPath, 0, noClobber)
 	if err != nil {
-		return false, err
+		return err
+	}
+	archive := split.NewTarArchive(tarFile)
+	archive.Mode = fileMode
+	splitter.WriterFactory = archive.Create
+
+	// 割り込まれた場合は書き込んだエントリまでの有効なアーカイブとして残す
+	err = splitter.SplitContext(ctx)
+	if err == nil || ctx.Err() != nil {
+		if closeErr := archive.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if closeErr := tarFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil && !noCleanup && ctx.Err() == nil {
+		os.Remove(tarPath)
+	}
+	return err
+}
+
+// パイプや端末のように読み込みを待つ可能性のある入力を、ctx がキャンセルされると待たずに ctx.Err() を返す io.Reader にする
+// シーク可能な入力は待たずに読み込めるため、シーク可能なまま reader を返す
+func interruptibleInput(ctx context.Context, reader io.Reader) io.Reader {
+	if seeker, ok := reader.(io.Seeker); ok {
+		if _, err := seeker.Seek(0, io.SeekCurrent); err == nil {
+			return reader
+		}
+	}
+	return &interruptibleReader{ctx: ctx, reader: reader}
+}
+
+// interruptibleReader は別の goroutine で読み込み、ctx がキャンセルされた場合はその結果を待たない
+// 待たなかった読み込みの結果は捨て、以降の Read はすべて ctx.Err() を返す
+type interruptibleReader struct {
+	ctx    context.Context
+	reader io.Reader
+	buffer []byte
+}
+
+type readResult struct {
+	n   int
+	err error
+}
+
+func (r *interruptibleReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	// 待たなかった読み込みが p に書き込まないように、別のバッファに読み込む
+	if len(r.buffer) < len(p) {
+		r.buffer = make([]byte, len(p))
 	}
+	buffer := r.buffer[:len(p)]
+	done := make(chan readResult, 1)
+	go func() {
+		n, err := r.reader.Read(buffer)
+		done <- readResult{n, err}
+	}()
 
-	hash2, err := getFileHash(filePath2)
+	select {
+	case result := <-done:
+		return copy(p, buffer[:result.n]), result.err
+	case <-r.ctx.Done():
+		return 0, r.ctx.Err()
+	}
+}
+
+// jsonResult は --json で出力する分割の結果
+type jsonResult struct {
+	Files      []jsonFile `json:"files"`
+	TotalBytes uint64     `json:"total_bytes"`
+	SplitType  string     `json:"split_type"`
+}
+
+type jsonFile struct {
+	Name  string `json:"name"`
+	Bytes uint64 `json:"bytes"`
+}
+
+// 分割した結果を JSON で w に書き込む
+func writeJSONResult(w io.Writer, splitter *split.Splitter) error {
+	result := jsonResult{Files: []jsonFile{}, SplitType: splitter.SplitType().String()}
+	for _, file := range splitter.CreatedFiles() {
+		result.Files = append(result.Files, jsonFile{Name: file.Name, Bytes: file.Bytes})
+		result.TotalBytes += file.Bytes
+	}
+	return json.NewEncoder(w).Encode(result)
+}
+
+// 出力ファイルごとに、その内容が入力の何バイト目から何バイト目の手前までかを TSV で path に書き込む
+// 出力ファイルは作成した順に入力を連続して区切るため、Gzip の場合も圧縮前のバイト数を積み上げて求める
+// 範囲は --input-offset の前を含む元の入力の先頭からとする
+// noClobber の場合は既存のファイルを上書きしない
+func writeManifest(path string, splitter *split.Splitter, offset uint64, noClobber bool) error {
+	manifest, err := split.CreateFile(path, 0, noClobber)
 	if err != nil {
-		return false, err
+		return err
 	}
+	start := offset
+	for _, file := range splitter.CreatedFiles() {
+		if _, err := fmt.Fprintf(manifest, "%s\t%d\t%d\n", file.Name, start, start+file.Bytes); err != nil {
+			manifest.Close()
+			return err
+		}
+		start += file.Bytes
+	}
+	return manifest.Close()
+}
+
+// prefix の出力ファイルを連結して output に書き込む
+// 途中で失敗した場合は書き込み途中の output を削除し、noClobber の場合は既存の output を上書きしない
+func (cli *CLI) join(prefix string, output string, suffix split.SuffixOptions, noClobber bool) error {
+	if output == "-" {
+		_, err := split.Join(cli.Stdout, prefix, suffix)
+		return err
+	}
+	_, err := split.JoinFile(output, prefix, suffix, noClobber)
+	return err
+}
+
+// バイト数を 1000 ごとの単位で読みやすい形式にする
+func formatByteSize(size uint64) string {
+	if size < 1000 {
+		return fmt.Sprintf("%d B", size)
+	}
+	value := float64(size)
+	unit := ""
+	for _, u := range []string{"KB", "MB", "GB", "TB", "PB", "EB"} {
+		if value < 1000 {
+			break
+		}
+		value /= 1000
+		unit = u
+	}
+	return fmt.Sprintf("%.1f %s", value, unit)
+}
 
-	return hash1 == hash2, nil
+func main() {
+	cli := &CLI{
+		Stdout: os.Stdout,
+		Stderr: os.Stderr,
+		Stdin:  os.Stdin,
+	}
+
+	err := cli.Run(os.Args)
+	// 引数の誤りは flag パッケージが出力済みのため、それ以外のエラーを出力する
+	var usageErr *usageError
+	if err != nil && !errors.As(err, &usageErr) {
+		fmt.Fprintf(cli.Stderr, "%s: %s\n", filepath.Base(os.Args[0]), err)
+	}
+	os.Exit(exitCode(err))
 }
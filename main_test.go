@@ -1,173 +1,2706 @@
 package main
 
 import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
 	"testing"
+
+	"split/split"
 )
 
-func TestParseByteSize(t *testing.T) {
+func TestCLIRunSuffixLength(t *testing.T) {
+	outputDir := t.TempDir() + "/"
+	inputFilePath := outputDir + "input.txt"
+	if err := os.WriteFile(inputFilePath, []byte("1\n2\n3\n"), 0644); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	cli := &CLI{Stdin: os.Stdin, Stdout: io.Discard, Stderr: io.Discard}
+	if err := cli.Run([]string{"split", "-a", "4", "-l", "1", inputFilePath, outputDir + "x"}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	for _, name := range []string{"xaaaa", "xaaab", "xaaac"} {
+		if _, err := os.Stat(outputDir + name); err != nil {
+			t.Errorf("Expected output file %s: %s", name, err)
+		}
+	}
+
+	err := cli.Run([]string{"split", "--suffix-length", "1", "-b", "1", inputFilePath, outputDir + "y"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	err = cli.Run([]string{"split", "-a", "-1", inputFilePath, outputDir + "z"})
+	if err == nil {
+		t.Errorf("Expected error for negative suffix length")
+	}
+}
+
+func TestCLIRunNumericSuffixes(t *testing.T) {
+	outputDir := t.TempDir() + "/"
+	inputFilePath := outputDir + "input.txt"
+	if err := os.WriteFile(inputFilePath, []byte("1\n2\n3\n"), 0644); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	cli := &CLI{Stdin: os.Stdin, Stdout: io.Discard, Stderr: io.Discard}
+	if err := cli.Run([]string{"split", "-d", "-l", "1", inputFilePath, outputDir + "x"}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	for i, name := range []string{"x00", "x01", "x02"} {
+		data, err := os.ReadFile(outputDir + name)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if expected := fmt.Sprintf("%d\n", i+1); string(data) != expected {
+			t.Errorf("Unexpected content of %s: got %q, expected %q", name, data, expected)
+		}
+	}
+}
+
+func TestCLIRunNumericSuffixesFrom(t *testing.T) {
+	tests := []struct {
+		valid         bool
+		args          []string
+		expectedNames []string
+	}{
+		{true, []string{"--numeric-suffixes=0"}, []string{"x00", "x01", "x02"}},
+		{true, []string{"--numeric-suffixes"}, []string{"x00", "x01", "x02"}},
+		{true, []string{"--numeric-suffixes=5"}, []string{"x05", "x06", "x07"}},
+		{true, []string{"-a", "3", "--numeric-suffixes=100"}, []string{"x100", "x101", "x102"}},
+		{false, []string{"--numeric-suffixes=100"}, nil},
+		{true, []string{"-x"}, []string{"x00", "x01", "x02"}},
+		{true, []string{"--hex-suffixes=e"}, []string{"x0e", "x0f", "x10"}},
+		{true, []string{"-a", "4", "--hex-suffixes=fff"}, []string{"x0fff", "x1000", "x1001"}},
+		{false, []string{"--hex-suffixes=100"}, nil},
+		{false, []string{"-d", "-x"}, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(strings.Join(test.args, " "), func(t *testing.T) {
+			outputDir := t.TempDir() + "/"
+			inputFilePath := outputDir + "input.txt"
+			if err := os.WriteFile(inputFilePath, []byte("1\n2\n3\n"), 0644); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+
+			args := append([]string{"split", "-l", "1"}, test.args...)
+			args = append(args, inputFilePath, outputDir+"x")
+			cli := &CLI{Stdin: os.Stdin, Stdout: io.Discard, Stderr: io.Discard}
+			err := cli.Run(args)
+			if err != nil && test.valid {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if err == nil && !test.valid {
+				t.Fatalf("Unexpected result : error is nil")
+			}
+
+			for _, name := range test.expectedNames {
+				if _, err := os.Stat(outputDir + name); err != nil {
+					t.Errorf("Expected output file %s: %s", name, err)
+				}
+			}
+		})
+	}
+}
+
+func TestCLIRunChunksNumericSuffixesFrom(t *testing.T) {
+	outputDir := t.TempDir() + "/"
+	inputFilePath := outputDir + "input.txt"
+	if err := os.WriteFile(inputFilePath, []byte("123456789"), 0644); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	cli := &CLI{Stdin: os.Stdin, Stdout: io.Discard, Stderr: io.Discard}
+	if err := cli.Run([]string{"split", "-n", "3", "--numeric-suffixes=1", inputFilePath, outputDir + "x"}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	for i, name := range []string{"x01", "x02", "x03"} {
+		data, err := os.ReadFile(outputDir + name)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if expected := "123456789"[i*3 : i*3+3]; string(data) != expected {
+			t.Errorf("Unexpected content of %s: got %q, expected %q", name, data, expected)
+		}
+	}
+	if _, err := os.Stat(outputDir + "x00"); !os.IsNotExist(err) {
+		t.Errorf("Unexpected output file x00: %v", err)
+	}
+}
+
+func TestNumericSuffixesFlagSet(t *testing.T) {
+	for _, value := range []string{"-1", "abc", "1.5"} {
+		f := &numericSuffixesFlag{}
+		if err := f.Set(value); err == nil {
+			t.Errorf("Expected error for %q", value)
+		}
+	}
+}
+
+func TestHexSuffixesFlagSet(t *testing.T) {
+	for _, value := range []string{"-1", "xyz", "0x10"} {
+		f := &numericSuffixesFlag{base: 16}
+		if err := f.Set(value); err == nil {
+			t.Errorf("Expected error for %q", value)
+		}
+	}
+
+	f := &numericSuffixesFlag{base: 16}
+	if err := f.Set("1f"); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if f.start != 0x1f || f.String() != "1f" {
+		t.Errorf("Unexpected start: got %d (%s), expected %d", f.start, f, 0x1f)
+	}
+}
+
+func TestParseChunkCount(t *testing.T) {
+	tests := []struct {
+		valid             bool
+		input             string
+		expectedSplitType split.SplitType
+		expectedChunk     uint64
+		expectedCount     uint64
+	}{
+		{true, "3", split.ByFiles, 0, 3},
+		{true, "l/3", split.ByLineFiles, 0, 3},
+		{true, "r/3", split.ByRoundRobin, 0, 3},
+		{true, "L/3", split.ByBalancedLines, 0, 3},
+		{true, "1/3", split.ByFiles, 1, 3},
+		{true, "3/3", split.ByFiles, 3, 3},
+		{false, "0/3", 0, 0, 0},
+		{false, "4/3", 0, 0, 0},
+		{false, "l/", 0, 0, 0},
+		{false, "x/3", 0, 0, 0},
+		{false, "3K", 0, 0, 0},
+		{false, `C:\out\x`, 0, 0, 0},
+		{false, "C:/3", 0, 0, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			splitType, chunk, count, err := parseChunkCount(test.input)
+			if err != nil && test.valid {
+				t.Errorf("Unexpected error: %s", err)
+			}
+			if err == nil && !test.valid {
+				t.Errorf("Unexpected result : error is nil, result is %v %v %v", splitType, chunk, count)
+			}
+			if test.valid && (splitType != test.expectedSplitType || chunk != test.expectedChunk || count != test.expectedCount) {
+				t.Errorf("Unexpected result: got %v %d %d, expected %v %d %d", splitType, chunk, count, test.expectedSplitType, test.expectedChunk, test.expectedCount)
+			}
+		})
+	}
+}
+
+func TestCLIRunLineBytes(t *testing.T) {
+	outputDir := t.TempDir() + "/"
+	inputFilePath := outputDir + "input.txt"
+	if err := os.WriteFile(inputFilePath, []byte("ab\ncd\nef\n"), 0644); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	cli := &CLI{Stdin: os.Stdin, Stdout: io.Discard, Stderr: io.Discard}
+	if err := cli.Run([]string{"split", "-C", "6", inputFilePath, outputDir + "x"}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	data, err := os.ReadFile(outputDir + "xaa")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if string(data) != "ab\ncd\n" {
+		t.Errorf("Unexpected content of xaa: got %q", data)
+	}
+
+	err = cli.Run([]string{"split", "--line-bytes", "1K", "-l", "2", inputFilePath, outputDir + "y"})
+	if err == nil || err.Error() != string(split.YouMustSpecifyOnlyOneOption) {
+		t.Errorf("Unexpected error: got %v, expected %s", err, split.YouMustSpecifyOnlyOneOption)
+	}
+}
+
+func TestCLIRunByteCount(t *testing.T) {
+	tests := []struct {
+		valid         bool
+		args          []string
+		expectedNames []string
+	}{
+		{false, []string{"-b", "0"}, nil},
+		{true, []string{}, []string{"xaa"}},
+		{true, []string{"-b", "1K"}, []string{"xaa", "xab"}},
+	}
+
+	for _, test := range tests {
+		t.Run(strings.Join(test.args, " "), func(t *testing.T) {
+			outputDir := t.TempDir() + "/"
+			inputFilePath := outputDir + "input.txt"
+			if err := os.WriteFile(inputFilePath, []byte(strings.Repeat("abc\n", 300)), 0644); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+
+			args := append([]string{"split"}, test.args...)
+			args = append(args, inputFilePath, outputDir+"x")
+			cli := &CLI{Stdin: os.Stdin, Stdout: io.Discard, Stderr: io.Discard}
+			err := cli.Run(args)
+			if err != nil && test.valid {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if err == nil && !test.valid {
+				t.Fatalf("Unexpected result : error is nil")
+			}
+			if !test.valid && !strings.HasPrefix(err.Error(), string(split.InvalidSplitSize)) {
+				t.Errorf("Unexpected error: got %s, expected %s", err, split.InvalidSplitSize)
+			}
+
+			entries, err := os.ReadDir(outputDir)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			names := []string{}
+			for _, entry := range entries {
+				if entry.Name() != "input.txt" {
+					names = append(names, entry.Name())
+				}
+			}
+			if strings.Join(names, ",") != strings.Join(test.expectedNames, ",") {
+				t.Errorf("Unexpected output files: got %v, expected %v", names, test.expectedNames)
+			}
+		})
+	}
+}
+
+func TestCLIRunVerbose(t *testing.T) {
+	outputDir := t.TempDir() + "/"
+	inputFilePath := outputDir + "input.txt"
+	if err := os.WriteFile(inputFilePath, []byte("1\n2\n3\n"), 0644); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	stdout := new(bytes.Buffer)
+	cli := &CLI{Stdin: os.Stdin, Stdout: stdout, Stderr: io.Discard}
+	if err := cli.Run([]string{"split", "--verbose", "-l", "1", inputFilePath, outputDir + "x"}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	expected := fmt.Sprintf("creating file '%[1]sxaa'\ncreating file '%[1]sxab'\ncreating file '%[1]sxac'\n", outputDir)
+	if stdout.String() != expected {
+		t.Errorf("Unexpected output: got %q, expected %q", stdout.String(), expected)
+	}
+}
+
+func TestCLIRunFilter(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available")
+	}
+
+	outputDir := t.TempDir() + "/"
+	inputFilePath := outputDir + "input.txt"
+	if err := os.WriteFile(inputFilePath, []byte("1\n2\n3\n"), 0644); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	// コマンドの出力は CLI の Stdout と Stderr に書き込む
+	stdout := new(bytes.Buffer)
+	stderr := new(bytes.Buffer)
+	cli := &CLI{Stdin: os.Stdin, Stdout: stdout, Stderr: stderr}
+	if err := cli.Run([]string{"split", "--filter", `cat; echo "$FILE" >&2`, "-l", "2", inputFilePath, outputDir + "x"}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if expected := "1\n2\n3\n"; stdout.String() != expected {
+		t.Errorf("Unexpected output: got %q, expected %q", stdout.String(), expected)
+	}
+	if expected := fmt.Sprintf("%[1]sxaa\n%[1]sxab\n", outputDir); stderr.String() != expected {
+		t.Errorf("Unexpected error output: got %q, expected %q", stderr.String(), expected)
+	}
+}
+
+func TestParseSeparator(t *testing.T) {
 	tests := []struct {
 		valid    bool
 		input    string
-		expected uint64
-	}{
-		{true, "1KB", 1000},
-		{true, "1kB", 1000},
-		{true, "20MB", 20 * 1000 * 1000},
-		{true, "20mB", 20 * 1000 * 1000},
-		{true, "300GB", 300 * 1000 * 1000 * 1000},
-		{true, "4000TB", 4000 * 1000 * 1000 * 1000 * 1000},
-		{true, "16383PB", 16383 * 1000 * 1000 * 1000 * 1000 * 1000},
-		{true, "1K", 1024},
-		{true, "1k", 1024},
-		{true, "1KiB", 1024},
-		{true, "1kiB", 1024},
-		{true, "20M", 20 * 1024 * 1024},
-		{true, "20MiB", 20 * 1024 * 1024},
-		{true, "20miB", 20 * 1024 * 1024},
-		{true, "300G", 300 * 1024 * 1024 * 1024},
-		{true, "4000T", 4000 * 1024 * 1024 * 1024 * 1024},
-		{true, "16383P", 16383 * 1024 * 1024 * 1024 * 1024 * 1024},
-		{true, "12345", 12345},
-		{false, "16384P", 0},
-		{false, "KB", 0},
-		{false, "K", 0},
-		{false, "Ki", 0},
-		{false, "ki", 0},
-		{false, "10B", 0},
-		{false, "10KA", 0},
-		{false, "10Ki", 0},
-		{false, "10ki", 0},
-		{false, "10g", 0},
-		{false, "10giB", 0},
-		{false, "10gB", 0},
+		expected byte
+	}{
+		{true, `\0`, 0},
+		{true, `\t`, '\t'},
+		{true, `\n`, '\n'},
+		{true, ",", ','},
+		{false, "", 0},
+		{false, ",,", 0},
+		{false, "é", 0},
 	}
 
 	for _, test := range tests {
 		t.Run(test.input, func(t *testing.T) {
-			result, err := parseByteSize(test.input)
+			result, err := parseSeparator(test.input)
 			if err != nil && test.valid {
-				t.Errorf("Error parsing byte size for input %s: %v", test.input, err)
+				t.Errorf("Unexpected error: %s", err)
 			}
 			if err == nil && !test.valid {
 				t.Errorf("Unexpected result : error is nil, result is %v", result)
 			}
 			if result != test.expected && test.valid {
-				t.Errorf("Unexpected result for input %s: got %d, expected %d", test.input, result, test.expected)
+				t.Errorf("Unexpected result: got %q, expected %q", result, test.expected)
 			}
 		})
 	}
 }
 
-func TestGenFileName(t *testing.T) {
+func TestCLIRunSeparator(t *testing.T) {
+	outputDir := t.TempDir() + "/"
+	inputFilePath := outputDir + "input.txt"
+	if err := os.WriteFile(inputFilePath, []byte("a\nb\x00c\x00d"), 0644); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	cli := &CLI{Stdin: os.Stdin, Stdout: io.Discard, Stderr: io.Discard}
+	if err := cli.Run([]string{"split", "-t", `\0`, "-l", "1", inputFilePath, outputDir + "x"}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	for name, expected := range map[string]string{"xaa": "a\nb\x00", "xab": "c\x00", "xac": "d"} {
+		data, err := os.ReadFile(outputDir + name)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if string(data) != expected {
+			t.Errorf("Unexpected content of %s: got %q, expected %q", name, data, expected)
+		}
+	}
+
+	err := cli.Run([]string{"split", "-t", "ab", "-l", "1", inputFilePath, outputDir + "y"})
+	if err == nil || !strings.HasPrefix(err.Error(), string(split.InvalidSeparator)) {
+		t.Errorf("Unexpected error: got %v, expected %s", err, split.InvalidSeparator)
+	}
+}
+
+func TestParseMultiByteSeparator(t *testing.T) {
 	tests := []struct {
-		valid     bool
-		prefix    string
-		index     uint64
-		fileCount uint64
-		splitType SplitType
-		expected  string
+		valid    bool
+		input    string
+		expected []byte
 	}{
-		{true, "prefix", 0, 1, ByFiles, "prefixaa"},
-		{true, "prefix", 1, 2, ByFiles, "prefixab"},
-		{true, "", 0, 26, ByFiles, "xaa"},
-		{true, "", 0, 676, ByFiles, "xaa"},
-		{true, "", 25, 27, ByFiles, "xaz"},
-		{true, "", 26, 27, ByFiles, "xba"},
-		{true, "", 675, 676, ByFiles, "xzz"},
-		{true, "", 0, 677, ByFiles, "xaaa"},
-		{true, "", 676, 677, ByFiles, "xbaa"},
-		{true, "", 17575, 17576, ByFiles, "xzzz"},
-		{true, "", 0, 17577, ByFiles, "xaaaa"},
-		{true, "", 17576, 17577, ByFiles, "xbaaa"},
-		{false, "", 0, 0, ByFiles, ""},
-		{false, "", 1, 0, ByFiles, ""},
-		{true, "prefix", 0, 0, ByBytes, "prefixaa"},
-		{true, "", 1, 0, ByBytes, "xab"},
-		{true, "", 649, 0, ByBytes, "xyz"},
-		{true, "", 650, 0, ByBytes, "xzaaa"},
-		{true, "", 651, 0, ByBytes, "xzaab"},
-		{true, "", 676, 0, ByBytes, "xzaba"},
-		{true, "", 17549, 0, ByBytes, "xzyzz"},
-		{true, "", 17550, 0, ByBytes, "xzzaaaa"},
-		{true, "", 456949, 0, ByBytes, "xzzyzzz"},
+		{true, `\r\n\r\n`, []byte("\r\n\r\n")},
+		{true, `\r\n`, []byte("\r\n")},
+		{true, "--", []byte("--")},
+		{true, `a\\b\0`, []byte("a\\b\x00")},
+		{true, `\t`, []byte("\t")},
+		{false, "", nil},
+		{false, `\`, nil},
+		{false, `\x`, nil},
 	}
 
 	for _, test := range tests {
-		t.Run(fmt.Sprintf("splitType: %v index: %d fileCount: %d", test.splitType, test.index, test.fileCount), func(t *testing.T) {
-			fileName, err := genFileName(test.prefix, test.index, test.fileCount, test.splitType)
+		t.Run(test.input, func(t *testing.T) {
+			result, err := parseMultiByteSeparator(test.input)
 			if err != nil && test.valid {
 				t.Errorf("Unexpected error: %s", err)
 			}
-			if fileName != test.expected && test.valid {
-				t.Errorf("Unexpected result: got %s, expected %s", fileName, test.expected)
-			}
-		})
-	}
-}
-
-func TestSplitterSplit(t *testing.T) {
-	wd, _ := os.Getwd()
-	wd += "/"
-	inputTestFileDir := wd + "testfiles/input/"
-	outputTestFileDir := wd + "testfiles/output/"
-	tests := []struct {
-		name                string
-		splitType           SplitType
-		count               uint64
-		inputFileName       string
-		outputFilePrefix    string
-		expectedOutputNames []string
-	}{
-		{
-			name:                "split by bytes",
-			splitType:           ByBytes,
-			count:               50,
-			inputFileName:       "sample.txt",
-			outputFilePrefix:    "bytes_",
-			expectedOutputNames: []string{"bytes_aa", "bytes_ab", "bytes_ac", "bytes_ad", "bytes_ae", "bytes_af", "bytes_ag", "bytes_ah"},
-		},
-		{
-			name:                "split by lines",
-			splitType:           ByLines,
-			count:               2,
-			inputFileName:       "sample.txt",
-			outputFilePrefix:    "lines_",
-			expectedOutputNames: []string{"lines_aa", "lines_ab", "lines_ac"},
-		},
-		{
-			name:                "split by files",
-			splitType:           ByFiles,
-			count:               3,
-			inputFileName:       "sample.txt",
-			outputFilePrefix:    "files_",
-			expectedOutputNames: []string{"files_aa", "files_ab", "files_ac"},
-		},
+			if err == nil && !test.valid {
+				t.Errorf("Unexpected result : error is nil, result is %v", result)
+			}
+			if !bytes.Equal(result, test.expected) && test.valid {
+				t.Errorf("Unexpected result: got %q, expected %q", result, test.expected)
+			}
+		})
+	}
+}
+
+func TestCLIRunMultiByteSeparator(t *testing.T) {
+	outputDir := t.TempDir() + "/"
+	inputFilePath := outputDir + "input.txt"
+	if err := os.WriteFile(inputFilePath, []byte("GET /\r\nHost: a\r\n\r\nGET /b\r\n\r\nGET /c\r\n\r\n"), 0644); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	cli := &CLI{Stdin: os.Stdin, Stdout: io.Discard, Stderr: io.Discard}
+	if err := cli.Run([]string{"split", "--separator", `\r\n\r\n`, "-l", "2", inputFilePath, outputDir + "x"}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	for name, expected := range map[string]string{"xaa": "GET /\r\nHost: a\r\n\r\nGET /b\r\n\r\n", "xab": "GET /c\r\n\r\n"} {
+		data, err := os.ReadFile(outputDir + name)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if string(data) != expected {
+			t.Errorf("Unexpected content of %s: got %q, expected %q", name, data, expected)
+		}
+	}
+
+	if err := cli.Run([]string{"split", "--separator", "--", "-t", `\0`, inputFilePath, outputDir + "y"}); !errors.Is(err, split.YouMustSpecifyOnlyOneOption) {
+		t.Errorf("Unexpected error: got %v, expected %s", err, split.YouMustSpecifyOnlyOneOption)
+	}
+}
+
+func TestCLIRunStdoutPrefix(t *testing.T) {
+	outputDir := t.TempDir() + "/"
+	inputFilePath := outputDir + "input.txt"
+	input := "1\n2\n3\n"
+	if err := os.WriteFile(inputFilePath, []byte(input), 0644); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	for _, chunkCount := range []string{"1", "l/1", "r/1"} {
+		t.Run(chunkCount, func(t *testing.T) {
+			stdout := new(bytes.Buffer)
+			cli := &CLI{Stdin: os.Stdin, Stdout: stdout, Stderr: io.Discard}
+			if err := cli.Run([]string{"split", "-n", chunkCount, inputFilePath, "-"}); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if stdout.String() != input {
+				t.Errorf("Unexpected output: got %q, expected %q", stdout.String(), input)
+			}
+		})
+	}
+
+	for _, args := range [][]string{{"-n", "2"}, {"-l", "1"}, {"-b", "1"}} {
+		t.Run(strings.Join(args, " "), func(t *testing.T) {
+			cli := &CLI{Stdin: os.Stdin, Stdout: io.Discard, Stderr: io.Discard}
+			err := cli.Run(append(append([]string{"split"}, args...), inputFilePath, "-"))
+			if err == nil || err.Error() != string(split.StdoutRequiresSingleOutput) {
+				t.Errorf("Unexpected error: got %v, expected %s", err, split.StdoutRequiresSingleOutput)
+			}
+		})
+	}
+
+	entries, err := os.ReadDir(outputDir)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("Unexpected files were created: %v", entries)
+	}
+}
+
+func TestCLIRunExtractChunk(t *testing.T) {
+	outputDir := t.TempDir() + "/"
+	inputFilePath := outputDir + "input.txt"
+	if err := os.WriteFile(inputFilePath, []byte("0123456789"), 0644); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	tests := []struct {
+		chunk    string
+		expected string
+	}{
+		{"1/3", "0123"},
+		{"2/3", "456"},
+		{"3/3", "789"},
+	}
+	for _, test := range tests {
+		t.Run(test.chunk, func(t *testing.T) {
+			stdout := new(bytes.Buffer)
+			cli := &CLI{Stdin: os.Stdin, Stdout: stdout, Stderr: io.Discard}
+			if err := cli.Run([]string{"split", "-n", test.chunk, inputFilePath, outputDir + "x"}); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if stdout.String() != test.expected {
+				t.Errorf("Unexpected output: got %q, expected %q", stdout.String(), test.expected)
+			}
+		})
+	}
+
+	entries, err := os.ReadDir(outputDir)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("Unexpected files were created: %v", entries)
+	}
+}
+
+func TestCLIRunExitCode(t *testing.T) {
+	outputDir := t.TempDir() + "/"
+	inputFilePath := outputDir + "input.txt"
+	if err := os.WriteFile(inputFilePath, []byte("1\n"), 0644); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected int
+	}{
+		{"success", []string{"-l", "1", inputFilePath, outputDir + "x"}, ExitSuccess},
+		{"help", []string{"-h"}, ExitSuccess},
+		{"unknown flag", []string{"--no-such-flag", inputFilePath}, ExitUsage},
+		{"invalid flag value", []string{"-l", "abc", inputFilePath}, ExitUsage},
+		{"missing input file", []string{outputDir + "missing.txt"}, ExitFailure},
 	}
+
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			inputFile, _ := os.Open(inputTestFileDir + test.inputFileName)
-			defer inputFile.Close()
+			stderr := new(bytes.Buffer)
+			cli := &CLI{Stdin: os.Stdin, Stdout: io.Discard, Stderr: stderr}
+			err := cli.Run(append([]string{"split"}, test.args...))
+			if test.name != "success" && err == nil {
+				t.Fatalf("Unexpected result : error is nil")
+			}
+			if code := exitCode(err); code != test.expected {
+				t.Errorf("Unexpected exit code: got %d, expected %d (error: %v)", code, test.expected, err)
+			}
+			if test.expected == ExitUsage && !strings.Contains(stderr.String(), "Usage:") {
+				t.Errorf("Usage was not printed to stderr: %q", stderr.String())
+			}
+		})
+	}
+}
 
-			splitter := NewSplitter(test.splitType, test.count, inputFile, test.outputFilePrefix)
-			splitter.Split()
+func TestCLIRunMultipleInputs(t *testing.T) {
+	outputDir := t.TempDir() + "/"
+	firstPath := outputDir + "first.txt"
+	secondPath := outputDir + "second.txt"
+	if err := os.WriteFile(firstPath, []byte("1\n2"), 0644); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if err := os.WriteFile(secondPath, []byte("3\n4\n"), 0644); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
 
-			for _, expectedOutputName := range test.expectedOutputNames {
-				expectedOutputFilePath := outputTestFileDir + expectedOutputName
-				outputFilePath := wd + expectedOutputName
-				defer os.Remove(outputFilePath)
+	tests := []struct {
+		name     string
+		args     []string
+		stdin    string
+		prefix   string
+		expected []string
+	}{
+		{"lines", []string{"-l", "1", firstPath, secondPath, outputDir + "l"}, "", "l", []string{"1\n", "23\n", "4\n"}},
+		{"chunks", []string{"-n", "3", firstPath, secondPath, outputDir + "n"}, "", "n", []string{"1\n2", "3\n", "4\n"}},
+		{"stdin", []string{"-l", "1", firstPath, "-", secondPath, outputDir + "s"}, "x\ny", "s", []string{"1\n", "2x\n", "y3\n", "4\n"}},
+		{"prefix flag", []string{"-l", "1", "-p", outputDir + "p", firstPath, secondPath}, "", "p", []string{"1\n", "23\n", "4\n"}},
+	}
 
-				ok, err := compareFileHashes(expectedOutputFilePath, outputFilePath)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cli := &CLI{Stdin: strings.NewReader(test.stdin), Stdout: io.Discard, Stderr: io.Discard}
+			if err := cli.Run(append([]string{"split"}, test.args...)); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			for i, expected := range test.expected {
+				name := outputDir + test.prefix + []string{"aa", "ab", "ac", "ad"}[i]
+				data, err := os.ReadFile(name)
 				if err != nil {
-					t.Errorf("Unexpected error: %s", err)
-					return
+					t.Fatalf("Unexpected error: %s", err)
 				}
-				if !ok {
-					t.Errorf("The result of comparing %v and %v is not as expected.", expectedOutputFilePath, outputFilePath)
-					return
+				if string(data) != expected {
+					t.Errorf("Unexpected content of %s: got %q, expected %q", name, data, expected)
+				}
+			}
+		})
+	}
+}
+
+func TestCLIRunDryRun(t *testing.T) {
+	tests := [][]string{
+		{"-b", "3"},
+		{"-l", "2"},
+		{"-n", "3"},
+		{"-n", "l/2"},
+		{"-n", "r/4", "-e"},
+		{"-C", "4", "-d", "--additional-suffix", ".txt"},
+		{"-l", "2", "--atomic"},
+	}
+
+	for _, args := range tests {
+		t.Run(strings.Join(args, " "), func(t *testing.T) {
+			outputDir := t.TempDir() + "/"
+			inputFilePath := outputDir + "input.txt"
+			if err := os.WriteFile(inputFilePath, []byte("1\n2\n3\n4\n5\n"), 0644); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+
+			stdout := new(bytes.Buffer)
+			cli := &CLI{Stdin: os.Stdin, Stdout: stdout, Stderr: io.Discard}
+			dryRunArgs := append(append([]string{"split", "--dry-run"}, args...), inputFilePath, outputDir+"x")
+			if err := cli.Run(dryRunArgs); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			entries, err := os.ReadDir(outputDir)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if len(entries) != 1 {
+				t.Fatalf("Unexpected files were created: %v", entries)
+			}
+
+			cli = &CLI{Stdin: os.Stdin, Stdout: io.Discard, Stderr: io.Discard}
+			if err := cli.Run(append(append([]string{"split"}, args...), inputFilePath, outputDir+"x")); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			entries, err = os.ReadDir(outputDir)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			expected := ""
+			for _, entry := range entries {
+				if entry.Name() != "input.txt" {
+					expected += outputDir + entry.Name() + "\n"
 				}
 			}
+			if stdout.String() != expected {
+				t.Errorf("Unexpected output: got %q, expected %q", stdout.String(), expected)
+			}
+		})
+	}
+}
+
+func TestCLIRunDefaultPrefix(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{"file with prefix", []string{"input.txt", "out"}, "outaa"},
+		{"file without prefix", []string{"input.txt"}, "xaa"},
+		{"file with empty prefix", []string{"input.txt", ""}, "xaa"},
+		{"stdin without prefix", []string{}, "xaa"},
+		{"stdin with prefix", []string{"-", "out"}, "outaa"},
+		{"stdin with empty prefix", []string{"-", ""}, "xaa"},
+		{"prefix flag", []string{"-p", "flag", "input.txt"}, "flagaa"},
+		{"long prefix flag over default", []string{"--prefix", "flag"}, "flagaa"},
+		{"empty prefix flag", []string{"--prefix", "", "input.txt"}, "xaa"},
+		{"prefix from input", []string{"--prefix-from-input", "input.txt"}, "input.txt.aa"},
+		{"prefix from input with separator", []string{"--prefix-from-input", "--suffix-separator", "-", "input.txt"}, "input.txt-aa"},
+		{"prefix from stdin", []string{"--prefix-from-input"}, "xaa"},
+	}
+
+	workDir, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	t.Cleanup(func() { os.Chdir(workDir) })
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := os.Chdir(t.TempDir()); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if err := os.WriteFile("input.txt", []byte("1\n2\n"), 0644); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+
+			cli := &CLI{Stdin: strings.NewReader("1\n2\n"), Stdout: io.Discard, Stderr: io.Discard}
+			if err := cli.Run(append([]string{"split"}, test.args...)); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			data, err := os.ReadFile(test.expected)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if string(data) != "1\n2\n" {
+				t.Errorf("Unexpected content of %s: got %q, expected %q", test.expected, data, "1\n2\n")
+			}
+		})
+	}
+}
+
+func TestCLIRunExitCodeClassification(t *testing.T) {
+	outputDir := t.TempDir() + "/"
+	inputFilePath := outputDir + "input.txt"
+	if err := os.WriteFile(inputFilePath, []byte("1\n2\n"), 0644); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected int
+	}{
+		{"conflicting modes", []string{"-l", "1", "-b", "1", inputFilePath, outputDir + "x"}, ExitUsage},
+		{"invalid byte size", []string{"-b", "10KA", inputFilePath, outputDir + "x"}, ExitUsage},
+		{"overflowing byte size", []string{"-b", "16384P", inputFilePath, outputDir + "x"}, ExitUsage},
+		{"zero lines", []string{"-l", "0", inputFilePath, outputDir + "x"}, ExitUsage},
+		{"invalid chunks", []string{"-n", "x/3", inputFilePath, outputDir + "x"}, ExitUsage},
+		{"invalid separator", []string{"-t", "ab", inputFilePath, outputDir + "x"}, ExitUsage},
+		{"invalid suffix alphabet", []string{"--suffix-alphabet", "aa", inputFilePath, outputDir + "x"}, ExitUsage},
+		{"invalid output pattern", []string{"--output-pattern", outputDir + "chunk", inputFilePath}, ExitUsage},
+		{"stdout with several outputs", []string{"-l", "1", inputFilePath, "-"}, ExitUsage},
+		{"missing input file", []string{outputDir + "missing.txt", outputDir + "x"}, ExitFailure},
+		{"missing output directory", []string{"-l", "1", inputFilePath, outputDir + "missing/x"}, ExitFailure},
+		{"suffixes exhausted", []string{"-l", "1", "-a", "1", "--suffix-start", "z", inputFilePath, outputDir + "y"}, ExitFailure},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cli := &CLI{Stdin: os.Stdin, Stdout: io.Discard, Stderr: io.Discard}
+			err := cli.Run(append([]string{"split"}, test.args...))
+			if err == nil {
+				t.Fatalf("Unexpected result : error is nil")
+			}
+			if code := exitCode(err); code != test.expected {
+				t.Errorf("Unexpected exit code: got %d, expected %d (error: %v)", code, test.expected, err)
+			}
+		})
+	}
+}
+
+func TestCLIRunStdinWithExistingFilePrefix(t *testing.T) {
+	outputDir := t.TempDir() + "/"
+	existing := outputDir + "myfile"
+	if err := os.WriteFile(existing, []byte("not the input\n"), 0644); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	tests := []struct {
+		args    []string
+		warning bool
+	}{
+		{[]string{"-", existing}, true},
+		{[]string{"-", outputDir + "new"}, false},
+		{[]string{"--prefix", existing, "-"}, false},
+		{[]string{"--quiet", "-", existing}, false},
+	}
+	for _, test := range tests {
+		t.Run(strings.Join(test.args, " "), func(t *testing.T) {
+			stderr := &bytes.Buffer{}
+			cli := &CLI{Stdin: strings.NewReader("1\n2\n"), Stdout: io.Discard, Stderr: stderr}
+			if err := cli.Run(append([]string{"split", "-l", "10"}, test.args...)); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if warned := strings.Contains(stderr.String(), "warning:"); warned != test.warning {
+				t.Errorf("Unexpected warning: got %q, expected warning: %v", stderr.String(), test.warning)
+			}
+		})
+	}
+
+	// 標準入力を myfile に接尾辞を付けた名前に分割し、myfile は変更しない
+	data, err := os.ReadFile(existing + "aa")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if string(data) != "1\n2\n" {
+		t.Errorf("Unexpected content of myfileaa: got %q, expected %q", data, "1\n2\n")
+	}
+	if data, err := os.ReadFile(existing); err != nil || string(data) != "not the input\n" {
+		t.Errorf("myfile is changed: %q, %v", data, err)
+	}
+}
+
+func TestCLIRunPrefixFromInput(t *testing.T) {
+	inputDir := t.TempDir() + "/"
+	inputFilePath := inputDir + "report.csv"
+	if err := os.WriteFile(inputFilePath, []byte("1\n2\n3\n"), 0644); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	workDir, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	t.Cleanup(func() { os.Chdir(workDir) })
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	// 入力ファイルのディレクトリを除いた名前を接頭辞とし、作業ディレクトリに作成する
+	cli := &CLI{Stdin: os.Stdin, Stdout: io.Discard, Stderr: io.Discard}
+	if err := cli.Run([]string{"split", "-l", "1", "--prefix-from-input", inputFilePath}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	for name, expected := range map[string]string{"report.csv.aa": "1\n", "report.csv.ab": "2\n", "report.csv.ac": "3\n"} {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if string(data) != expected {
+			t.Errorf("Unexpected content of %s: got %q, expected %q", name, data, expected)
+		}
+	}
+
+	// 接頭辞の指定とは併用できない
+	for _, args := range [][]string{
+		{"--prefix-from-input", "-p", "out", inputFilePath},
+		{"--prefix-from-input", inputFilePath, "out"},
+	} {
+		if err := cli.Run(append([]string{"split"}, args...)); !errors.Is(err, split.YouMustSpecifyOnlyOneOption) {
+			t.Errorf("Unexpected error for %v: got %v, expected %s", args, err, split.YouMustSpecifyOnlyOneOption)
+		}
+	}
+}
+
+func TestCLIRunChunksFromStdin(t *testing.T) {
+	outputDir := t.TempDir() + "/"
+	cli := &CLI{Stdin: strings.NewReader("abcdefgh"), Stdout: io.Discard, Stderr: io.Discard}
+	if err := cli.Run([]string{"split", "-n", "3", "-", outputDir + "x"}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	for name, expected := range map[string]string{"xaa": "abc", "xab": "def", "xac": "gh"} {
+		data, err := os.ReadFile(outputDir + name)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if string(data) != expected {
+			t.Errorf("Unexpected content of %s: got %q, expected %q", name, data, expected)
+		}
+	}
+}
+
+func TestCLIRunPreservesFileMode(t *testing.T) {
+	outputDir := t.TempDir() + "/"
+	inputFilePath := outputDir + "input.txt"
+	if err := os.WriteFile(inputFilePath, []byte("1\n2\n"), 0600); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if err := os.Chmod(inputFilePath, 0600); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	cli := &CLI{Stdin: os.Stdin, Stdout: io.Discard, Stderr: io.Discard}
+	if err := cli.Run([]string{"split", "-l", "1", inputFilePath, outputDir + "x"}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	for _, name := range []string{"xaa", "xab"} {
+		info, err := os.Stat(outputDir + name)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if info.Mode().Perm() != 0600 {
+			t.Errorf("Unexpected mode of %s: got %v, expected %v", name, info.Mode().Perm(), os.FileMode(0600))
+		}
+	}
+}
+
+func TestCLIRunSummary(t *testing.T) {
+	outputDir := t.TempDir() + "/"
+	inputFilePath := outputDir + "input.txt"
+	if err := os.WriteFile(inputFilePath, []byte("1234567890"), 0644); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	stderr := new(bytes.Buffer)
+	cli := &CLI{Stdin: os.Stdin, Stdout: io.Discard, Stderr: stderr}
+	if err := cli.Run([]string{"split", "--summary", "-b", "4", inputFilePath, outputDir + "x"}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	total := 0
+	for _, name := range []string{"xaa", "xab", "xac"} {
+		info, err := os.Stat(outputDir + name)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		total += int(info.Size())
+	}
+	if expected := fmt.Sprintf("wrote 3 files, %d B total\n", total); stderr.String() != expected {
+		t.Errorf("Unexpected summary: got %q, expected %q", stderr.String(), expected)
+	}
+}
+
+func TestFormatByteSize(t *testing.T) {
+	tests := []struct {
+		size     uint64
+		expected string
+	}{
+		{0, "0 B"},
+		{999, "999 B"},
+		{1000, "1.0 KB"},
+		{3400000, "3.4 MB"},
+		{18446744073709551615, "18.4 EB"},
+	}
+
+	for _, test := range tests {
+		if got := formatByteSize(test.size); got != test.expected {
+			t.Errorf("Unexpected result for %d: got %s, expected %s", test.size, got, test.expected)
+		}
+	}
+}
+
+func TestCLIRunNoClobber(t *testing.T) {
+	for _, noClobber := range []bool{false, true} {
+		t.Run(fmt.Sprintf("no-clobber: %v", noClobber), func(t *testing.T) {
+			outputDir := t.TempDir() + "/"
+			inputFilePath := outputDir + "input.txt"
+			if err := os.WriteFile(inputFilePath, []byte("1\n2\n"), 0644); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if err := os.WriteFile(outputDir+"xaa", []byte("keep\n"), 0644); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+
+			args := []string{"split", "-l", "1"}
+			if noClobber {
+				args = append(args, "--no-clobber")
+			}
+			cli := &CLI{Stdin: os.Stdin, Stdout: io.Discard, Stderr: io.Discard}
+			err := cli.Run(append(args, inputFilePath, outputDir+"x"))
+
+			data, readErr := os.ReadFile(outputDir + "xaa")
+			if readErr != nil {
+				t.Fatalf("Unexpected error: %s", readErr)
+			}
+			if noClobber {
+				if err == nil || !strings.Contains(err.Error(), string(split.OutputFileExists)) || !strings.Contains(err.Error(), outputDir+"xaa") {
+					t.Errorf("Unexpected error: got %v, expected %s", err, split.OutputFileExists)
+				}
+				if string(data) != "keep\n" {
+					t.Errorf("Existing file was overwritten: %q", data)
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("Unexpected error: %s", err)
+				}
+				if string(data) != "1\n" {
+					t.Errorf("Unexpected content of xaa: got %q, expected %q", data, "1\n")
+				}
+			}
+		})
+	}
+}
+
+func TestCLIRunSeparatorPattern(t *testing.T) {
+	tests := []struct {
+		valid    bool
+		args     []string
+		expected []string
+	}{
+		{true, []string{"--separator-pattern", "^----$"}, []string{"a\n", "----\nb\n"}},
+		{true, []string{"--separator-pattern", "^----$", "--suppress-matched"}, []string{"a\n", "b\n"}},
+		{false, []string{"--separator-pattern", "("}, nil},
+		{false, []string{"--separator-pattern", "^----$", "-l", "1"}, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(strings.Join(test.args, " "), func(t *testing.T) {
+			outputDir := t.TempDir() + "/"
+			inputFilePath := outputDir + "input.txt"
+			if err := os.WriteFile(inputFilePath, []byte("a\n----\nb\n"), 0644); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+
+			cli := &CLI{Stdin: os.Stdin, Stdout: io.Discard, Stderr: io.Discard}
+			err := cli.Run(append(append([]string{"split"}, test.args...), inputFilePath, outputDir+"x"))
+			if err != nil && test.valid {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if err == nil && !test.valid {
+				t.Fatalf("Unexpected result : error is nil")
+			}
+
+			for i, expected := range test.expected {
+				name := outputDir + []string{"xaa", "xab"}[i]
+				data, err := os.ReadFile(name)
+				if err != nil {
+					t.Fatalf("Unexpected error: %s", err)
+				}
+				if string(data) != expected {
+					t.Errorf("Unexpected content of %s: got %q, expected %q", name, data, expected)
+				}
+			}
+		})
+	}
+}
+
+func TestCLIRunSuffixesExhaustedBeforeWriting(t *testing.T) {
+	outputDir := t.TempDir() + "/"
+	inputFilePath := outputDir + "input.txt"
+	if err := os.WriteFile(inputFilePath, bytes.Repeat([]byte("a"), 1000), 0644); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	cli := &CLI{Stdin: os.Stdin, Stdout: io.Discard, Stderr: io.Discard}
+	// 作成済みのファイルの削除ではなく、書き込む前に失敗することを確認する
+	err := cli.Run([]string{"split", "--no-cleanup", "-a", "1", "-b", "10", inputFilePath, outputDir + "x"})
+	if err == nil || err.Error() != string(split.OutputFileSuffixesExhausted) {
+		t.Errorf("Unexpected error: got %v, expected %s", err, split.OutputFileSuffixesExhausted)
+	}
+
+	entries, err := os.ReadDir(outputDir)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("Unexpected files were created: %v", entries)
+	}
+}
+
+func TestCLIRunConflictingSplitModes(t *testing.T) {
+	modes := [][]string{
+		{"-b", "1"},
+		{"-l", "1"},
+		{"-n", "2"},
+		{"-C", "4"},
+		{"--line-bytes", "4"},
+		{"--separator-pattern", "^$"},
+	}
+
+	outputDir := t.TempDir() + "/"
+	inputFilePath := outputDir + "input.txt"
+	if err := os.WriteFile(inputFilePath, []byte("1\n2\n"), 0644); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	for i := range modes {
+		for j := i + 1; j < len(modes); j++ {
+			if modes[i][0] == "-C" && modes[j][0] == "--line-bytes" {
+				continue
+			}
+			args := append(append([]string{"split"}, modes[i]...), modes[j]...)
+			t.Run(strings.Join(args[1:], " "), func(t *testing.T) {
+				cli := &CLI{Stdin: os.Stdin, Stdout: io.Discard, Stderr: io.Discard}
+				err := cli.Run(append(args, inputFilePath, outputDir+"x"))
+				if err == nil || err.Error() != string(split.YouMustSpecifyOnlyOneOption) {
+					t.Errorf("Unexpected error: got %v, expected %s", err, split.YouMustSpecifyOnlyOneOption)
+				}
+			})
+		}
+	}
+
+	entries, err := os.ReadDir(outputDir)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("Unexpected files were created: %v", entries)
+	}
+}
+
+func TestCLIRunModeSpecificOptions(t *testing.T) {
+	outputDir := t.TempDir() + "/"
+	inputFilePath := outputDir + "input.txt"
+	if err := os.WriteFile(inputFilePath, []byte("1\n2\n"), 0644); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	// 特定の分割方法でだけ用いるオプションは、他の分割方法と指定しても無視せずにエラーとする
+	tests := []struct {
+		args     []string
+		expected error
+	}{
+		{[]string{"--suppress-matched"}, split.SuppressRequiresPattern},
+		{[]string{"--suppress-matched", "-l", "1"}, split.SuppressRequiresPattern},
+		{[]string{"--max-line-bytes", "4", "-b", "1"}, split.MaxLineBytesRequiresLines},
+		{[]string{"--max-line-bytes", "4", "-n", "2"}, split.MaxLineBytesRequiresLines},
+		{[]string{"--max-line-bytes", "4", "-C", "4"}, split.MaxLineBytesRequiresLines},
+		{[]string{"--ensure-trailing-newline", "-b", "1"}, split.TrailingNewlineNeedsLines},
+		{[]string{"--ensure-trailing-newline", "-C", "4"}, split.TrailingNewlineNeedsLines},
+		{[]string{"--ensure-trailing-newline", "-n", "2"}, split.TrailingNewlineNeedsLines},
+		{[]string{"--total-size", "4"}, split.TotalSizeRequiresChunks},
+		{[]string{"--total-size", "4", "-b", "1"}, split.TotalSizeRequiresChunks},
+		{[]string{"--total-size", "4", "-l", "1"}, split.TotalSizeRequiresChunks},
+		{[]string{"--total-size", "4", "-n", "r/2"}, split.TotalSizeRequiresChunks},
+		{[]string{"--total-size", "4", "-n", "L/2"}, split.TotalSizeRequiresChunks},
+		{[]string{"--utf8-safe"}, split.UTF8SafeRequiresByteSplit},
+		{[]string{"--utf8-safe", "-n", "2"}, split.UTF8SafeRequiresByteSplit},
+		{[]string{"--utf8-safe", "-C", "4"}, split.UTF8SafeRequiresByteSplit},
+		{[]string{"--min-chunk", "4"}, split.MinChunkRequiresChunks},
+		{[]string{"--min-chunk", "4", "-b", "1"}, split.MinChunkRequiresChunks},
+		{[]string{"--min-chunk", "4", "-n", "l/2"}, split.MinChunkRequiresChunks},
+		{[]string{"--min-chunk", "4", "-n", "r/2"}, split.MinChunkRequiresChunks},
+	}
+
+	for _, test := range tests {
+		t.Run(strings.Join(test.args, " "), func(t *testing.T) {
+			cli := &CLI{Stdin: os.Stdin, Stdout: io.Discard, Stderr: io.Discard}
+			err := cli.Run(append(append([]string{"split"}, test.args...), inputFilePath, outputDir+"x"))
+			if !errors.Is(err, test.expected) {
+				t.Errorf("Unexpected error: got %v, expected %s", err, test.expected)
+			}
+			if code := exitCode(err); code != ExitUsage {
+				t.Errorf("Unexpected exit code: got %d, expected %d", code, ExitUsage)
+			}
+		})
+	}
+
+	entries, err := os.ReadDir(outputDir)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("Unexpected files were created: %v", entries)
+	}
+}
+
+func TestCountSplitModes(t *testing.T) {
+	tests := []struct {
+		modes    []bool
+		expected int
+	}{
+		{[]bool{}, 0},
+		{[]bool{false, false}, 0},
+		{[]bool{true, false, false}, 1},
+		{[]bool{true, false, true}, 2},
+	}
+
+	for _, test := range tests {
+		if got := countSplitModes(test.modes...); got != test.expected {
+			t.Errorf("Unexpected result for %v: got %d, expected %d", test.modes, got, test.expected)
+		}
+	}
+}
+
+func TestCLIRunLineEnding(t *testing.T) {
+	tests := []struct {
+		valid    bool
+		args     []string
+		expected []string
+	}{
+		{true, []string{"--line-ending", "lf"}, []string{"a\r\nb\n", "c\r\nd\r\n"}},
+		{true, []string{"--line-ending", "crlf"}, []string{"a\r\nb\nc\r\n", "d\r\n"}},
+		{true, []string{"--line-ending", "auto"}, []string{"a\r\nb\nc\r\n", "d\r\n"}},
+		{false, []string{"--line-ending", "cr"}, nil},
+		{false, []string{"--line-ending", "crlf", "-t", `\0`}, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(strings.Join(test.args, " "), func(t *testing.T) {
+			outputDir := t.TempDir() + "/"
+			inputFilePath := outputDir + "input.txt"
+			if err := os.WriteFile(inputFilePath, []byte("a\r\nb\nc\r\nd\r\n"), 0644); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+
+			cli := &CLI{Stdin: os.Stdin, Stdout: io.Discard, Stderr: io.Discard}
+			err := cli.Run(append(append([]string{"split", "-l", "2"}, test.args...), inputFilePath, outputDir+"x"))
+			if err != nil && test.valid {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if err == nil && !test.valid {
+				t.Fatalf("Unexpected result : error is nil")
+			}
+
+			for i, expected := range test.expected {
+				name := outputDir + []string{"xaa", "xab"}[i]
+				data, err := os.ReadFile(name)
+				if err != nil {
+					t.Fatalf("Unexpected error: %s", err)
+				}
+				if string(data) != expected {
+					t.Errorf("Unexpected content of %s: got %q, expected %q", name, data, expected)
+				}
+			}
+		})
+	}
+}
+
+func TestCLIRunAtomic(t *testing.T) {
+	outputDir := t.TempDir() + "/"
+	inputFilePath := outputDir + "input.txt"
+	if err := os.WriteFile(inputFilePath, []byte("1\n2\n"), 0644); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	cli := &CLI{Stdin: os.Stdin, Stdout: io.Discard, Stderr: io.Discard}
+	if err := cli.Run([]string{"split", "--atomic", "-l", "1", inputFilePath, outputDir + "x"}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	entries, err := os.ReadDir(outputDir)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	names := []string{}
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	if strings.Join(names, ",") != "input.txt,xaa,xab" {
+		t.Errorf("Unexpected files: %v", names)
+	}
+
+	// 既存のファイルは一時ファイルからの名前の変更でも上書きしない
+	err = cli.Run([]string{"split", "--atomic", "--no-clobber", "-l", "1", inputFilePath, outputDir + "x"})
+	if err == nil || !strings.Contains(err.Error(), string(split.OutputFileExists)) {
+		t.Errorf("Unexpected error: got %v, expected %s", err, split.OutputFileExists)
+	}
+}
+
+func TestCLIRunErrorIs(t *testing.T) {
+	outputDir := t.TempDir() + "/"
+	inputFilePath := outputDir + "input.txt"
+	if err := os.WriteFile(inputFilePath, []byte("1\n2\n"), 0644); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	tests := []struct {
+		args     []string
+		expected split.ErrorMsg
+	}{
+		{[]string{"-b", "1", "-l", "1"}, split.YouMustSpecifyOnlyOneOption},
+		{[]string{"-n", "x"}, split.InvalidNumberOfChunks},
+		{[]string{"-n", "4/3"}, split.InvalidChunkNumber},
+		{[]string{"-t", "ab"}, split.InvalidSeparator},
+		{[]string{"-a", "-1"}, split.InvalidSuffixLength},
+		{[]string{"--numeric-suffixes=100"}, split.SuffixStartTooLarge},
+		{[]string{"-b", "0"}, split.InvalidSplitSize},
+		{[]string{"-l", "0"}, split.InvalidSplitSize},
+		{[]string{"-n", "0"}, split.InvalidSplitSize},
+		{[]string{"-n", "l/0"}, split.InvalidSplitSize},
+		{[]string{"-C", "0"}, split.InvalidSplitSize},
+		{[]string{"-b", "1x"}, split.InvalidByteSizeFormat},
+		{[]string{"--separator-pattern", "("}, split.InvalidPattern},
+		{[]string{"--line-ending", "cr"}, split.InvalidLineEnding},
+	}
+
+	for _, test := range tests {
+		t.Run(strings.Join(test.args, " "), func(t *testing.T) {
+			cli := &CLI{Stdin: os.Stdin, Stdout: io.Discard, Stderr: io.Discard}
+			err := cli.Run(append(append([]string{"split"}, test.args...), inputFilePath, outputDir+"x"))
+			if !errors.Is(err, test.expected) {
+				t.Errorf("Unexpected error: got %v, expected %s", err, test.expected)
+			}
+		})
+	}
+
+	// 接頭辞が "-" の場合は出力が1つに決まる必要がある
+	cli := &CLI{Stdin: os.Stdin, Stdout: io.Discard, Stderr: io.Discard}
+	if err := cli.Run([]string{"split", "-l", "1", inputFilePath, "-"}); !errors.Is(err, split.StdoutRequiresSingleOutput) {
+		t.Errorf("Unexpected error: got %v, expected %s", err, split.StdoutRequiresSingleOutput)
+	}
+	f := &numericSuffixesFlag{}
+	if err := f.Set("abc"); !errors.Is(err, split.InvalidSuffixStart) {
+		t.Errorf("Unexpected error: got %v, expected %s", err, split.InvalidSuffixStart)
+	}
+}
+
+func TestCLIRunDefaultLineCount(t *testing.T) {
+	outputDir := t.TempDir() + "/"
+	inputFilePath := outputDir + "input.txt"
+	input := strings.Repeat("a\n", int(split.DefaultCount)+1)
+	if err := os.WriteFile(inputFilePath, []byte(input), 0644); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	// -l と -n を指定しない場合は既定の行数で分割する
+	cli := &CLI{Stdin: os.Stdin, Stdout: io.Discard, Stderr: io.Discard}
+	if err := cli.Run([]string{"split", inputFilePath, outputDir + "x"}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	for name, expected := range map[string]string{"xaa": input[:2*split.DefaultCount], "xab": "a\n"} {
+		data, err := os.ReadFile(outputDir + name)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if string(data) != expected {
+			t.Errorf("Unexpected content of %s: got %d bytes, expected %d bytes", name, len(data), len(expected))
+		}
+	}
+}
+
+func TestCLIRunParallel(t *testing.T) {
+	outputDir := t.TempDir() + "/"
+	inputFilePath := outputDir + "input.txt"
+	input := strings.Repeat("0123456789", 100)
+	if err := os.WriteFile(inputFilePath, []byte(input), 0644); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	// 並行して書き込んでも逐次の場合と同じ内容とファイル名になる
+	cli := &CLI{Stdin: os.Stdin, Stdout: io.Discard, Stderr: io.Discard}
+	if err := cli.Run([]string{"split", "-n", "3", "--parallel", "3", inputFilePath, outputDir + "x"}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	for name, expected := range map[string]string{"xaa": input[:334], "xab": input[334:667], "xac": input[667:]} {
+		data, err := os.ReadFile(outputDir + name)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if string(data) != expected {
+			t.Errorf("Unexpected content of %s: got %q, expected %q", name, data, expected)
+		}
+	}
+}
+
+func TestCLIRunUTF8Safe(t *testing.T) {
+	outputDir := t.TempDir() + "/"
+	inputFilePath := outputDir + "input.txt"
+	if err := os.WriteFile(inputFilePath, []byte("abあいう"), 0644); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	cli := &CLI{Stdin: os.Stdin, Stdout: io.Discard, Stderr: io.Discard}
+	if err := cli.Run([]string{"split", "-b", "4", "--utf8-safe", inputFilePath, outputDir + "x"}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	for name, expected := range map[string]string{"xaa": "ab", "xab": "あ", "xac": "い", "xad": "う"} {
+		data, err := os.ReadFile(outputDir + name)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if string(data) != expected {
+			t.Errorf("Unexpected content of %s: got %q, expected %q", name, data, expected)
+		}
+	}
+}
+
+func TestCLIRunOutputPattern(t *testing.T) {
+	outputDir := t.TempDir() + "/"
+	inputFilePath := outputDir + "input.txt"
+	if err := os.WriteFile(inputFilePath, []byte("1\n2\n3\n"), 0644); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	cli := &CLI{Stdin: os.Stdin, Stdout: io.Discard, Stderr: io.Discard}
+	if err := cli.Run([]string{"split", "-l", "1", "--output-pattern", outputDir + "chunk-%03d.dat", inputFilePath}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	for name, expected := range map[string]string{"chunk-000.dat": "1\n", "chunk-001.dat": "2\n", "chunk-002.dat": "3\n"} {
+		data, err := os.ReadFile(outputDir + name)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if string(data) != expected {
+			t.Errorf("Unexpected content of %s: got %q, expected %q", name, data, expected)
+		}
+	}
+
+	tests := []struct {
+		args     []string
+		expected error
+	}{
+		// 番号がないとすべての出力ファイルが同じ名前になる
+		{[]string{"--output-pattern", outputDir + "chunk.dat"}, split.InvalidOutputPattern},
+		{[]string{"--output-pattern", outputDir + "chunk-%s.dat"}, split.InvalidOutputPattern},
+		{[]string{"--output-pattern", outputDir + "chunk-%d", "-p", "out"}, split.YouMustSpecifyOnlyOneOption},
+		{[]string{"--output-pattern", outputDir + "chunk-%d", inputFilePath, "out"}, split.YouMustSpecifyOnlyOneOption},
+	}
+	for _, test := range tests {
+		args := append([]string{"split", "-l", "1"}, test.args...)
+		if len(test.args) == 2 {
+			args = append(args, inputFilePath)
+		}
+		if err := cli.Run(args); !errors.Is(err, test.expected) {
+			t.Errorf("Unexpected error for %v: got %v, expected %s", test.args, err, test.expected)
+		}
+	}
+	if _, err := os.Stat(outputDir + "chunk.dat"); err == nil {
+		t.Errorf("chunk.dat is created with an invalid pattern")
+	}
+}
+
+func TestCLIRunMinChunk(t *testing.T) {
+	outputDir := t.TempDir() + "/"
+	inputFilePath := outputDir + "input.txt"
+	if err := os.WriteFile(inputFilePath, []byte(strings.Repeat("0123456789", 10)), 0644); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	stderr := &bytes.Buffer{}
+	cli := &CLI{Stdin: os.Stdin, Stdout: io.Discard, Stderr: stderr}
+	if err := cli.Run([]string{"split", "-n", "50", "--min-chunk", "30", inputFilePath, outputDir + "x"}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	names, err := filepath.Glob(outputDir + "x*")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if len(names) > 3 {
+		t.Errorf("Unexpected number of files: got %v, expected at most 3", names)
+	}
+	for _, name := range names {
+		if info, err := os.Stat(name); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		} else if info.Size() < 30 {
+			t.Errorf("%s is smaller than --min-chunk: %d bytes", name, info.Size())
+		}
+	}
+	if expected := "reduced the number of files from 50 to 3 for --min-chunk\n"; stderr.String() != expected {
+		t.Errorf("Unexpected stderr: got %q, expected %q", stderr.String(), expected)
+	}
+}
+
+func TestCLIRunManifest(t *testing.T) {
+	inputDir := t.TempDir() + "/"
+	inputFilePath := inputDir + "input.bin"
+	input := strings.Repeat("0123456789", 10)
+	if err := os.WriteFile(inputFilePath, []byte(input), 0644); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	tests := []struct {
+		args  []string
+		start uint64
+	}{
+		{[]string{"-b", "7"}, 0},
+		{[]string{"-n", "3"}, 0},
+		{[]string{"-n", "200", "-e"}, 0},
+		{[]string{"--sizes", "10,25"}, 0},
+		{[]string{"-b", "30", "-z"}, 0},
+		{[]string{"-b", "30", "--input-offset", "15"}, 15},
+	}
+	for _, test := range tests {
+		t.Run(strings.Join(test.args, " "), func(t *testing.T) {
+			outputDir := t.TempDir() + "/"
+			cli := &CLI{Stdin: os.Stdin, Stdout: io.Discard, Stderr: io.Discard}
+			args := append(append([]string{"split", "--manifest", outputDir + "out.idx"}, test.args...), inputFilePath, outputDir+"x")
+			if err := cli.Run(args); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			data, err := os.ReadFile(outputDir + "out.idx")
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+
+			// 範囲は重ならずに連続し、入力全体を覆う
+			next := test.start
+			lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+			for _, line := range lines {
+				fields := strings.Split(line, "\t")
+				if len(fields) != 3 {
+					t.Fatalf("Unexpected manifest line: %q", line)
+				}
+				start, err := strconv.ParseUint(fields[1], 10, 64)
+				if err != nil {
+					t.Fatalf("Unexpected error: %s", err)
+				}
+				end, err := strconv.ParseUint(fields[2], 10, 64)
+				if err != nil {
+					t.Fatalf("Unexpected error: %s", err)
+				}
+				if start != next || end < start {
+					t.Errorf("Unexpected range of %s: got %d-%d, expected to start at %d", fields[0], start, end, next)
+				}
+				next = end
+
+				// 圧縮していないファイルは入力のその範囲と同じ内容になる
+				if !strings.HasSuffix(fields[0], ".gz") {
+					content, err := os.ReadFile(fields[0])
+					if err != nil {
+						t.Fatalf("Unexpected error: %s", err)
+					}
+					if string(content) != input[start:end] {
+						t.Errorf("Unexpected content of %s: got %q, expected %q", fields[0], content, input[start:end])
+					}
+				}
+			}
+			if next != uint64(len(input)) {
+				t.Errorf("Unexpected end of the manifest: got %d, expected %d", next, len(input))
+			}
+		})
+	}
+
+	// 行単位の分割では入力の範囲を記録しない
+	cli := &CLI{Stdin: os.Stdin, Stdout: io.Discard, Stderr: io.Discard}
+	outputDir := t.TempDir() + "/"
+	if err := cli.Run([]string{"split", "-l", "1", "--manifest", outputDir + "out.idx", inputFilePath, outputDir + "x"}); !errors.Is(err, split.ManifestRequiresByteSplit) {
+		t.Errorf("Unexpected error: got %v, expected %s", err, split.ManifestRequiresByteSplit)
+	}
+
+	// --no-clobber の場合は既存の一覧を上書きしない
+	if err := os.WriteFile(outputDir+"out.idx", []byte("keep\n"), 0644); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	err := cli.Run([]string{"split", "-b", "30", "--no-clobber", "--manifest", outputDir + "out.idx", inputFilePath, outputDir + "y"})
+	if !errors.Is(err, split.OutputFileExists) {
+		t.Errorf("Unexpected error: got %v, expected %s", err, split.OutputFileExists)
+	}
+	if data, err := os.ReadFile(outputDir + "out.idx"); err != nil || string(data) != "keep\n" {
+		t.Errorf("Unexpected content of out.idx: %q, %v", data, err)
+	}
+}
+
+func TestParseSizes(t *testing.T) {
+	tests := []struct {
+		valid    bool
+		input    string
+		expected []uint64
+	}{
+		{true, "1M,10M", []uint64{1024 * 1024, 10 * 1024 * 1024}},
+		{true, "100", []uint64{100}},
+		{false, "1M,", nil},
+		{false, "1M,0", nil},
+		{false, "", nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			sizes, err := parseSizes(test.input, split.UnitsDefault)
+			if err != nil && test.valid {
+				t.Errorf("Unexpected error: %s", err)
+			}
+			if err == nil && !test.valid {
+				t.Errorf("Unexpected result : error is nil, result is %v", sizes)
+			}
+			if test.valid && fmt.Sprint(sizes) != fmt.Sprint(test.expected) {
+				t.Errorf("Unexpected result: got %v, expected %v", sizes, test.expected)
+			}
+		})
+	}
+}
+
+func TestCLIRunRanges(t *testing.T) {
+	outputDir := t.TempDir() + "/"
+	inputFilePath := outputDir + "input.txt"
+	if err := os.WriteFile(inputFilePath, []byte("1\n2\n3\n4\n5\n"), 0644); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	cli := &CLI{Stdin: os.Stdin, Stdout: io.Discard, Stderr: io.Discard}
+	if err := cli.Run([]string{"split", "--ranges", "1-2:intro,3-4:body", inputFilePath, outputDir + "part-"}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	for name, expected := range map[string]string{"part-intro": "1\n2\n", "part-body": "3\n4\n"} {
+		data, err := os.ReadFile(outputDir + name)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if string(data) != expected {
+			t.Errorf("Unexpected content of %s: got %q, expected %q", name, data, expected)
+		}
+	}
+
+	err := cli.Run([]string{"split", "--ranges", "1-3:intro,2-4:body", inputFilePath, outputDir + "overlap-"})
+	if !errors.Is(err, split.InvalidRanges) {
+		t.Errorf("Unexpected error: got %v, expected %s", err, split.InvalidRanges)
+	}
+	err = cli.Run([]string{"split", "--ranges", "1-2:intro,4-5:body", inputFilePath, outputDir + "gap-"})
+	if !errors.Is(err, split.RangesNotContiguous) {
+		t.Errorf("Unexpected error: got %v, expected %s", err, split.RangesNotContiguous)
+	}
+	if err := cli.Run([]string{"split", "--ranges", "1-2:intro", "-l", "1", inputFilePath, outputDir + "both-"}); !errors.Is(err, split.YouMustSpecifyOnlyOneOption) {
+		t.Errorf("Unexpected error: got %v, expected %s", err, split.YouMustSpecifyOnlyOneOption)
+	}
+}
+
+func TestCLIRunFollowInvalid(t *testing.T) {
+	outputDir := t.TempDir() + "/"
+	inputFilePath := outputDir + "input.txt"
+	if err := os.WriteFile(inputFilePath, []byte("1\n2\n"), 0644); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	tests := []struct {
+		args     []string
+		expected split.ErrorMsg
+	}{
+		{[]string{"--follow", "-C", "10", "-", outputDir + "x"}, split.FollowRequiresSingleFile},
+		{[]string{"--follow", "-C", "10", inputFilePath, inputFilePath, outputDir + "x"}, split.FollowRequiresSingleFile},
+		{[]string{"--follow", "-n", "2", inputFilePath, outputDir + "x"}, split.FollowWithChunks},
+	}
+
+	for _, test := range tests {
+		t.Run(strings.Join(test.args, " "), func(t *testing.T) {
+			cli := &CLI{Stdin: strings.NewReader(""), Stdout: io.Discard, Stderr: io.Discard}
+			if err := cli.Run(append([]string{"split"}, test.args...)); !errors.Is(err, test.expected) {
+				t.Errorf("Unexpected error: got %v, expected %s", err, test.expected)
+			}
+		})
+	}
+}
+
+func TestCLIRunTar(t *testing.T) {
+	outputDir := t.TempDir() + "/"
+	inputFilePath := outputDir + "input.txt"
+	input := "1\n2\n3\n4\n5\n"
+	if err := os.WriteFile(inputFilePath, []byte(input), 0644); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	cli := &CLI{Stdin: os.Stdin, Stdout: io.Discard, Stderr: io.Discard}
+	if err := cli.Run([]string{"split", "-l", "2", "--tar", outputDir + "out.tar", inputFilePath}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	// 出力ファイルは作成せず、アーカイブのエントリとして書き込む
+	if _, err := os.Stat("xaa"); !os.IsNotExist(err) {
+		t.Errorf("Unexpected output file xaa: %v", err)
+	}
+
+	archiveFile, err := os.Open(outputDir + "out.tar")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	defer archiveFile.Close()
+	reader := tar.NewReader(archiveFile)
+	names := []string{}
+	joined := &bytes.Buffer{}
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		names = append(names, header.Name)
+		if _, err := io.Copy(joined, reader); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+	}
+	if expected := []string{"xaa", "xab", "xac"}; strings.Join(names, ",") != strings.Join(expected, ",") {
+		t.Errorf("Unexpected entries: got %v, expected %v", names, expected)
+	}
+	if joined.String() != input {
+		t.Errorf("Unexpected content: got %q, expected %q", joined.String(), input)
+	}
+
+	err = cli.Run([]string{"split", "-n", "1", "--tar", outputDir + "stdout.tar", inputFilePath, "-"})
+	if !errors.Is(err, split.YouMustSpecifyOnlyOneOption) {
+		t.Errorf("Unexpected error: got %v, expected %s", err, split.YouMustSpecifyOnlyOneOption)
+	}
+
+	// --no-clobber の場合は既存のアーカイブを上書きしない
+	err = cli.Run([]string{"split", "-l", "2", "--no-clobber", "--tar", outputDir + "out.tar", inputFilePath})
+	if !errors.Is(err, split.OutputFileExists) || !strings.Contains(err.Error(), outputDir+"out.tar") {
+		t.Errorf("Unexpected error: got %v, expected %s", err, split.OutputFileExists)
+	}
+	if info, err := os.Stat(outputDir + "out.tar"); err != nil || info.Size() == 0 {
+		t.Errorf("Existing archive was overwritten: %v", err)
+	}
+}
+
+func TestInputWindow(t *testing.T) {
+	tests := []struct {
+		offset   uint64
+		limit    uint64
+		expected string
+	}{
+		{0, 0, "0123456789"},
+		{3, 0, "3456789"},
+		{0, 4, "0123"},
+		{2, 5, "23456"},
+		{8, 5, "89"},
+		{20, 5, ""},
+	}
+
+	for _, test := range tests {
+		for _, seekable := range []bool{true, false} {
+			t.Run(fmt.Sprintf("offset: %d limit: %d seekable: %v", test.offset, test.limit, seekable), func(t *testing.T) {
+				var input io.Reader = strings.NewReader("0123456789")
+				if !seekable {
+					input = struct{ io.Reader }{input}
+				}
+				reader, err := inputWindow(input, test.offset, test.limit)
+				if err != nil {
+					t.Fatalf("Unexpected error: %s", err)
+				}
+				if _, ok := reader.(io.Seeker); ok != seekable {
+					t.Errorf("Unexpected seekability: got %v, expected %v", ok, seekable)
+				}
+				data, err := io.ReadAll(reader)
+				if err != nil {
+					t.Fatalf("Unexpected error: %s", err)
+				}
+				if string(data) != test.expected {
+					t.Errorf("Unexpected content: got %q, expected %q", data, test.expected)
+				}
+			})
+		}
+	}
+}
+
+// seekOnlyReader は io.ReaderAt を実装しないシーク可能な入力
+type seekOnlyReader struct {
+	io.ReadSeeker
+}
+
+func TestExcludeTrailer(t *testing.T) {
+	tests := []struct {
+		n       uint64
+		body    string
+		trailer string
+	}{
+		{4, "012345", "6789"},
+		{1, "012345678", "9"},
+		{10, "", "0123456789"},
+		{20, "", "0123456789"},
+	}
+
+	for _, test := range tests {
+		for name, wrap := range map[string]func(*strings.Reader) io.Reader{
+			"reader at": func(r *strings.Reader) io.Reader { return r },
+			"seek only": func(r *strings.Reader) io.Reader { return seekOnlyReader{r} },
+		} {
+			t.Run(fmt.Sprintf("%d %s", test.n, name), func(t *testing.T) {
+				body, trailer, err := excludeTrailer(wrap(strings.NewReader("0123456789")), test.n)
+				if err != nil {
+					t.Fatalf("Unexpected error: %s", err)
+				}
+				// 末尾は分割の内容を読み終えてから読む
+				data, err := io.ReadAll(body)
+				if err != nil {
+					t.Fatalf("Unexpected error: %s", err)
+				}
+				if string(data) != test.body {
+					t.Errorf("Unexpected body: got %q, expected %q", data, test.body)
+				}
+				data, err = io.ReadAll(trailer)
+				if err != nil {
+					t.Fatalf("Unexpected error: %s", err)
+				}
+				if string(data) != test.trailer {
+					t.Errorf("Unexpected trailer: got %q, expected %q", data, test.trailer)
+				}
+			})
+		}
+	}
+
+	if _, _, err := excludeTrailer(struct{ io.Reader }{strings.NewReader("0123456789")}, 4); !errors.Is(err, split.CannotDetermineFileSize) {
+		t.Errorf("Unexpected error: got %v, expected %s", err, split.CannotDetermineFileSize)
+	}
+}
+
+func TestCLIRunTrailerBytes(t *testing.T) {
+	outputDir := t.TempDir() + "/"
+	inputFilePath := outputDir + "input.bin"
+	if err := os.WriteFile(inputFilePath, []byte("0123456789abcdefFOOTER"), 0644); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	cli := &CLI{Stdin: os.Stdin, Stdout: io.Discard, Stderr: io.Discard}
+	if err := cli.Run([]string{"split", "-n", "2", "--trailer-bytes", "6", "--trailer-file", inputFilePath, outputDir + "x"}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	// 最後のファイルは末尾の6バイトの手前で終わり、除いた6バイトは x.trailer に書き込む
+	for name, expected := range map[string]string{"xaa": "01234567", "xab": "89abcdef", "x.trailer": "FOOTER"} {
+		data, err := os.ReadFile(outputDir + name)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if string(data) != expected {
+			t.Errorf("Unexpected content of %s: got %q, expected %q", name, data, expected)
+		}
+	}
+
+	// --trailer-file がない場合は末尾を書き込まない
+	otherDir := t.TempDir() + "/"
+	if err := cli.Run([]string{"split", "-b", "10", "--trailer-bytes", "6", inputFilePath, otherDir + "x"}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if data, err := os.ReadFile(otherDir + "xab"); err != nil || string(data) != "abcdef" {
+		t.Errorf("Unexpected content of xab: %q, %v", data, err)
+	}
+	if _, err := os.Stat(otherDir + "x.trailer"); err == nil {
+		t.Errorf("x.trailer is created without --trailer-file")
+	}
+
+	// --no-clobber の場合は既存の x.trailer を上書きしない
+	err := cli.Run([]string{"split", "-n", "2", "--trailer-bytes", "6", "--trailer-file", "--no-clobber", inputFilePath, t.TempDir() + "/x"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	clobberDir := t.TempDir() + "/"
+	if err := os.WriteFile(clobberDir+"x.trailer", []byte("keep"), 0644); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	err = cli.Run([]string{"split", "-n", "2", "--trailer-bytes", "6", "--trailer-file", "--no-clobber", inputFilePath, clobberDir + "x"})
+	if !errors.Is(err, split.OutputFileExists) {
+		t.Errorf("Unexpected error: got %v, expected %s", err, split.OutputFileExists)
+	}
+	if data, err := os.ReadFile(clobberDir + "x.trailer"); err != nil || string(data) != "keep" {
+		t.Errorf("Unexpected content of x.trailer: %q, %v", data, err)
+	}
+
+	// --trailer-bytes がない場合は書き込む内容がないため、無視せずにエラーとする
+	err = cli.Run([]string{"split", "-n", "2", "--trailer-file", inputFilePath, otherDir + "y"})
+	if !errors.Is(err, split.TrailerFileRequiresBytes) {
+		t.Errorf("Unexpected error: got %v, expected %s", err, split.TrailerFileRequiresBytes)
+	}
+
+	// 標準入力などのシークできない入力では末尾を求められない
+	cli.Stdin = struct{ io.Reader }{strings.NewReader("0123456789")}
+	if err := cli.Run([]string{"split", "--trailer-bytes", "6", "-", otherDir + "stdin"}); !errors.Is(err, split.CannotDetermineFileSize) {
+		t.Errorf("Unexpected error: got %v, expected %s", err, split.CannotDetermineFileSize)
+	}
+}
+
+func TestCLIRunInputWindow(t *testing.T) {
+	outputDir := t.TempDir() + "/"
+	inputFilePath := outputDir + "input.txt"
+	if err := os.WriteFile(inputFilePath, []byte("0123456789"), 0644); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	cli := &CLI{Stdin: os.Stdin, Stdout: io.Discard, Stderr: io.Discard}
+	if err := cli.Run([]string{"split", "-n", "2", "--input-offset", "2", "--input-limit", "6", inputFilePath, outputDir + "x"}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	for name, expected := range map[string]string{"xaa": "234", "xab": "567"} {
+		data, err := os.ReadFile(outputDir + name)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if string(data) != expected {
+			t.Errorf("Unexpected content of %s: got %q, expected %q", name, data, expected)
+		}
+	}
+
+	if err := cli.Run([]string{"split", "--input-offset", "1X", inputFilePath, outputDir + "y"}); !errors.Is(err, split.InvalidByteSizeFormat) {
+		t.Errorf("Unexpected error: got %v, expected %s", err, split.InvalidByteSizeFormat)
+	}
+}
+
+func TestCLIRunHeaderLines(t *testing.T) {
+	outputDir := t.TempDir() + "/"
+	inputFilePath := outputDir + "input.csv"
+	if err := os.WriteFile(inputFilePath, []byte("id,name\n1,a\n2,b\n3,c\n"), 0644); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	cli := &CLI{Stdin: os.Stdin, Stdout: io.Discard, Stderr: io.Discard}
+	if err := cli.Run([]string{"split", "--header-lines", "1", "-l", "2", inputFilePath, outputDir + "x"}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	for name, expected := range map[string]string{"xaa": "id,name\n1,a\n2,b\n", "xab": "id,name\n3,c\n"} {
+		data, err := os.ReadFile(outputDir + name)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if string(data) != expected {
+			t.Errorf("Unexpected content of %s: got %q, expected %q", name, data, expected)
+		}
+	}
+}
+
+func TestCLIRunWindowsPrefix(t *testing.T) {
+	outputDir := t.TempDir() + "/"
+	inputFilePath := outputDir + "input.txt"
+	if err := os.WriteFile(inputFilePath, []byte("1\n2\n"), 0644); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	// ドライブ文字の後のコロンは -n の K/N などと解釈せず、最後の引数をそのまま接頭辞とする
+	for _, prefix := range []string{`C:\out\x`, "C:/out/x"} {
+		t.Run(prefix, func(t *testing.T) {
+			stdout := &bytes.Buffer{}
+			cli := &CLI{Stdin: os.Stdin, Stdout: stdout, Stderr: io.Discard}
+			if err := cli.Run([]string{"split", "--dry-run", "-n", "2", inputFilePath, prefix}); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if expected := prefix + "aa\n" + prefix + "ab\n"; stdout.String() != expected {
+				t.Errorf("Unexpected output file names: got %q, expected %q", stdout.String(), expected)
+			}
+		})
+	}
+}
+
+func TestCLIRunRecordMarker(t *testing.T) {
+	tests := []struct {
+		valid    bool
+		args     []string
+		expected []string
+	}{
+		{true, []string{"--record-marker", "1e1f", "--records-per-file", "3"}, []string{"\x1e\x1fa\x1e\x1fb\x1e\x1fc", "\x1e\x1fd"}},
+		{true, []string{"--record-marker", "1e1f", "--records-per-file", "2"}, []string{"\x1e\x1fa\x1e\x1fb", "\x1e\x1fc\x1e\x1fd"}},
+		{false, []string{"--record-marker", "xyz"}, nil},
+		{false, []string{"--records-per-file", "3"}, nil},
+		{false, []string{"--record-marker", "1e1f", "-l", "1"}, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(strings.Join(test.args, " "), func(t *testing.T) {
+			outputDir := t.TempDir() + "/"
+			inputFilePath := outputDir + "input.bin"
+			if err := os.WriteFile(inputFilePath, []byte("\x1e\x1fa\x1e\x1fb\x1e\x1fc\x1e\x1fd"), 0644); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+
+			cli := &CLI{Stdin: os.Stdin, Stdout: io.Discard, Stderr: io.Discard}
+			err := cli.Run(append(append([]string{"split"}, test.args...), inputFilePath, outputDir+"x"))
+			if err != nil && test.valid {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if err == nil && !test.valid {
+				t.Fatalf("Unexpected result : error is nil")
+			}
+
+			for i, expected := range test.expected {
+				name := outputDir + []string{"xaa", "xab"}[i]
+				data, err := os.ReadFile(name)
+				if err != nil {
+					t.Fatalf("Unexpected error: %s", err)
+				}
+				if string(data) != expected {
+					t.Errorf("Unexpected content of %s: got %q, expected %q", name, data, expected)
+				}
+			}
+		})
+	}
+}
+
+func TestPercentSizes(t *testing.T) {
+	tests := []struct {
+		valid    bool
+		size     int
+		percent  uint64
+		expected []uint64
+	}{
+		{true, 100, 25, []uint64{25, 25, 25, 25}},
+		{true, 100, 33, []uint64{33, 33, 34}},
+		{true, 100, 30, []uint64{30, 30, 40}},
+		{true, 100, 100, []uint64{100}},
+		{true, 10, 1, []uint64{10}},
+		{true, 10, 25, []uint64{2, 3, 2, 3}},
+		{true, 21, 33, []uint64{6, 7, 8}},
+		{true, 150, 50, []uint64{75, 75}},
+		{true, 0, 50, []uint64{1}},
+		{false, 100, 0, nil},
+		{false, 100, 101, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("size: %d percent: %d", test.size, test.percent), func(t *testing.T) {
+			sizes, err := percentSizes(strings.NewReader(strings.Repeat("a", test.size)), test.percent)
+			if err != nil && test.valid {
+				t.Errorf("Unexpected error: %s", err)
+			}
+			if err == nil && !test.valid {
+				t.Errorf("Unexpected result : error is nil, result is %v", sizes)
+			}
+			if test.valid && fmt.Sprint(sizes) != fmt.Sprint(test.expected) {
+				t.Errorf("Unexpected result: got %v, expected %v", sizes, test.expected)
+			}
+		})
+	}
+
+	// 入力のサイズが100で割り切れない場合もファイル数は 100/percent となる
+	sizes, err := percentSizes(strings.NewReader(strings.Repeat("a", 150)), 1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	total := uint64(0)
+	for _, size := range sizes {
+		total += size
+	}
+	if len(sizes) != 100 || total != 150 {
+		t.Errorf("Unexpected result: got %d files of %d bytes, expected 100 files of 150 bytes", len(sizes), total)
+	}
+
+	if _, err := percentSizes(struct{ io.Reader }{strings.NewReader("a")}, 25); !errors.Is(err, split.CannotDetermineFileSize) {
+		t.Errorf("Unexpected error: got %v, expected %s", err, split.CannotDetermineFileSize)
+	}
+}
+
+func TestCLIRunPercent(t *testing.T) {
+	tests := []struct {
+		percent       string
+		expectedSizes []int
+	}{
+		{"25", []int{25, 25, 25, 25}},
+		{"33", []int{33, 33, 34}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.percent, func(t *testing.T) {
+			outputDir := t.TempDir() + "/"
+			inputFilePath := outputDir + "input.txt"
+			if err := os.WriteFile(inputFilePath, bytes.Repeat([]byte("a"), 100), 0644); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+
+			cli := &CLI{Stdin: os.Stdin, Stdout: io.Discard, Stderr: io.Discard}
+			if err := cli.Run([]string{"split", "--percent", test.percent, inputFilePath, outputDir + "x"}); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			entries, err := os.ReadDir(outputDir)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			// 入力ファイルと出力ファイル
+			if len(entries) != len(test.expectedSizes)+1 {
+				t.Fatalf("Unexpected number of files: got %d, expected %d", len(entries)-1, len(test.expectedSizes))
+			}
+			for i, expected := range test.expectedSizes {
+				info, err := os.Stat(outputDir + "xa" + string(rune('a'+i)))
+				if err != nil {
+					t.Fatalf("Unexpected error: %s", err)
+				}
+				if info.Size() != int64(expected) {
+					t.Errorf("Unexpected size of %s: got %d, expected %d", info.Name(), info.Size(), expected)
+				}
+			}
+		})
+	}
+
+	// 標準入力のパイプはサイズが分からない
+	cli := &CLI{Stdin: struct{ io.Reader }{strings.NewReader("abc")}, Stdout: io.Discard, Stderr: io.Discard}
+	if err := cli.Run([]string{"split", "--percent", "25", "-", t.TempDir() + "/x"}); !errors.Is(err, split.CannotDetermineFileSize) {
+		t.Errorf("Unexpected error: got %v, expected %s", err, split.CannotDetermineFileSize)
+	}
+}
+
+func TestCLIRunRotateInterval(t *testing.T) {
+	tests := []struct {
+		valid         bool
+		args          []string
+		expectedFiles int
+	}{
+		{true, []string{"--rotate-interval", "1h"}, 1},
+		{true, []string{"--rotate-interval", "1h", "-l", "2"}, 2},
+		{false, []string{"--rotate-interval", "abc"}, 0},
+		{false, []string{"--rotate-interval", "0s"}, 0},
+		{false, []string{"--rotate-interval", "1h", "-b", "10"}, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(strings.Join(test.args, " "), func(t *testing.T) {
+			outputDir := t.TempDir() + "/"
+			inputFilePath := outputDir + "input.log"
+			if err := os.WriteFile(inputFilePath, []byte("1\n2\n3\n"), 0644); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+
+			cli := &CLI{Stdin: os.Stdin, Stdout: io.Discard, Stderr: io.Discard}
+			err := cli.Run(append(append([]string{"split"}, test.args...), inputFilePath, outputDir+"log-"))
+			if err != nil && test.valid {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if err == nil && !test.valid {
+				t.Fatalf("Unexpected result : error is nil")
+			}
+
+			// 同じ時刻に作成したファイルも番号で区別する
+			names, err := filepath.Glob(outputDir + "log-*")
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if len(names) != test.expectedFiles {
+				t.Fatalf("Unexpected output files: got %v, expected %d files", names, test.expectedFiles)
+			}
+			pattern := regexp.MustCompile(`^log-\d{8}T\d{6}-\d+$`)
+			for _, name := range names {
+				if !pattern.MatchString(filepath.Base(name)) {
+					t.Errorf("Unexpected output file name: %s", name)
+				}
+			}
+		})
+	}
+}
+
+func TestCLIRunVerify(t *testing.T) {
+	outputDir := t.TempDir() + "/"
+	inputFilePath := outputDir + "input.txt"
+	if err := os.WriteFile(inputFilePath, []byte("1\n2\n3\n4\n5\n"), 0644); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	cli := &CLI{Stdin: os.Stdin, Stdout: io.Discard, Stderr: io.Discard}
+	if err := cli.Run([]string{"split", "-d", "-l", "2", inputFilePath, outputDir + "x"}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	stdout := &bytes.Buffer{}
+	cli.Stdout = stdout
+	if err := cli.Run([]string{"split", "--verify", "-d", outputDir + "x", inputFilePath}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if expected := "OK: 3 files match " + inputFilePath + "\n"; stdout.String() != expected {
+		t.Errorf("Unexpected output: got %q, expected %q", stdout.String(), expected)
+	}
+
+	if err := os.WriteFile(outputDir+"x01", []byte("3\n"), 0644); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	err := cli.Run([]string{"split", "--verify", "-d", outputDir + "x", inputFilePath})
+	if !errors.Is(err, split.VerificationFailed) || exitCode(err) != ExitFailure {
+		t.Errorf("Unexpected error: got %v, expected %s", err, split.VerificationFailed)
+	}
+
+	if err := cli.Run([]string{"split", "--verify", outputDir + "x"}); !errors.Is(err, split.InvalidNumberOfArguments) {
+		t.Errorf("Unexpected error: got %v, expected %s", err, split.InvalidNumberOfArguments)
+	}
+}
+
+func TestCLIRunJoin(t *testing.T) {
+	outputDir := t.TempDir() + "/"
+	inputFilePath := outputDir + "input.txt"
+	if err := os.WriteFile(inputFilePath, []byte("1\n2\n3\n4\n5\n"), 0644); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	cli := &CLI{Stdin: os.Stdin, Stdout: io.Discard, Stderr: io.Discard}
+	if err := cli.Run([]string{"split", "-l", "2", "--additional-suffix", ".part", inputFilePath, outputDir + "x"}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if err := cli.Run([]string{"split", "--join", "--additional-suffix", ".part", outputDir + "x", outputDir + "joined.txt"}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	joined, err := os.ReadFile(outputDir + "joined.txt")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if string(joined) != "1\n2\n3\n4\n5\n" {
+		t.Errorf("Unexpected output: got %q", joined)
+	}
+
+	stdout := &bytes.Buffer{}
+	cli.Stdout = stdout
+	if err := cli.Run([]string{"split", "--join", "--additional-suffix", ".part", outputDir + "x", "-"}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if stdout.String() != "1\n2\n3\n4\n5\n" {
+		t.Errorf("Unexpected output: got %q", stdout.String())
+	}
+
+	// 連結するファイルがない場合は出力ファイルを残さない
+	err = cli.Run([]string{"split", "--join", outputDir + "y", outputDir + "missing.txt"})
+	if !errors.Is(err, split.SplitFilesNotFound) {
+		t.Errorf("Unexpected error: got %v, expected %s", err, split.SplitFilesNotFound)
+	}
+	if _, err := os.Stat(outputDir + "missing.txt"); !os.IsNotExist(err) {
+		t.Errorf("Output file was left: %v", err)
+	}
+
+	// 連結するファイルを連結先にすると切り詰めてしまうため、書き込まずにエラーとする
+	err = cli.Run([]string{"split", "--join", "--additional-suffix", ".part", outputDir + "x", outputDir + "xab.part"})
+	if !errors.Is(err, split.JoinOutputIsInput) {
+		t.Errorf("Unexpected error: got %v, expected %s", err, split.JoinOutputIsInput)
+	}
+	if data, err := os.ReadFile(outputDir + "xab.part"); err != nil || string(data) != "3\n4\n" {
+		t.Errorf("Unexpected content of xab.part: got %q (error: %v)", data, err)
+	}
+
+	// --no-clobber の場合は既存の連結先を上書きしない
+	err = cli.Run([]string{"split", "--join", "--no-clobber", "--additional-suffix", ".part", outputDir + "x", outputDir + "joined.txt"})
+	if !errors.Is(err, split.OutputFileExists) {
+		t.Errorf("Unexpected error: got %v, expected %s", err, split.OutputFileExists)
+	}
+
+	if err := cli.Run([]string{"split", "--join", "--verify", outputDir + "x", inputFilePath}); !errors.Is(err, split.YouMustSpecifyOnlyOneOption) {
+		t.Errorf("Unexpected error: got %v, expected %s", err, split.YouMustSpecifyOnlyOneOption)
+	}
+}
+
+func TestCLIRunUnits(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected []int
+	}{
+		{[]string{"-b", "1K"}, []int{1024, 976}},
+		{[]string{"--si", "-b", "1K"}, []int{1000, 1000}},
+		{[]string{"-b", "1KB"}, []int{1000, 1000}},
+		{[]string{"--iec", "-b", "1KB"}, []int{1024, 976}},
+	}
+	for _, test := range tests {
+		t.Run(strings.Join(test.args, " "), func(t *testing.T) {
+			outputDir := t.TempDir() + "/"
+			cli := &CLI{Stdin: strings.NewReader(strings.Repeat("a", 2000)), Stdout: io.Discard, Stderr: io.Discard}
+			args := append(append([]string{"split"}, test.args...), "-", outputDir+"x")
+			if err := cli.Run(args); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			for i, name := range []string{"xaa", "xab"} {
+				info, err := os.Stat(outputDir + name)
+				if err != nil {
+					t.Fatalf("Unexpected error: %s", err)
+				}
+				if info.Size() != int64(test.expected[i]) {
+					t.Errorf("Unexpected size of %s: got %d, expected %d", name, info.Size(), test.expected[i])
+				}
+			}
+		})
+	}
+
+	cli := &CLI{Stdin: strings.NewReader(""), Stdout: io.Discard, Stderr: io.Discard}
+	if err := cli.Run([]string{"split", "--si", "--iec", "-b", "1K"}); !errors.Is(err, split.YouMustSpecifyOnlyOneOption) {
+		t.Errorf("Unexpected error: got %v, expected %s", err, split.YouMustSpecifyOnlyOneOption)
+	}
+}
+
+func TestCLIRunCreateDirs(t *testing.T) {
+	outputDir := t.TempDir() + "/"
+	cli := &CLI{Stdin: strings.NewReader("1\n2\n"), Stdout: io.Discard, Stderr: io.Discard}
+	err := cli.Run([]string{"split", "-l", "1", "-", outputDir + "out/part-"})
+	if !errors.Is(err, split.OutputDirectoryNotFound) || exitCode(err) != ExitFailure {
+		t.Errorf("Unexpected error: got %v, expected %s", err, split.OutputDirectoryNotFound)
+	}
+
+	cli.Stdin = strings.NewReader("1\n2\n")
+	if err := cli.Run([]string{"split", "--create-dirs", "-l", "1", "-", outputDir + "out/part-"}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	data, err := os.ReadFile(outputDir + "out/part-ab")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if string(data) != "2\n" {
+		t.Errorf("Unexpected content: got %q", data)
+	}
+}
+
+func TestCLIRunSuffixStart(t *testing.T) {
+	tests := []struct {
+		valid    bool
+		args     []string
+		expected []string
+	}{
+		{true, []string{"--suffix-start=ba"}, []string{"xba", "xbb", "xbc"}},
+		// 開始値を指定した場合は桁数を増やさない
+		{false, []string{"--suffix-start=zy"}, nil},
+		{true, []string{"--suffix-start=b", "-a", "3"}, []string{"xaab", "xaac", "xaad"}},
+		{false, []string{"--suffix-start=bA"}, nil},
+		{false, []string{"--suffix-start=baa"}, nil},
+		{false, []string{"--suffix-start=ba", "-d"}, nil},
+	}
+	for _, test := range tests {
+		t.Run(strings.Join(test.args, " "), func(t *testing.T) {
+			outputDir := t.TempDir() + "/"
+			cli := &CLI{Stdin: strings.NewReader("1\n2\n3\n"), Stdout: io.Discard, Stderr: io.Discard}
+			args := append(append([]string{"split", "-l", "1"}, test.args...), "-", outputDir+"x")
+			err := cli.Run(args)
+			if err != nil && test.valid {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if err == nil && !test.valid {
+				t.Fatalf("Unexpected result: error is nil")
+			}
+
+			entries, err := os.ReadDir(outputDir)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			names := []string{}
+			for _, entry := range entries {
+				names = append(names, entry.Name())
+			}
+			if strings.Join(names, ",") != strings.Join(test.expected, ",") {
+				t.Errorf("Unexpected files: got %v, expected %v", names, test.expected)
+			}
+		})
+	}
+}
+
+func TestCLIRunEmptyInput(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected []string
+	}{
+		{[]string{"-b", "3"}, []string{}},
+		{[]string{"-l", "3"}, []string{}},
+		{[]string{}, []string{}},
+		{[]string{"-n", "3"}, []string{"xaa", "xab", "xac"}},
+		{[]string{"-n", "r/2"}, []string{"xaa", "xab"}},
+		{[]string{"-e", "-n", "3"}, []string{}},
+	}
+	for _, test := range tests {
+		t.Run(strings.Join(test.args, " "), func(t *testing.T) {
+			outputDir := t.TempDir() + "/"
+			cli := &CLI{Stdin: strings.NewReader(""), Stdout: io.Discard, Stderr: io.Discard}
+			args := append(append([]string{"split"}, test.args...), "-", outputDir+"x")
+			if err := cli.Run(args); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+
+			entries, err := os.ReadDir(outputDir)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			names := []string{}
+			for _, entry := range entries {
+				names = append(names, entry.Name())
+			}
+			if strings.Join(names, ",") != strings.Join(test.expected, ",") {
+				t.Errorf("Unexpected files: got %v, expected %v", names, test.expected)
+			}
+		})
+	}
+}
+
+func TestCLIRunMaxFiles(t *testing.T) {
+	outputDir := t.TempDir() + "/"
+	cli := &CLI{Stdin: strings.NewReader("1234567890"), Stdout: io.Discard, Stderr: io.Discard}
+	err := cli.Run([]string{"split", "--max-files", "4", "--no-cleanup", "-b", "1", "-", outputDir + "x"})
+	if !errors.Is(err, split.TooManyOutputFiles) || exitCode(err) != ExitFailure {
+		t.Errorf("Unexpected error: got %v, expected %s", err, split.TooManyOutputFiles)
+	}
+
+	entries, err := os.ReadDir(outputDir)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if len(entries) != 4 {
+		t.Errorf("Unexpected number of output files: got %d, expected 4", len(entries))
+	}
+}
+
+func TestCLIRunDefaultBytesEnv(t *testing.T) {
+	tests := []struct {
+		valid    bool
+		env      string
+		args     []string
+		expected []string
+	}{
+		{true, "4", []string{}, []string{"1\n2\n", "3\n4\n", "5\n"}},
+		{true, "1K", []string{}, []string{"1\n2\n3\n4\n5\n"}},
+		// コマンドライン引数を優先する
+		{true, "4", []string{"-l", "1"}, []string{"1\n", "2\n", "3\n", "4\n", "5\n"}},
+		{true, "4", []string{"-n", "2"}, []string{"1\n2\n3", "\n4\n5\n"}},
+		{true, "", []string{}, []string{"1\n2\n3\n4\n5\n"}},
+		{false, "0", []string{}, nil},
+		{false, "4X", []string{}, nil},
+	}
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%s=%s %s", DefaultBytesEnv, test.env, strings.Join(test.args, " ")), func(t *testing.T) {
+			t.Setenv(DefaultBytesEnv, test.env)
+			outputDir := t.TempDir() + "/"
+			cli := &CLI{Stdin: strings.NewReader("1\n2\n3\n4\n5\n"), Stdout: io.Discard, Stderr: io.Discard}
+			args := append(append([]string{"split"}, test.args...), "-", outputDir+"x")
+			err := cli.Run(args)
+			if err != nil && test.valid {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if err == nil && !test.valid {
+				t.Fatalf("Unexpected result: error is nil")
+			}
+
+			for i, expected := range test.expected {
+				name := outputDir + "x" + []string{"aa", "ab", "ac", "ad", "ae"}[i]
+				data, err := os.ReadFile(name)
+				if err != nil {
+					t.Fatalf("Unexpected error: %s", err)
+				}
+				if string(data) != expected {
+					t.Errorf("Unexpected content of %s: got %q, expected %q", name, data, expected)
+				}
+			}
+		})
+	}
+}
+
+func TestCLIRunJSON(t *testing.T) {
+	tests := []struct {
+		args      []string
+		splitType string
+	}{
+		{[]string{"-b", "4"}, "bytes"},
+		{[]string{"-l", "2"}, "lines"},
+		{[]string{"-n", "r/3"}, "round_robin"},
+		{[]string{"-z", "-n", "2"}, "files"},
+	}
+	for _, test := range tests {
+		t.Run(strings.Join(test.args, " "), func(t *testing.T) {
+			outputDir := t.TempDir() + "/"
+			stdout := &bytes.Buffer{}
+			cli := &CLI{Stdin: strings.NewReader("1\n2\n3\n4\n5\n"), Stdout: stdout, Stderr: io.Discard}
+			args := append(append([]string{"split", "--json"}, test.args...), "-", outputDir+"x")
+			if err := cli.Run(args); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+
+			var result struct {
+				Files []struct {
+					Name  string `json:"name"`
+					Bytes uint64 `json:"bytes"`
+				} `json:"files"`
+				TotalBytes uint64 `json:"total_bytes"`
+				SplitType  string `json:"split_type"`
+			}
+			if err := json.Unmarshal(stdout.Bytes(), &result); err != nil {
+				t.Fatalf("Unexpected error: %s: %q", err, stdout.String())
+			}
+			if result.SplitType != test.splitType {
+				t.Errorf("Unexpected split type: got %s, expected %s", result.SplitType, test.splitType)
+			}
+			if result.TotalBytes != 10 {
+				t.Errorf("Unexpected total bytes: got %d, expected 10", result.TotalBytes)
+			}
+
+			// 一覧のファイルが出力先のファイルと一致する
+			entries, err := os.ReadDir(outputDir)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if len(entries) != len(result.Files) {
+				t.Fatalf("Unexpected number of files: got %d, expected %d", len(result.Files), len(entries))
+			}
+			for i, file := range result.Files {
+				if file.Name != outputDir+entries[i].Name() {
+					t.Errorf("Unexpected name: got %s, expected %s", file.Name, outputDir+entries[i].Name())
+				}
+				if setGzip := test.args[0] == "-z"; !setGzip {
+					info, err := entries[i].Info()
+					if err != nil {
+						t.Fatalf("Unexpected error: %s", err)
+					}
+					if uint64(info.Size()) != file.Bytes {
+						t.Errorf("Unexpected bytes of %s: got %d, expected %d", file.Name, file.Bytes, info.Size())
+					}
+				}
+			}
+		})
+	}
+
+	cli := &CLI{Stdin: strings.NewReader("1\n"), Stdout: io.Discard, Stderr: io.Discard}
+	if err := cli.Run([]string{"split", "--json", "-n", "1", "-", "-"}); !errors.Is(err, split.YouMustSpecifyOnlyOneOption) {
+		t.Errorf("Unexpected error: got %v, expected %s", err, split.YouMustSpecifyOnlyOneOption)
+	}
+
+	// --verbose の出力が混ざると JSON として読めないため、ファイルを作成せずにエラーとする
+	outputDir := t.TempDir() + "/"
+	stdout := &bytes.Buffer{}
+	cli = &CLI{Stdin: strings.NewReader("1\n"), Stdout: stdout, Stderr: io.Discard}
+	if err := cli.Run([]string{"split", "--json", "--verbose", "-l", "1", "-", outputDir + "x"}); !errors.Is(err, split.YouMustSpecifyOnlyOneOption) {
+		t.Errorf("Unexpected error: got %v, expected %s", err, split.YouMustSpecifyOnlyOneOption)
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("Unexpected output: %q", stdout.String())
+	}
+	if entries, err := os.ReadDir(outputDir); err != nil || len(entries) != 0 {
+		t.Errorf("Unexpected files were created: %v (error: %v)", entries, err)
+	}
+}
+
+func TestCLIRunSuffixAlphabet(t *testing.T) {
+	tests := []struct {
+		valid    bool
+		args     []string
+		expected []string
+	}{
+		{true, []string{"--suffix-alphabet=0123456789"}, []string{"x00", "x01", "x02"}},
+		{true, []string{"--suffix-alphabet=ABC"}, []string{"xAA", "xAB", "xAC"}},
+		{true, []string{"--suffix-alphabet=ABC", "--suffix-start=BA"}, []string{"xBA", "xBB", "xBC"}},
+		{false, []string{"--suffix-alphabet=A"}, nil},
+		{false, []string{"--suffix-alphabet=ABA"}, nil},
+		{false, []string{"--suffix-alphabet=ABC", "-d"}, nil},
+		{false, []string{"--suffix-alphabet=ABC", "--suffix-start=ba"}, nil},
+	}
+	for _, test := range tests {
+		t.Run(strings.Join(test.args, " "), func(t *testing.T) {
+			outputDir := t.TempDir() + "/"
+			cli := &CLI{Stdin: strings.NewReader("1\n2\n3\n"), Stdout: io.Discard, Stderr: io.Discard}
+			args := append(append([]string{"split", "-l", "1"}, test.args...), "-", outputDir+"x")
+			err := cli.Run(args)
+			if err != nil && test.valid {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if err == nil && !test.valid {
+				t.Fatalf("Unexpected result: error is nil")
+			}
+
+			entries, err := os.ReadDir(outputDir)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			names := []string{}
+			for _, entry := range entries {
+				names = append(names, entry.Name())
+			}
+			if strings.Join(names, ",") != strings.Join(test.expected, ",") {
+				t.Errorf("Unexpected files: got %v, expected %v", names, test.expected)
+			}
+		})
+	}
+}
+
+func TestCLIRunBalancedLinesFromStdin(t *testing.T) {
+	// 一時ファイルを作成するディレクトリ
+	tempDir := t.TempDir()
+	t.Setenv("TMPDIR", tempDir)
+
+	outputDir := t.TempDir() + "/"
+	input := ""
+	for i := 1; i <= 10; i++ {
+		input += fmt.Sprintf("line %d\n", i)
+	}
+	cli := &CLI{Stdin: strings.NewReader(input), Stdout: io.Discard, Stderr: io.Discard}
+	if err := cli.Run([]string{"split", "-n", "L/4", "-", outputDir + "out-"}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	// 10行を4つに分けると3行と2行になる
+	joined := ""
+	for name, expected := range map[string]int{"out-aa": 3, "out-ab": 3, "out-ac": 2, "out-ad": 2} {
+		data, err := os.ReadFile(outputDir + name)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if lines := strings.Count(string(data), "\n"); lines != expected {
+			t.Errorf("Unexpected number of lines in %s: got %d, expected %d", name, lines, expected)
+		}
+	}
+	for _, name := range []string{"out-aa", "out-ab", "out-ac", "out-ad"} {
+		data, _ := os.ReadFile(outputDir + name)
+		joined += string(data)
+	}
+	if joined != input {
+		t.Errorf("Unexpected joined content: %q", joined)
+	}
+
+	// 失敗した場合も一時ファイルを削除する
+	cli.Stdin = strings.NewReader(input)
+	if err := cli.Run([]string{"split", "--no-clobber", "-n", "L/4", "-", outputDir + "out-"}); !errors.Is(err, split.OutputFileExists) {
+		t.Errorf("Unexpected error: got %v, expected %s", err, split.OutputFileExists)
+	}
+	entries, err := os.ReadDir(tempDir)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("Temporary files were left: %v", entries)
+	}
+}
+
+func TestCLIRunPrefixFlagOverridesPositional(t *testing.T) {
+	outputDir := t.TempDir() + "/"
+	inputFilePath := outputDir + "input.txt"
+	positionalPath := outputDir + "positional"
+	if err := os.WriteFile(inputFilePath, []byte("1\n2\n"), 0644); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if err := os.WriteFile(positionalPath, []byte("3\n"), 0644); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	// --prefix を指定した場合、最後の引数は接頭辞ではなく入力として扱う
+	cli := &CLI{Stdin: os.Stdin, Stdout: io.Discard, Stderr: io.Discard}
+	if err := cli.Run([]string{"split", "-l", "2", "--prefix", outputDir + "flag", inputFilePath, positionalPath}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	entries, err := os.ReadDir(outputDir)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	names := []string{}
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	if strings.Join(names, ",") != "flagaa,flagab,input.txt,positional" {
+		t.Errorf("Unexpected files: %v", names)
+	}
+	data, err := os.ReadFile(outputDir + "flagab")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if string(data) != "3\n" {
+		t.Errorf("Unexpected content of flagab: got %q", data)
+	}
+}
+
+func TestCLIRunQuiet(t *testing.T) {
+	tests := [][]string{
+		{"--quiet", "--verbose", "-l", "1"},
+		{"--verbose", "--quiet", "-l", "1"},
+		{"--quiet", "--summary", "--verbose", "-n", "2"},
+	}
+	for _, args := range tests {
+		t.Run(strings.Join(args, " "), func(t *testing.T) {
+			outputDir := t.TempDir() + "/"
+			stdout := &bytes.Buffer{}
+			stderr := &bytes.Buffer{}
+			cli := &CLI{Stdin: strings.NewReader("1\n2\n"), Stdout: stdout, Stderr: stderr}
+			if err := cli.Run(append(append([]string{"split"}, args...), "-", outputDir+"x")); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if stdout.Len() != 0 || stderr.Len() != 0 {
+				t.Errorf("Unexpected output: stdout %q, stderr %q", stdout.String(), stderr.String())
+			}
+			if _, err := os.Stat(outputDir + "xab"); err != nil {
+				t.Errorf("Unexpected error: %s", err)
+			}
+		})
+	}
+
+	// 確認の結果も出力しない
+	outputDir := t.TempDir() + "/"
+	if err := os.WriteFile(outputDir+"xaa", []byte("1\n"), 0644); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	stdout := &bytes.Buffer{}
+	cli := &CLI{Stdin: os.Stdin, Stdout: stdout, Stderr: io.Discard}
+	if err := cli.Run([]string{"split", "--quiet", "--verify", outputDir + "x", outputDir + "xaa"}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("Unexpected output: %q", stdout.String())
+	}
+}
+
+func TestCLIRunDecompress(t *testing.T) {
+	outputDir := t.TempDir() + "/"
+	inputFilePath := outputDir + "input.gz"
+	compressed := &bytes.Buffer{}
+	gzipWriter := gzip.NewWriter(compressed)
+	gzipWriter.Write([]byte("1\n2\n3\n4\n5\n"))
+	gzipWriter.Close()
+	if err := os.WriteFile(inputFilePath, compressed.Bytes(), 0644); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	tests := []struct {
+		args     []string
+		expected map[string]string
+	}{
+		{[]string{"-l", "2"}, map[string]string{"xaa": "1\n2\n", "xab": "3\n4\n", "xac": "5\n"}},
+		{[]string{"-b", "4"}, map[string]string{"xaa": "1\n2\n", "xab": "3\n4\n", "xac": "5\n"}},
+		{[]string{"-n", "2"}, map[string]string{"xaa": "1\n2\n3", "xab": "\n4\n5\n"}},
+		// 展開してから再び圧縮する
+		{[]string{"-z", "-l", "3"}, map[string]string{"xaa.gz": "1\n2\n3\n", "xab.gz": "4\n5\n"}},
+	}
+	for _, test := range tests {
+		t.Run(strings.Join(test.args, " "), func(t *testing.T) {
+			dir := t.TempDir() + "/"
+			cli := &CLI{Stdin: os.Stdin, Stdout: io.Discard, Stderr: io.Discard}
+			args := append(append([]string{"split", "--decompress=gzip"}, test.args...), inputFilePath, dir+"x")
+			if err := cli.Run(args); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if len(entries) != len(test.expected) {
+				t.Errorf("Unexpected number of files: got %d, expected %d", len(entries), len(test.expected))
+			}
+			for name, expected := range test.expected {
+				file, err := os.Open(dir + name)
+				if err != nil {
+					t.Fatalf("Unexpected error: %s", err)
+				}
+				defer file.Close()
+				var reader io.Reader = file
+				if strings.HasSuffix(name, ".gz") {
+					gzipReader, err := gzip.NewReader(file)
+					if err != nil {
+						t.Fatalf("Unexpected error: %s", err)
+					}
+					reader = gzipReader
+				}
+				data, err := io.ReadAll(reader)
+				if err != nil {
+					t.Fatalf("Unexpected error: %s", err)
+				}
+				if string(data) != expected {
+					t.Errorf("Unexpected content of %s: got %q, expected %q", name, data, expected)
+				}
+			}
+		})
+	}
+
+	cli := &CLI{Stdin: strings.NewReader("not compressed"), Stdout: io.Discard, Stderr: io.Discard}
+	if err := cli.Run([]string{"split", "--decompress=gzip", "-", outputDir + "y"}); !errors.Is(err, split.InvalidCompressedInput) {
+		t.Errorf("Unexpected error: got %v, expected %s", err, split.InvalidCompressedInput)
+	}
+	cli.Stdin = bytes.NewReader(compressed.Bytes())
+	if err := cli.Run([]string{"split", "--decompress=bzip2", "-", outputDir + "y"}); !errors.Is(err, split.InvalidDecompression) {
+		t.Errorf("Unexpected error: got %v, expected %s", err, split.InvalidDecompression)
+	}
+}
+
+func TestCLIRunInputDirectory(t *testing.T) {
+	outputDir := t.TempDir() + "/"
+	inputDir := t.TempDir()
+	inputFilePath := outputDir + "input.txt"
+	if err := os.WriteFile(inputFilePath, []byte("1\n"), 0644); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	for _, args := range [][]string{
+		{inputDir, outputDir + "x"},
+		{"-p", outputDir + "x", inputFilePath, inputDir},
+	} {
+		t.Run(strings.Join(args, " "), func(t *testing.T) {
+			cli := &CLI{Stdin: os.Stdin, Stdout: io.Discard, Stderr: io.Discard}
+			err := cli.Run(append([]string{"split"}, args...))
+			if !errors.Is(err, split.InputIsDirectory) || !strings.Contains(err.Error(), inputDir) {
+				t.Errorf("Unexpected error: got %v, expected %s", err, split.InputIsDirectory)
+			}
+			if _, err := os.Stat(outputDir + "xaa"); !os.IsNotExist(err) {
+				t.Errorf("Output file was created: %v", err)
+			}
 		})
 	}
 }
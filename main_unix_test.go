@@ -0,0 +1,65 @@
+//go:build unix
+
+package main
+
+import (
+	"errors"
+	"io"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"split/split"
+)
+
+// 割り込まれた場合は書き込み途中のファイルを行の終わりで閉じ、失敗として終了する
+func TestCLIRunInterrupted(t *testing.T) {
+	outputDir := t.TempDir() + "/"
+	stdinReader, stdinWriter := io.Pipe()
+	defer stdinWriter.Close()
+
+	cli := &CLI{Stdin: stdinReader, Stdout: io.Discard, Stderr: io.Discard}
+	done := make(chan error, 1)
+	go func() {
+		done <- cli.Run([]string{"split", "--unbuffered", "-l", "2", "-", outputDir + "x"})
+	}()
+
+	if _, err := stdinWriter.Write([]byte("1\n2\n3\n")); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	// xab の作成を待ってから割り込む (シグナルの受け取りはそれより前に始まっている)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if data, err := os.ReadFile(outputDir + "xab"); err == nil && string(data) == "3\n" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Split did not write xab")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := syscall.Kill(os.Getpid(), syscall.SIGINT); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	// 次の行を待っている読み込みも打ち切られ、入力を閉じなくても終わる
+	select {
+	case err := <-done:
+		if !errors.Is(err, split.Interrupted) {
+			t.Fatalf("Unexpected error: got %v, expected %s", err, split.Interrupted)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Split did not finish after SIGINT")
+	}
+
+	for name, expected := range map[string]string{"xaa": "1\n2\n", "xab": "3\n"} {
+		data, err := os.ReadFile(outputDir + name)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if string(data) != expected {
+			t.Errorf("Unexpected content of %s: got %q, expected %q", name, data, expected)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package split
+
+import (
+	"io"
+)
+
+// EachChunk は出力ファイルを作成する代わりに、チャンクごとにその内容を読み込む io.Reader を渡して fn を呼び出す
+// チャンクの区切り方は出力ファイルと同じで、index は出力ファイルの番号となる
+// ファイルシステムには触れないため、Filter、Atomic、Checksum、OnChunkComplete などの出力ファイルに関する指定は用いない
+// Gzip も出力ファイルに関する指定として用いず、fn には圧縮前の内容を渡す
+//
+// fn は次のチャンクを始める前に返るが、ByRoundRobin ではすべてのチャンクを同時に書き込むため並行して呼び出される
+// fn が r を最後まで読まずに nil を返した場合、残りの内容は読み捨てる
+// fn がエラーを返した場合は分割を中断してそのエラーを返す
+// 分割がエラーで終わった場合、fn には途中までの内容が渡っていることがある
+func (s *Splitter) EachChunk(fn func(index uint64, r io.Reader) error) error {
+	chunks := *s
+	chunks.Filter = ""
+	chunks.Gzip = false
+	chunks.Verbose = nil
+	chunks.Checksum = ""
+	chunks.OnChunkComplete = nil
+	chunks.Atomic = false
+	chunks.NoClobber = false
+	// 出力先を作成しない Splitter として扱い、検査や削除でファイルシステムに触れないようにする
+	chunks.WriterFactory = func(name string) (io.WriteCloser, error) {
+		return discardWriteCloser{}, nil
+	}
+	chunks.OutputRemover = nil
+	chunks.startChunk = func(index uint64) io.WriteCloser {
+		reader, writer := io.Pipe()
+		done := make(chan error, 1)
+		go func() {
+			err := fn(index, reader)
+			if err == nil {
+				_, err = io.Copy(io.Discard, reader)
+			}
+			reader.CloseWithError(err)
+			done <- err
+		}()
+		return &chunkWriteCloser{writer: writer, done: done}
+	}
+
+	return chunks.Split()
+}
+
+// chunkWriteCloser は書き込んだ内容を EachChunk の関数に渡し、Close でその関数が返るのを待つ
+type chunkWriteCloser struct {
+	writer *io.PipeWriter
+	done   chan error
+	err    error
+	closed bool
+}
+
+func (w *chunkWriteCloser) Write(p []byte) (int, error) {
+	return w.writer.Write(p)
+}
+
+func (w *chunkWriteCloser) Close() error {
+	if !w.closed {
+		w.closed = true
+		w.writer.Close()
+		w.err = <-w.done
+	}
+	return w.err
+}
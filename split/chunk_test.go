@@ -0,0 +1,120 @@
+package split
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestSplitterEachChunk(t *testing.T) {
+	tests := []struct {
+		splitType SplitType
+		count     uint64
+		expected  []string
+	}{
+		{ByBytes, 4, []string{"1\n2\n", "3\n4\n", "5\n"}},
+		{ByLines, 2, []string{"1\n2\n", "3\n4\n", "5\n"}},
+		{ByFiles, 2, []string{"1\n2\n3", "\n4\n5\n"}},
+		{ByLineFiles, 2, []string{"1\n2\n3\n", "4\n5\n"}},
+		{ByRoundRobin, 2, []string{"1\n3\n5\n", "2\n4\n"}},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("splitType: %v", test.splitType), func(t *testing.T) {
+			// ファイルを作成しないことを確認するため、空のディレクトリを接頭辞とする
+			outputDir := t.TempDir() + "/"
+			prefix := outputDir + "x"
+			var mutex sync.Mutex
+			chunks := map[uint64]string{}
+			splitter := NewSplitter(test.splitType, test.count, strings.NewReader("1\n2\n3\n4\n5\n"), prefix)
+			err := splitter.EachChunk(func(index uint64, r io.Reader) error {
+				data, err := io.ReadAll(r)
+				if err != nil {
+					return err
+				}
+				mutex.Lock()
+				defer mutex.Unlock()
+				chunks[index] = string(data)
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+
+			if len(chunks) != len(test.expected) {
+				t.Fatalf("Unexpected chunks: got %q, expected %q", chunks, test.expected)
+			}
+			for i, expected := range test.expected {
+				if chunks[uint64(i)] != expected {
+					t.Errorf("Unexpected content of chunk %d: got %q, expected %q", i, chunks[uint64(i)], expected)
+				}
+			}
+			entries, err := os.ReadDir(outputDir)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if len(entries) != 0 {
+				t.Errorf("Unexpected files were created: %v", entries)
+			}
+		})
+	}
+}
+
+func TestSplitterEachChunkGzip(t *testing.T) {
+	// Gzip を指定した Splitter でも、圧縮前の内容を渡す
+	chunks := []string{}
+	splitter := NewSplitter(ByLines, 2, strings.NewReader("1\n2\n3\n"), "")
+	splitter.Gzip = true
+	err := splitter.EachChunk(func(index uint64, r io.Reader) error {
+		data, err := io.ReadAll(r)
+		chunks = append(chunks, string(data))
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if expected := []string{"1\n2\n", "3\n"}; strings.Join(chunks, ",") != strings.Join(expected, ",") {
+		t.Errorf("Unexpected chunks: got %q, expected %q", chunks, expected)
+	}
+}
+
+func TestSplitterEachChunkError(t *testing.T) {
+	failure := errors.New("upload failed")
+	calls := 0
+	splitter := NewSplitter(ByLines, 1, strings.NewReader(strings.Repeat("line\n", 100000)), "")
+	err := splitter.EachChunk(func(index uint64, r io.Reader) error {
+		calls++
+		if index == 1 {
+			return failure
+		}
+		return nil
+	})
+	if !errors.Is(err, failure) {
+		t.Errorf("Unexpected error: got %v, expected %v", err, failure)
+	}
+	// エラーの後はチャンクを始めない
+	if calls != 2 {
+		t.Errorf("Unexpected number of calls: got %d, expected 2", calls)
+	}
+}
+
+func TestSplitterEachChunkPartialRead(t *testing.T) {
+	// 最後まで読まずに返しても次のチャンクに進む
+	indexes := []uint64{}
+	splitter := NewSplitter(ByBytes, 1<<20, strings.NewReader(strings.Repeat("a", 3<<20)), "")
+	err := splitter.EachChunk(func(index uint64, r io.Reader) error {
+		indexes = append(indexes, index)
+		_, err := r.Read(make([]byte, 1))
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if len(indexes) != 3 {
+		t.Errorf("Unexpected chunks: %v", indexes)
+	}
+}
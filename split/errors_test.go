@@ -0,0 +1,89 @@
+package split
+
+import (
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestErrorMsgIs(t *testing.T) {
+	outputDir := t.TempDir() + "/"
+	if err := os.WriteFile(outputDir+"xaa", nil, 0644); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	tests := []struct {
+		expected ErrorMsg
+		run      func() error
+	}{
+		{InvalidByteSizeFormat, func() error {
+			_, err := ParseByteSize("10KA")
+			return err
+		}},
+		{OverflowHasOccured, func() error {
+			_, err := ParseByteSize("16384P")
+			return err
+		}},
+		{InvalidIndex, func() error {
+			_, err := GenFileName("", 3, 3, ByFiles, SuffixOptions{})
+			return err
+		}},
+		{OutputFileSuffixesExhausted, func() error {
+			_, err := GenFileName("", 26, 0, ByLines, SuffixOptions{Length: 1})
+			return err
+		}},
+		{InvalidAdditionalSuffix, func() error {
+			_, err := GenFileName("", 0, 0, ByLines, SuffixOptions{Additional: "a/b"})
+			return err
+		}},
+		{InvalidSuffixSeparator, func() error {
+			_, err := GenFileName("", 0, 0, ByLines, SuffixOptions{Separator: "/"})
+			return err
+		}},
+		{InvalidSplitSize, func() error {
+			return NewSplitter(ByBytes, 0, strings.NewReader("1"), outputDir+"x").Split()
+		}},
+		{CannotDetermineFileSize, func() error {
+			_, err := NewSplitter(ByBytes, 1, nonSeekableReader{strings.NewReader("1")}, outputDir+"x").OutputFileNames()
+			return err
+		}},
+		{InvalidChunkNumber, func() error {
+			return NewSplitter(ByFiles, 3, strings.NewReader("123"), outputDir+"x").WriteChunk(io.Discard, 4)
+		}},
+		{FilterFailed, func() error {
+			splitter := NewSplitter(ByLines, 1, strings.NewReader("1\n"), outputDir+"filter")
+			splitter.Filter = "cat > /dev/null; exit 1"
+			return splitter.Split()
+		}},
+		{OutputDirectoryNotWritable, func() error {
+			return NewSplitter(ByLines, 1, strings.NewReader("1\n"), outputDir+"xaa/x").Split()
+		}},
+		{OutputDirectoryNotFound, func() error {
+			return NewSplitter(ByLines, 1, strings.NewReader("1\n"), outputDir+"missing/x").Split()
+		}},
+		{InvalidPattern, func() error {
+			return NewPatternSplitter(nil, strings.NewReader("1\n"), outputDir+"pattern").Split()
+		}},
+		{InvalidLineEnding, func() error {
+			splitter := NewSplitter(ByLines, 1, strings.NewReader("1\n"), outputDir+"line")
+			splitter.LineEnding = LineEndingCRLF
+			splitter.Separator = 0
+			return splitter.Split()
+		}},
+		{OutputFileExists, func() error {
+			splitter := NewSplitter(ByLines, 1, strings.NewReader("1\n"), outputDir+"x")
+			splitter.NoClobber = true
+			return splitter.Split()
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(string(test.expected), func(t *testing.T) {
+			if err := test.run(); !errors.Is(err, test.expected) {
+				t.Errorf("Unexpected error: got %v, expected %s", err, test.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package split_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"split/split"
+)
+
+func ExampleSplitter_Split() {
+	outputDir, err := os.MkdirTemp("", "split-example")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer os.RemoveAll(outputDir)
+
+	reader := strings.NewReader("line1\nline2\nline3\n")
+	splitter := split.NewSplitter(split.ByLines, 2, reader, filepath.Join(outputDir, "part-"))
+	if err := splitter.Split(); err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	entries, err := os.ReadDir(outputDir)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for _, entry := range entries {
+		data, _ := os.ReadFile(filepath.Join(outputDir, entry.Name()))
+		fmt.Printf("%s: %q\n", entry.Name(), data)
+	}
+	// Output:
+	// part-aa: "line1\nline2\n"
+	// part-ab: "line3\n"
+}
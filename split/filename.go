@@ -0,0 +1,290 @@
+package split
+
+import (
+	"bytes"
+	"fmt"
+	"math/bits"
+	"os"
+	"strings"
+)
+
+// 接尾辞の生成方法
+type SuffixOptions struct {
+	// 0の場合は既定の長さから自動で拡張する
+	Length int
+	// 英字の代わりに数字を使用する
+	Numeric bool
+	// 英字の代わりに16進数を使用する (Numeric より優先する)
+	Hex bool
+	// 空でない場合、接尾辞に使う文字を小さい順に並べたもの (Hex や Numeric より優先する)
+	// 重複のない2文字以上の ASCII 文字で、パスの区切り文字は含められない
+	Alphabet string
+	// 最初の出力ファイルの接尾辞の値
+	// 0以外の場合は桁数を自動で拡張しない
+	Start uint64
+	// 生成した接尾辞の後ろに付加する文字列
+	Additional string
+	// 接頭辞と接尾辞の間に挿入する文字列
+	Separator string
+}
+
+func (o SuffixOptions) alphabet() string {
+	if o.Alphabet != "" {
+		return o.Alphabet
+	}
+	if o.Hex {
+		return HexSuffixAlphabet
+	}
+	if o.Numeric {
+		return NumericSuffixAlphabet
+	}
+	return AlphabeticSuffixAlphabet
+}
+
+// PrefixOrDefault は接頭辞が空文字列の場合に DefaultPrefix を返す
+// 出力ファイル名やそれに続けるファイル名の接頭辞は、すべてこの関数で求める
+func PrefixOrDefault(prefix string) string {
+	if prefix == "" {
+		return DefaultPrefix
+	}
+	return prefix
+}
+
+// GenFileName は index 番目の出力ファイル名を生成する
+// suffix.Length が0の場合は既定の2文字から必要に応じて桁数を増やし、
+// 指定された場合はその桁数以上で出力する
+// prefix が空文字列の場合は DefaultPrefix を用いる
+//
+// 桁数の増やし方は GNU split と同じで、ファイル数の決まらない分割方法では
+// 接尾辞の先頭の文字が最後の文字 (英字は z、数字は 9) になった時点で、その文字を残して
+// 残りの桁を1つ増やした最初の値から続ける
+// 残した文字は接尾辞の一部であり、接頭辞と Separator は桁数によらずそのまま出力する
+//
+//	xaa, xab, ..., xyz, xzaaa, xzaab, ..., xzyzz, xzzaaaa, ...
+//	x00, x01, ..., x89, x9000, x9001, ..., x9899, x990000, ...
+//
+// ファイル数の決まる分割方法では、すべての名前が同じ桁数になるようにファイル数から桁数を求める
+func GenFileName(prefix string, index uint64, fileCount uint64, splitType SplitType, suffix SuffixOptions) (string, error) {
+	prefix = PrefixOrDefault(prefix)
+	if strings.ContainsAny(suffix.Additional, "/"+string(os.PathSeparator)) {
+		return "", fmt.Errorf("%w: %s", InvalidAdditionalSuffix, suffix.Additional)
+	}
+	encoded, err := genSuffix(index, fileCount, splitType, suffix)
+	if err != nil {
+		return "", err
+	}
+	return prefix + suffix.Separator + encoded + suffix.Additional, nil
+}
+
+// index 番目の出力ファイルの接尾辞を、接頭辞と Separator、Additional を除いて生成する
+func genSuffix(index uint64, fileCount uint64, splitType SplitType, suffix SuffixOptions) (string, error) {
+	if splitType.hasFixedFileCount() && index+1 > fileCount {
+		return "", InvalidIndex
+	}
+
+	alphabet := suffix.alphabet()
+	base := uint64(len(alphabet))
+	width := DefaultSuffixLength
+	if suffix.Length > 0 {
+		width = suffix.Length
+	}
+	index += suffix.Start
+
+	if strings.ContainsAny(suffix.Separator, "/"+string(os.PathSeparator)) {
+		return "", fmt.Errorf("%w: %s", InvalidSuffixSeparator, suffix.Separator)
+	}
+	if suffix.Alphabet != "" {
+		if err := ValidateSuffixAlphabet(suffix.Alphabet); err != nil {
+			return "", err
+		}
+	}
+
+	// 桁数を増やす際に接尾辞の先頭に残す文字
+	widened := ""
+	// 桁数や開始値が指定されている場合は固定長とする
+	if !splitType.hasFixedFileCount() && suffix.Length == 0 && suffix.Start == 0 {
+		// 先頭の文字が最後の文字になった時点で、その文字を残したまま桁数を1つ増やす
+		// 各桁数で表現できる数は uint64 の範囲を超えるまで増え続けるため、必ず表現できる
+		last := alphabet[len(alphabet)-1:]
+		for {
+			groupSize, overflow := mulPow(base-1, base, width-1)
+			if overflow || index < groupSize {
+				break
+			}
+			index -= groupSize
+			widened += last
+			width++
+		}
+	} else if splitType.hasFixedFileCount() {
+		// ファイル数から必要な桁数を求める
+		last, carry := bits.Add64(fileCount-1, suffix.Start, 0)
+		if carry != 0 {
+			return "", OutputFileSuffixesExhausted
+		}
+		needed := 1
+		for n := last / base; n > 0; n /= base {
+			needed++
+		}
+		if needed > width {
+			if suffix.Length > 0 {
+				return "", OutputFileSuffixesExhausted
+			}
+			width = needed
+		}
+	}
+
+	encoded, err := encodeSuffix(index, width, alphabet)
+	if err != nil {
+		return "", err
+	}
+	return widened + encoded, nil
+}
+
+// ValidateOutputPattern は Splitter.OutputPattern に指定できる書式かを確認する
+// 使える変換は %d (出力ファイルの番号) と %s (既定の接尾辞)、%% のみで、
+// 出力ファイルごとに異なる名前となるように %d を1つ以上含める必要がある
+// %d には %03d のようにフラグや幅を指定できる
+func ValidateOutputPattern(pattern string) error {
+	_, err := parseOutputPattern(pattern)
+	return err
+}
+
+// pattern に含まれる変換の文字を順に返す
+func parseOutputPattern(pattern string) ([]byte, error) {
+	verbs := []byte{}
+	hasIndex := false
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i] != '%' {
+			continue
+		}
+		// フラグ、幅、精度を読み飛ばす
+		i++
+		for i < len(pattern) && strings.IndexByte("+-# 0123456789.", pattern[i]) >= 0 {
+			i++
+		}
+		if i >= len(pattern) {
+			return nil, fmt.Errorf("%w: %s", InvalidOutputPattern, pattern)
+		}
+		switch pattern[i] {
+		case '%':
+			if pattern[i-1] != '%' {
+				return nil, fmt.Errorf("%w: %s", InvalidOutputPattern, pattern)
+			}
+		case 'd':
+			hasIndex = true
+			verbs = append(verbs, 'd')
+		case 's':
+			verbs = append(verbs, 's')
+		default:
+			return nil, fmt.Errorf("%w: %s", InvalidOutputPattern, pattern)
+		}
+	}
+	if !hasIndex {
+		return nil, fmt.Errorf("%w: %s: no %%d for the file number", InvalidOutputPattern, pattern)
+	}
+	return verbs, nil
+}
+
+// OutputPattern に従って index 番目の出力ファイル名を求める
+// %d には SuffixOptions.Start を加えた番号、%s には接頭辞などを除いた既定の接尾辞を渡す
+func formatOutputPattern(pattern string, index uint64, fileCount uint64, splitType SplitType, suffix SuffixOptions) (string, error) {
+	verbs, err := parseOutputPattern(pattern)
+	if err != nil {
+		return "", err
+	}
+	encoded := ""
+	if bytes.IndexByte(verbs, 's') >= 0 {
+		encoded, err = genSuffix(index, fileCount, splitType, suffix)
+		if err != nil {
+			return "", err
+		}
+	} else if splitType.hasFixedFileCount() && index+1 > fileCount {
+		return "", InvalidIndex
+	}
+	number, carry := bits.Add64(index, suffix.Start, 0)
+	if carry != 0 {
+		return "", OutputFileSuffixesExhausted
+	}
+
+	args := make([]any, 0, len(verbs))
+	for _, verb := range verbs {
+		if verb == 'd' {
+			args = append(args, number)
+		} else {
+			args = append(args, encoded)
+		}
+	}
+	// 書式として扱うのは利用者が指定した pattern だけで、接尾辞は引数として渡す
+	return fmt.Sprintf(pattern, args...), nil
+}
+
+// ValidateSuffixAlphabet は SuffixOptions.Alphabet に指定できる文字の並びかを確認する
+func ValidateSuffixAlphabet(alphabet string) error {
+	if len(alphabet) < 2 {
+		return fmt.Errorf("%w: %s", InvalidSuffixAlphabet, alphabet)
+	}
+	seen := map[byte]bool{}
+	for i := 0; i < len(alphabet); i++ {
+		c := alphabet[i]
+		// 接尾辞は1バイトを1桁として扱うため ASCII の表示可能な文字に限る
+		if c <= ' ' || c > '~' || c == '/' || c == os.PathSeparator || seen[c] {
+			return fmt.Errorf("%w: %s", InvalidSuffixAlphabet, alphabet)
+		}
+		seen[c] = true
+	}
+	return nil
+}
+
+// index を alphabet を用いて width 桁で表現する
+func encodeSuffix(index uint64, width int, alphabet string) (string, error) {
+	base := uint64(len(alphabet))
+	encoded := make([]byte, width)
+	for i := width - 1; i >= 0; i-- {
+		encoded[i] = alphabet[index%base]
+		index /= base
+	}
+	if index > 0 {
+		return "", OutputFileSuffixesExhausted
+	}
+	return string(encoded), nil
+}
+
+// ParseSuffix は alphabet で表現された接尾辞を、GenFileName の index として用いる値に変換する
+// encodeSuffix の逆の変換で、"ba" は英字の接尾辞で26となる
+func ParseSuffix(suffix string, alphabet string) (uint64, error) {
+	if suffix == "" {
+		return 0, fmt.Errorf("%w: %s", InvalidSuffixStart, suffix)
+	}
+	base := uint64(len(alphabet))
+	value := uint64(0)
+	for _, c := range suffix {
+		digit := strings.IndexRune(alphabet, c)
+		if digit < 0 {
+			return 0, fmt.Errorf("%w: %s", InvalidSuffixStart, suffix)
+		}
+		hi, lo := bits.Mul64(value, base)
+		if hi != 0 {
+			return 0, fmt.Errorf("%w: %s", OverflowHasOccured, suffix)
+		}
+		sum, carry := bits.Add64(lo, uint64(digit), 0)
+		if carry != 0 {
+			return 0, fmt.Errorf("%w: %s", OverflowHasOccured, suffix)
+		}
+		value = sum
+	}
+	return value, nil
+}
+
+// a * base^exp を求める
+// uint64 の範囲を超える場合は overflow を true とする
+func mulPow(a uint64, base uint64, exp int) (result uint64, overflow bool) {
+	result = a
+	for i := 0; i < exp; i++ {
+		hi, lo := bits.Mul64(result, base)
+		if hi != 0 {
+			return 0, true
+		}
+		result = lo
+	}
+	return result, false
+}
@@ -0,0 +1,528 @@
+package split
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestGenFileName(t *testing.T) {
+	tests := []struct {
+		valid        bool
+		prefix       string
+		index        uint64
+		fileCount    uint64
+		splitType    SplitType
+		suffixLength int
+		expected     string
+	}{
+		{true, "prefix", 0, 1, ByFiles, 0, "prefixaa"},
+		{true, "prefix", 1, 2, ByFiles, 0, "prefixab"},
+		{true, "", 0, 26, ByFiles, 0, "xaa"},
+		{true, "", 0, 676, ByFiles, 0, "xaa"},
+		{true, "", 25, 27, ByFiles, 0, "xaz"},
+		{true, "", 26, 27, ByFiles, 0, "xba"},
+		{true, "", 675, 676, ByFiles, 0, "xzz"},
+		{true, "", 0, 677, ByFiles, 0, "xaaa"},
+		{true, "", 676, 677, ByFiles, 0, "xbaa"},
+		{true, "", 17575, 17576, ByFiles, 0, "xzzz"},
+		{true, "", 0, 17577, ByFiles, 0, "xaaaa"},
+		{true, "", 17576, 17577, ByFiles, 0, "xbaaa"},
+		{false, "", 0, 0, ByFiles, 0, ""},
+		{false, "", 1, 0, ByFiles, 0, ""},
+		{true, "prefix", 0, 0, ByBytes, 0, "prefixaa"},
+		{true, "", 1, 0, ByBytes, 0, "xab"},
+		{true, "", 649, 0, ByBytes, 0, "xyz"},
+		{true, "", 650, 0, ByBytes, 0, "xzaaa"},
+		{true, "", 651, 0, ByBytes, 0, "xzaab"},
+		{true, "", 676, 0, ByBytes, 0, "xzaba"},
+		{true, "", 17549, 0, ByBytes, 0, "xzyzz"},
+		{true, "", 17550, 0, ByBytes, 0, "xzzaaaa"},
+		{true, "", 456949, 0, ByBytes, 0, "xzzyzzz"},
+		{true, "", 2481152873203736549, 0, ByBytes, 0, "xzzzzzzzzzzzyzzzzzzzzzzzz"},
+		{true, "", 2481152873203736550, 0, ByBytes, 0, "xzzzzzzzzzzzzaaaaaaaaaaaaaa"},
+		{true, "", math.MaxUint64, 0, ByBytes, 0, "xzzzzzzzzzzzzglhxczmxsyumrp"},
+		{false, "", math.MaxUint64, 0, ByBytes, 13, ""},
+		{true, "", 0, 0, ByBytes, 4, "xaaaa"},
+		{true, "", 650, 0, ByBytes, 4, "xaaza"},
+		{true, "", 25, 0, ByLines, 1, "xz"},
+		{false, "", 26, 0, ByLines, 1, ""},
+		{false, "", 676, 0, ByBytes, 2, ""},
+		{true, "", 0, 3, ByFiles, 3, "xaaa"},
+		{true, "", 0, 677, ByFiles, 3, "xaaa"},
+		{false, "", 0, 677, ByFiles, 2, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("splitType: %v index: %d fileCount: %d suffixLength: %d", test.splitType, test.index, test.fileCount, test.suffixLength), func(t *testing.T) {
+			fileName, err := GenFileName(test.prefix, test.index, test.fileCount, test.splitType, SuffixOptions{Length: test.suffixLength})
+			if err != nil && test.valid {
+				t.Errorf("Unexpected error: %s", err)
+			}
+			if err == nil && !test.valid {
+				t.Errorf("Unexpected result : error is nil, result is %v", fileName)
+			}
+			if fileName != test.expected && test.valid {
+				t.Errorf("Unexpected result: got %s, expected %s", fileName, test.expected)
+			}
+		})
+	}
+}
+
+func TestGenFileNameNumeric(t *testing.T) {
+	tests := []struct {
+		valid        bool
+		index        uint64
+		fileCount    uint64
+		splitType    SplitType
+		suffixLength int
+		expected     string
+	}{
+		{true, 0, 0, ByLines, 0, "x00"},
+		{true, 89, 0, ByLines, 0, "x89"},
+		{true, 90, 0, ByLines, 0, "x9000"},
+		{true, 989, 0, ByLines, 0, "x9899"},
+		{true, 990, 0, ByLines, 0, "x990000"},
+		{true, 7, 0, ByBytes, 3, "x007"},
+		{false, 1000, 0, ByBytes, 3, ""},
+		{true, 9, 10, ByFiles, 0, "x09"},
+		{true, 99, 101, ByFiles, 0, "x099"},
+	}
+	startTests := []struct {
+		index        uint64
+		fileCount    uint64
+		splitType    SplitType
+		suffixLength int
+		start        uint64
+		expected     string
+	}{
+		{0, 0, ByLines, 0, 5, "x05"},
+		{94, 0, ByLines, 0, 5, "x99"},
+		{0, 0, ByLines, 3, 100, "x100"},
+		{2, 3, ByFiles, 0, 98, "x100"},
+		{0, 3, ByFiles, 0, 1, "x01"},
+		{2, 3, ByFiles, 0, 1, "x03"},
+	}
+	for _, test := range startTests {
+		fileName, err := GenFileName("", test.index, test.fileCount, test.splitType, SuffixOptions{Length: test.suffixLength, Numeric: true, Start: test.start})
+		if err != nil {
+			t.Errorf("Unexpected error: %s", err)
+		}
+		if fileName != test.expected {
+			t.Errorf("Unexpected result: got %s, expected %s", fileName, test.expected)
+		}
+	}
+	if _, err := GenFileName("", 95, 0, ByLines, SuffixOptions{Numeric: true, Start: 5}); err == nil {
+		t.Errorf("Expected error when the start value exhausts the suffix width")
+	}
+	// 開始値をずらしても、番号はファイル数の範囲で確認する
+	if _, err := GenFileName("", 3, 3, ByFiles, SuffixOptions{Numeric: true, Start: 1}); !errors.Is(err, InvalidIndex) {
+		t.Errorf("Unexpected error: got %v, expected %s", err, InvalidIndex)
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("splitType: %v index: %d fileCount: %d suffixLength: %d", test.splitType, test.index, test.fileCount, test.suffixLength), func(t *testing.T) {
+			fileName, err := GenFileName("", test.index, test.fileCount, test.splitType, SuffixOptions{Length: test.suffixLength, Numeric: true})
+			if err != nil && test.valid {
+				t.Errorf("Unexpected error: %s", err)
+			}
+			if err == nil && !test.valid {
+				t.Errorf("Unexpected result : error is nil, result is %v", fileName)
+			}
+			if fileName != test.expected && test.valid {
+				t.Errorf("Unexpected result: got %s, expected %s", fileName, test.expected)
+			}
+		})
+	}
+}
+
+func TestGenFileNameAdditionalSuffix(t *testing.T) {
+	tests := []struct {
+		valid     bool
+		index     uint64
+		fileCount uint64
+		splitType SplitType
+		suffix    SuffixOptions
+		expected  string
+	}{
+		{true, 0, 0, ByLines, SuffixOptions{Additional: ".csv"}, "xaa.csv"},
+		{true, 650, 0, ByBytes, SuffixOptions{Additional: ".csv"}, "xzaaa.csv"},
+		{true, 1, 3, ByFiles, SuffixOptions{Additional: ".csv"}, "xab.csv"},
+		{true, 1, 0, ByLines, SuffixOptions{Numeric: true, Additional: ".csv"}, "x01.csv"},
+		{true, 0, 0, ByLines, SuffixOptions{Numeric: true, Start: 5, Length: 3, Additional: ".log"}, "x005.log"},
+		{false, 0, 0, ByLines, SuffixOptions{Additional: "dir/.csv"}, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expected, func(t *testing.T) {
+			fileName, err := GenFileName("", test.index, test.fileCount, test.splitType, test.suffix)
+			if err != nil && test.valid {
+				t.Errorf("Unexpected error: %s", err)
+			}
+			if err == nil && !test.valid {
+				t.Errorf("Unexpected result : error is nil, result is %v", fileName)
+			}
+			if fileName != test.expected && test.valid {
+				t.Errorf("Unexpected result: got %s, expected %s", fileName, test.expected)
+			}
+		})
+	}
+}
+
+func TestGenFileNameSuffixSeparator(t *testing.T) {
+	tests := []struct {
+		valid     bool
+		prefix    string
+		index     uint64
+		fileCount uint64
+		splitType SplitType
+		suffix    SuffixOptions
+		expected  string
+	}{
+		{true, "log", 0, 0, ByLines, SuffixOptions{Separator: "-"}, "log-aa"},
+		{true, "log", 0, 0, ByLines, SuffixOptions{}, "logaa"},
+		{true, "log", 650, 0, ByBytes, SuffixOptions{Separator: "-"}, "log-zaaa"},
+		{true, "log", 1, 3, ByFiles, SuffixOptions{Separator: "_"}, "log_ab"},
+		{true, "log", 1, 0, ByLines, SuffixOptions{Numeric: true, Separator: "-"}, "log-01"},
+		{true, "log", 0, 0, ByLines, SuffixOptions{Numeric: true, Start: 5, Separator: "-", Additional: ".csv"}, "log-05.csv"},
+		{true, "", 0, 0, ByLines, SuffixOptions{Separator: "."}, "x.aa"},
+		{false, "log", 0, 0, ByLines, SuffixOptions{Separator: "/"}, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expected, func(t *testing.T) {
+			fileName, err := GenFileName(test.prefix, test.index, test.fileCount, test.splitType, test.suffix)
+			if err != nil && test.valid {
+				t.Errorf("Unexpected error: %s", err)
+			}
+			if err == nil && !test.valid {
+				t.Errorf("Unexpected result : error is nil, result is %v", fileName)
+			}
+			if fileName != test.expected && test.valid {
+				t.Errorf("Unexpected result: got %s, expected %s", fileName, test.expected)
+			}
+		})
+	}
+}
+
+func TestGenFileNameHex(t *testing.T) {
+	tests := []struct {
+		valid     bool
+		index     uint64
+		fileCount uint64
+		splitType SplitType
+		suffix    SuffixOptions
+		expected  string
+	}{
+		{true, 0, 0, ByLines, SuffixOptions{Hex: true}, "x00"},
+		{true, 9, 0, ByLines, SuffixOptions{Hex: true}, "x09"},
+		{true, 10, 0, ByLines, SuffixOptions{Hex: true}, "x0a"},
+		{true, 15, 0, ByLines, SuffixOptions{Hex: true}, "x0f"},
+		{true, 16, 0, ByLines, SuffixOptions{Hex: true}, "x10"},
+		{true, 239, 0, ByLines, SuffixOptions{Hex: true}, "xef"},
+		{true, 240, 0, ByLines, SuffixOptions{Hex: true}, "xf000"},
+		{true, 0, 0, ByLines, SuffixOptions{Hex: true, Length: 4}, "x0000"},
+		{true, 255, 0, ByLines, SuffixOptions{Hex: true, Length: 4}, "x00ff"},
+		{true, 0, 0, ByLines, SuffixOptions{Hex: true, Start: 10}, "x0a"},
+		{true, 6, 0, ByLines, SuffixOptions{Hex: true, Start: 10}, "x10"},
+		{true, 0, 0, ByLines, SuffixOptions{Hex: true, Start: 0xff, Length: 4}, "x00ff"},
+		{true, 16, 17, ByFiles, SuffixOptions{Hex: true}, "x10"},
+		{true, 0, 257, ByFiles, SuffixOptions{Hex: true}, "x000"},
+		{true, 1, 0, ByLines, SuffixOptions{Hex: true, Numeric: true}, "x01"},
+		{false, 246, 0, ByLines, SuffixOptions{Hex: true, Start: 10}, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expected, func(t *testing.T) {
+			fileName, err := GenFileName("", test.index, test.fileCount, test.splitType, test.suffix)
+			if err != nil && test.valid {
+				t.Errorf("Unexpected error: %s", err)
+			}
+			if err == nil && !test.valid {
+				t.Errorf("Unexpected result : error is nil, result is %v", fileName)
+			}
+			if fileName != test.expected && test.valid {
+				t.Errorf("Unexpected result: got %s, expected %s", fileName, test.expected)
+			}
+		})
+	}
+}
+
+func TestGenFileNameAlphabet(t *testing.T) {
+	tests := []struct {
+		valid     bool
+		index     uint64
+		fileCount uint64
+		splitType SplitType
+		suffix    SuffixOptions
+		expected  string
+	}{
+		// 数字の並びは Numeric と同じ接尾辞になる
+		{true, 0, 0, ByLines, SuffixOptions{Alphabet: "0123456789"}, "x00"},
+		{true, 89, 0, ByLines, SuffixOptions{Alphabet: "0123456789"}, "x89"},
+		{true, 90, 0, ByLines, SuffixOptions{Alphabet: "0123456789"}, "x9000"},
+		{true, 0, 0, ByLines, SuffixOptions{Alphabet: "AB"}, "xAA"},
+		{true, 1, 0, ByLines, SuffixOptions{Alphabet: "AB"}, "xAB"},
+		{true, 2, 0, ByLines, SuffixOptions{Alphabet: "AB"}, "xBAAA"},
+		{true, 61, 0, ByLines, SuffixOptions{Alphabet: "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"}, "xa9"},
+		{true, 62, 0, ByLines, SuffixOptions{Alphabet: "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"}, "xba"},
+		{true, 3, 4, ByFiles, SuffixOptions{Alphabet: "AB"}, "xBB"},
+		{true, 4, 5, ByFiles, SuffixOptions{Alphabet: "AB"}, "xBAA"},
+		{true, 1, 0, ByLines, SuffixOptions{Alphabet: "xy", Numeric: true, Hex: true}, "xxy"},
+		{false, 0, 0, ByLines, SuffixOptions{Alphabet: "a"}, ""},
+		{false, 0, 0, ByLines, SuffixOptions{Alphabet: "aba"}, ""},
+		{false, 0, 0, ByLines, SuffixOptions{Alphabet: "ab/"}, ""},
+		{false, 0, 0, ByLines, SuffixOptions{Alphabet: "a b"}, ""},
+		{false, 0, 0, ByLines, SuffixOptions{Alphabet: "aé"}, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.suffix.Alphabet+"/"+test.expected, func(t *testing.T) {
+			fileName, err := GenFileName("", test.index, test.fileCount, test.splitType, test.suffix)
+			if err != nil && test.valid {
+				t.Errorf("Unexpected error: %s", err)
+			}
+			if err == nil && !test.valid {
+				t.Errorf("Unexpected result : error is nil, result is %v", fileName)
+			}
+			if !test.valid && !errors.Is(err, InvalidSuffixAlphabet) {
+				t.Errorf("Unexpected error: got %v, expected %s", err, InvalidSuffixAlphabet)
+			}
+			if fileName != test.expected && test.valid {
+				t.Errorf("Unexpected result: got %s, expected %s", fileName, test.expected)
+			}
+		})
+	}
+}
+
+func TestGenFileNamePrefixVerbatim(t *testing.T) {
+	// 桁数が増える前後の index
+	indexes := map[bool][]uint64{
+		false: {0, 649, 650, 17549, 17550},
+		true:  {0, 89, 90, 989, 990},
+	}
+	// Windows のドライブ文字やバックスラッシュを含むパスも接頭辞として扱い、その後に接尾辞を付ける
+	for _, prefix := range []string{"x", "z", "part-z", "out/zz", "日本語", `C:\out\x`, "C:/out/x", `\\server\share\x`} {
+		for _, numeric := range []bool{false, true} {
+			for _, separator := range []string{"", "_"} {
+				suffix := SuffixOptions{Numeric: numeric, Separator: separator, Additional: ".txt"}
+				t.Run(fmt.Sprintf("%s numeric: %v separator: %q", prefix, numeric, separator), func(t *testing.T) {
+					for _, index := range indexes[numeric] {
+						fileName, err := GenFileName(prefix, index, 0, ByLines, suffix)
+						if err != nil {
+							t.Fatalf("Unexpected error: %s", err)
+						}
+						// 接頭辞と区切りはそのまま出力し、桁数の増加は接尾辞だけに現れる
+						if !strings.HasPrefix(fileName, prefix+separator) {
+							t.Errorf("Prefix was changed for index %d: %s", index, fileName)
+						}
+						encoded := strings.TrimSuffix(strings.TrimPrefix(fileName, prefix+separator), ".txt")
+						if strings.Trim(encoded, suffix.alphabet()) != "" {
+							t.Errorf("Unexpected suffix for index %d: %s", index, encoded)
+						}
+						defaultName, err := GenFileName("", index, 0, ByLines, SuffixOptions{Numeric: numeric})
+						if err != nil {
+							t.Fatalf("Unexpected error: %s", err)
+						}
+						if "x"+encoded != defaultName {
+							t.Errorf("Suffix depends on the prefix for index %d: got %s, expected %s", index, encoded, strings.TrimPrefix(defaultName, "x"))
+						}
+					}
+				})
+			}
+		}
+	}
+}
+
+// GNU split の出力ファイル名の並びとの比較
+func TestGenFileNameGNUSequence(t *testing.T) {
+	tests := []struct {
+		start    uint64
+		suffix   SuffixOptions
+		expected []string
+	}{
+		{0, SuffixOptions{}, []string{"xaa", "xab", "xac"}},
+		{24, SuffixOptions{}, []string{"xay", "xaz", "xba", "xbb"}},
+		{647, SuffixOptions{}, []string{"xyx", "xyy", "xyz", "xzaaa", "xzaab"}},
+		{674, SuffixOptions{}, []string{"xzaay", "xzaaz", "xzaba"}},
+		{17548, SuffixOptions{}, []string{"xzyzy", "xzyzz", "xzzaaaa", "xzzaaab"}},
+		{456948, SuffixOptions{}, []string{"xzzyzzy", "xzzyzzz", "xzzzaaaaa"}},
+		{0, SuffixOptions{Numeric: true}, []string{"x00", "x01", "x02"}},
+		{88, SuffixOptions{Numeric: true}, []string{"x88", "x89", "x9000", "x9001"}},
+		{988, SuffixOptions{Numeric: true}, []string{"x9898", "x9899", "x990000"}},
+		{9988, SuffixOptions{Numeric: true}, []string{"x998998", "x998999", "x99900000"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expected[0], func(t *testing.T) {
+			previous := ""
+			for i, expected := range test.expected {
+				fileName, err := GenFileName("", test.start+uint64(i), 0, ByLines, test.suffix)
+				if err != nil {
+					t.Fatalf("Unexpected error: %s", err)
+				}
+				if fileName != expected {
+					t.Errorf("Unexpected result for index %d: got %s, expected %s", test.start+uint64(i), fileName, expected)
+				}
+				// 辞書順に並べても作成した順になる
+				if fileName <= previous {
+					t.Errorf("Unexpected order: %s is not after %s", fileName, previous)
+				}
+				previous = fileName
+			}
+		})
+	}
+}
+
+func TestParseSuffix(t *testing.T) {
+	tests := []struct {
+		valid    bool
+		suffix   string
+		alphabet string
+		expected uint64
+	}{
+		{true, "aa", AlphabeticSuffixAlphabet, 0},
+		{true, "ab", AlphabeticSuffixAlphabet, 1},
+		{true, "ba", AlphabeticSuffixAlphabet, 26},
+		{true, "zz", AlphabeticSuffixAlphabet, 675},
+		{true, "b", AlphabeticSuffixAlphabet, 1},
+		{true, "baa", AlphabeticSuffixAlphabet, 676},
+		{true, "0ff", HexSuffixAlphabet, 255},
+		{true, "1844674407370955161", NumericSuffixAlphabet, 1844674407370955161},
+		{false, "18446744073709551616", NumericSuffixAlphabet, 0},
+		{false, "", AlphabeticSuffixAlphabet, 0},
+		{false, "Ba", AlphabeticSuffixAlphabet, 0},
+		{false, "a1", AlphabeticSuffixAlphabet, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.suffix, func(t *testing.T) {
+			value, err := ParseSuffix(test.suffix, test.alphabet)
+			if err != nil && test.valid {
+				t.Errorf("Unexpected error: %s", err)
+			}
+			if err == nil && !test.valid {
+				t.Errorf("Unexpected result : error is nil, result is %v", value)
+			}
+			if value != test.expected && test.valid {
+				t.Errorf("Unexpected result: got %d, expected %d", value, test.expected)
+			}
+			// 生成した接尾辞と一致する
+			if test.valid && len(test.suffix) == DefaultSuffixLength && test.alphabet == AlphabeticSuffixAlphabet {
+				fileName, err := GenFileName("x", 0, 0, ByLines, SuffixOptions{Start: value})
+				if err != nil {
+					t.Fatalf("Unexpected error: %s", err)
+				}
+				if fileName != "x"+test.suffix {
+					t.Errorf("Unexpected file name: got %s, expected x%s", fileName, test.suffix)
+				}
+			}
+		})
+	}
+}
+
+func TestGenFileNameLargeFileCount(t *testing.T) {
+	tests := []struct {
+		fileCount uint64
+		suffix    SuffixOptions
+		width     int
+	}{
+		{676, SuffixOptions{}, 2},
+		{677, SuffixOptions{}, 3},
+		{1000000, SuffixOptions{}, 5},
+		{10000000, SuffixOptions{}, 5},
+		{11881377, SuffixOptions{}, 6},
+		{1000000, SuffixOptions{Numeric: true}, 6},
+		{10000000, SuffixOptions{Numeric: true}, 7},
+		{10000001, SuffixOptions{Numeric: true}, 8},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%d numeric: %v", test.fileCount, test.suffix.Numeric), func(t *testing.T) {
+			// すべての名前を確認すると時間がかかるため、大きなファイル数では間引いて確認する
+			step := uint64(1)
+			if testing.Short() || test.fileCount > 1000000 {
+				step = 997
+			}
+			previous := ""
+			check := func(index uint64) {
+				fileName, err := GenFileName("x", index, test.fileCount, ByFiles, test.suffix)
+				if err != nil {
+					t.Fatalf("Unexpected error for index %d: %s", index, err)
+				}
+				// すべての名前が同じ桁数になり、辞書順に並べると作成した順になるため重複しない
+				if len(fileName) != 1+test.width {
+					t.Fatalf("Unexpected width for index %d: %s", index, fileName)
+				}
+				if fileName <= previous {
+					t.Fatalf("Unexpected order for index %d: %s is not after %s", index, fileName, previous)
+				}
+				previous = fileName
+			}
+			for index := uint64(0); index < test.fileCount; index += step {
+				check(index)
+			}
+			if (test.fileCount-1)%step != 0 {
+				check(test.fileCount - 1)
+			}
+
+			if _, err := GenFileName("x", test.fileCount, test.fileCount, ByFiles, test.suffix); !errors.Is(err, InvalidIndex) {
+				t.Errorf("Unexpected error: got %v, expected %s", err, InvalidIndex)
+			}
+		})
+	}
+
+	// 開始値を加えた最後の番号が uint64 に収まらない
+	_, err := GenFileName("x", 0, 10, ByFiles, SuffixOptions{Numeric: true, Start: math.MaxUint64 - 3})
+	if !errors.Is(err, OutputFileSuffixesExhausted) {
+		t.Errorf("Unexpected error: got %v, expected %s", err, OutputFileSuffixesExhausted)
+	}
+}
+
+func TestFormatOutputPattern(t *testing.T) {
+	tests := []struct {
+		valid     bool
+		pattern   string
+		index     uint64
+		fileCount uint64
+		splitType SplitType
+		suffix    SuffixOptions
+		expected  string
+	}{
+		{true, "chunk-%03d.dat", 0, 0, ByLines, SuffixOptions{}, "chunk-000.dat"},
+		{true, "chunk-%03d.dat", 41, 0, ByLines, SuffixOptions{}, "chunk-041.dat"},
+		{true, "chunk-%03d.dat", 1234, 0, ByLines, SuffixOptions{}, "chunk-1234.dat"},
+		{true, "chunk-%d.dat", 0, 0, ByLines, SuffixOptions{Start: 1}, "chunk-1.dat"},
+		{true, "part%s-%d", 27, 0, ByBytes, SuffixOptions{}, "partbb-27"},
+		{true, "part%s-%d", 2, 3, ByFiles, SuffixOptions{Numeric: true}, "part02-2"},
+		{true, "out/%d%%", 5, 0, ByLines, SuffixOptions{}, "out/5%"},
+		{true, "%-4d|", 5, 0, ByLines, SuffixOptions{}, "5   |"},
+		{false, "chunk-%d", 3, 3, ByFiles, SuffixOptions{}, ""},
+		{false, "chunk.dat", 0, 0, ByLines, SuffixOptions{}, ""},
+		{false, "chunk-%s.dat", 0, 0, ByLines, SuffixOptions{}, ""},
+		{false, "chunk-%x.dat", 0, 0, ByLines, SuffixOptions{}, ""},
+		{false, "chunk-%v.dat", 0, 0, ByLines, SuffixOptions{}, ""},
+		{false, "chunk-%d%", 0, 0, ByLines, SuffixOptions{}, ""},
+		{false, "chunk-%03%d", 0, 0, ByLines, SuffixOptions{}, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.pattern, func(t *testing.T) {
+			fileName, err := formatOutputPattern(test.pattern, test.index, test.fileCount, test.splitType, test.suffix)
+			if err != nil && test.valid {
+				t.Errorf("Unexpected error: %s", err)
+			}
+			if err == nil && !test.valid {
+				t.Errorf("Unexpected result : error is nil, result is %v", fileName)
+			}
+			if fileName != test.expected && test.valid {
+				t.Errorf("Unexpected result: got %s, expected %s", fileName, test.expected)
+			}
+			if validateErr := ValidateOutputPattern(test.pattern); validateErr == nil && !errors.Is(err, InvalidIndex) && !test.valid {
+				t.Errorf("Unexpected result: %s is accepted", test.pattern)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package split
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// DefaultFollowInterval は NewFollowReader で入力の末尾に達してから読み直すまでの既定の間隔
+const DefaultFollowInterval = time.Second
+
+// followReader は tail -f のように、入力の末尾に達しても追記を待って読み込みを続ける
+type followReader struct {
+	ctx      context.Context
+	reader   io.Reader
+	interval time.Duration
+}
+
+// NewFollowReader は reader の末尾に達するたびに interval だけ待って読み直す io.Reader を返す
+// ctx がキャンセルされると、その時点までに追記された内容を返した後に io.EOF を返す
+// 書き込み中の行は区切り文字が追記されるまで待つため、行の途中でファイルが切り替わることはない
+func NewFollowReader(ctx context.Context, reader io.Reader, interval time.Duration) io.Reader {
+	if interval <= 0 {
+		interval = DefaultFollowInterval
+	}
+	return &followReader{ctx: ctx, reader: reader, interval: interval}
+}
+
+func (r *followReader) Read(p []byte) (int, error) {
+	for {
+		n, err := r.reader.Read(p)
+		if n > 0 || (err != nil && err != io.EOF) {
+			if err == io.EOF {
+				err = nil
+			}
+			return n, err
+		}
+		if r.ctx.Err() != nil {
+			return 0, io.EOF
+		}
+
+		// キャンセルされた場合も、最後に追記された分を読むためにもう一度読み直す
+		timer := time.NewTimer(r.interval)
+		select {
+		case <-r.ctx.Done():
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package split
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestFollowReader(t *testing.T) {
+	outputDir := t.TempDir() + "/"
+	inputFilePath := outputDir + "active.log"
+	if err := os.WriteFile(inputFilePath, []byte("aaaa\n"), 0644); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	input, err := os.Open(inputFilePath)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	defer input.Close()
+	appendInput := func(content string) {
+		file, err := os.OpenFile(inputFilePath, os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		defer file.Close()
+		if _, err := file.WriteString(content); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	splitter := NewSplitter(ByLineBytes, 10, NewFollowReader(ctx, input, 10*time.Millisecond), outputDir+"out-")
+	done := make(chan error, 1)
+	go func() {
+		done <- splitter.Split()
+	}()
+
+	// 追記した行は、入力の末尾に達した後も読み込まれて次のファイルに出力される
+	appendInput("bbbb\n")
+	appendInput("cccc\n")
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := os.Stat(outputDir + "out-ab"); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Appended lines were not split")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	appendInput("dddd\n")
+	cancel()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Split did not finish after cancel")
+	}
+
+	for name, expected := range map[string]string{"out-aa": "aaaa\nbbbb\n", "out-ab": "cccc\ndddd\n"} {
+		data, err := os.ReadFile(outputDir + name)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if string(data) != expected {
+			t.Errorf("Unexpected content of %s: got %q, expected %q", name, data, expected)
+		}
+	}
+	if _, err := os.Stat(outputDir + "out-ac"); !os.IsNotExist(err) {
+		t.Errorf("Unexpected output file out-ac: %v", err)
+	}
+}
@@ -0,0 +1,64 @@
+package split
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+)
+
+// チェックサムに使用できるハッシュ関数
+const (
+	ChecksumMD5    string = "md5"
+	ChecksumSHA256 string = "sha256"
+)
+
+func newHash(algorithm string) (hash.Hash, error) {
+	switch algorithm {
+	case ChecksumMD5:
+		return md5.New(), nil
+	case ChecksumSHA256:
+		return sha256.New(), nil
+	}
+	return nil, fmt.Errorf("%w: %s", InvalidChecksumAlgorithm, algorithm)
+}
+
+func getFileHash(filePath string) (string, error) {
+	return getFileHashWith(filePath, ChecksumMD5)
+}
+
+// algorithm のハッシュ関数でファイルのハッシュ値を求める
+func getFileHashWith(filePath string, algorithm string) (string, error) {
+	hash, err := newHash(algorithm)
+	if err != nil {
+		return "", err
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%x", hash.Sum(nil)), nil
+}
+
+func compareFileHashes(filePath1, filePath2 string) (bool, error) {
+	hash1, err := getFileHash(filePath1)
+	if err != nil {
+		return false, err
+	}
+
+	hash2, err := getFileHash(filePath2)
+	if err != nil {
+		return false, err
+	}
+
+	return hash1 == hash2, nil
+}
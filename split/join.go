@@ -0,0 +1,82 @@
+package split
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// Join は prefix の出力ファイルを接尾辞の順番に連結して writer に書き込み、連結したファイルの数を返す
+// ファイルは SplitFileNames と同じ規則で探し、最初に存在しない接尾辞で終える
+func Join(writer io.Writer, prefix string, suffix SuffixOptions) (int, error) {
+	names, err := joinFileNames(prefix, suffix)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := joinFiles(writer, names); err != nil {
+		return 0, err
+	}
+	return len(names), nil
+}
+
+// JoinFile は Join と同じく prefix の出力ファイルを連結して output に書き込み、連結したファイルの数を返す
+// 連結するファイルを列挙してから output を作成するため、作成した output を連結するファイルとして読むことはない
+// output が連結するファイルのいずれかである場合は、切り詰めて内容を失わないように JoinOutputIsInput を返す
+// noClobber の場合は既存の output を上書きせずに OutputFileExists を返す
+// 連結に失敗した場合は output を削除する
+func JoinFile(output string, prefix string, suffix SuffixOptions, noClobber bool) (int, error) {
+	names, err := joinFileNames(prefix, suffix)
+	if err != nil {
+		return 0, err
+	}
+	if outputInfo, err := os.Stat(output); err == nil {
+		for _, name := range names {
+			if info, err := os.Stat(name); err == nil && os.SameFile(outputInfo, info) {
+				return 0, fmt.Errorf("%w: %s", JoinOutputIsInput, output)
+			}
+		}
+	}
+
+	file, err := CreateFile(output, 0, noClobber)
+	if err != nil {
+		return 0, err
+	}
+	err = joinFiles(file, names)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(output)
+		return 0, err
+	}
+	return len(names), nil
+}
+
+// prefix の出力ファイルを列挙し、1つもない場合は SplitFilesNotFound を返す
+func joinFileNames(prefix string, suffix SuffixOptions) ([]string, error) {
+	names, err := SplitFileNames(prefix, suffix)
+	if err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("%w: %s", SplitFilesNotFound, PrefixOrDefault(prefix))
+	}
+	return names, nil
+}
+
+// ファイルを順番に writer に書き込む
+func joinFiles(writer io.Writer, filePaths []string) error {
+	for _, filePath := range filePaths {
+		file, err := os.Open(filePath)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(writer, file)
+		file.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
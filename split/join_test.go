@@ -0,0 +1,124 @@
+package split
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestJoin(t *testing.T) {
+	tests := []struct {
+		name      string
+		splitType SplitType
+		count     uint64
+		suffix    SuffixOptions
+		files     int
+	}{
+		{"alphabetic suffixes", ByLines, 1, SuffixOptions{}, 5},
+		{"numeric suffixes", ByBytes, 4, SuffixOptions{Numeric: true}, 3},
+		{"hex suffixes with start", ByLines, 2, SuffixOptions{Hex: true, Start: 14}, 3},
+		{"additional suffix", ByLineFiles, 2, SuffixOptions{Additional: ".txt", Separator: "_"}, 2},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			outputDir := t.TempDir() + "/"
+			original := outputDir + "original.txt"
+			if err := os.WriteFile(original, []byte("1\n2\n3\n4\n5\n"), 0644); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			input, err := os.Open(original)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			defer input.Close()
+
+			splitter := NewSplitter(test.splitType, test.count, input, outputDir+"x")
+			splitter.Suffix = test.suffix
+			if err := splitter.Split(); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+
+			joined := outputDir + "joined.txt"
+			file, err := os.Create(joined)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			files, err := Join(file, outputDir+"x", test.suffix)
+			file.Close()
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if files != test.files {
+				t.Errorf("Unexpected number of files: got %d, expected %d", files, test.files)
+			}
+
+			ok, err := compareFileHashes(original, joined)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if !ok {
+				t.Errorf("Joined file does not match the original")
+			}
+		})
+	}
+
+	outputDir := t.TempDir() + "/"
+	if _, err := Join(&bytes.Buffer{}, outputDir+"x", SuffixOptions{}); !errors.Is(err, SplitFilesNotFound) {
+		t.Errorf("Unexpected error: got %v, expected %s", err, SplitFilesNotFound)
+	}
+}
+
+func TestJoinFile(t *testing.T) {
+	outputDir := t.TempDir() + "/"
+	splitter := NewSplitter(ByLines, 1, strings.NewReader("1\n2\n3\n"), outputDir+"x")
+	if err := splitter.Split(); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if err := os.Symlink(outputDir+"xab", outputDir+"link"); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	tests := []struct {
+		name      string
+		output    string
+		noClobber bool
+		expected  error
+	}{
+		{"new output", "joined.txt", false, nil},
+		{"overwrite", "joined.txt", false, nil},
+		{"no clobber", "joined.txt", true, OutputFileExists},
+		{"split file", "xab", false, JoinOutputIsInput},
+		{"link to split file", "link", false, JoinOutputIsInput},
+		// 連結を始めた後に作成した連結先は、連結するファイルとして読まない
+		{"next suffix", "xad", false, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			files, err := JoinFile(outputDir+test.output, outputDir+"x", SuffixOptions{}, test.noClobber)
+			if test.expected != nil {
+				if !errors.Is(err, test.expected) {
+					t.Errorf("Unexpected error: got %v, expected %s", err, test.expected)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if files != 3 {
+				t.Errorf("Unexpected number of files: got %d, expected %d", files, 3)
+			}
+			if data, err := os.ReadFile(outputDir + test.output); err != nil || string(data) != "1\n2\n3\n" {
+				t.Errorf("Unexpected content of %s: got %q (error: %v)", test.output, data, err)
+			}
+		})
+	}
+
+	// 連結するファイルの内容は変わらない
+	for name, expected := range map[string]string{"xaa": "1\n", "xab": "2\n", "xac": "3\n"} {
+		if data, err := os.ReadFile(outputDir + name); err != nil || string(data) != expected {
+			t.Errorf("Unexpected content of %s: got %q (error: %v)", name, data, err)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package split
+
+import (
+	"context"
+	"io"
+	"sync"
+)
+
+// byteChunk は入力の start バイト目から size バイトを index 番目の出力ファイルに書き込むチャンク
+type byteChunk struct {
+	index uint64
+	start uint64
+	size  uint64
+}
+
+// parallelReader はチャンクごとに位置を指定して読み込め、読み終えた位置に移動できる入力
+type parallelReader interface {
+	io.ReaderAt
+	io.Seeker
+}
+
+// Parallel でチャンクを並行して書き込めるか
+// EachChunk の関数は次のチャンクを始める前に返る必要があるため、並行して書き込まない
+func (s *Splitter) parallelWritable(reader io.Reader) (parallelReader, bool) {
+	if s.Parallel <= 1 || s.startChunk != nil {
+		return nil, false
+	}
+	input, ok := reader.(parallelReader)
+	return input, ok
+}
+
+// input の現在位置からのチャンクを最大 Parallel 個ずつ並行して出力ファイルに書き込み、
+// すべて書き込んだ後は逐次の場合と同じく読み終えた位置に移動する
+// 出力ファイルはチャンクの順に1つずつ作成し、ファイル名や Created の順序を逐次の場合と同じにする
+// 最初に発生したエラーを返し、それ以降のチャンクは作成しない
+// 書き込み中のチャンクはすべて終わるのを待ってから返るため、返った後に出力ファイルを削除できる
+func (s *Splitter) copyChunksParallel(ctx context.Context, input parallelReader, chunks []byteChunk) error {
+	base, err := input.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if err := s.writeChunksParallel(ctx, input, base, chunks); err != nil {
+		return err
+	}
+	end := base
+	for _, chunk := range chunks {
+		end += int64(chunk.size)
+	}
+	_, err = input.Seek(end, io.SeekStart)
+	return err
+}
+
+func (s *Splitter) writeChunksParallel(ctx context.Context, readerAt io.ReaderAt, base int64, chunks []byteChunk) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// 書き込み中の出力ファイルと Progress の進捗を共有するため、数えるたびにロックする
+	s.progressMu = &sync.Mutex{}
+	defer func() { s.progressMu = nil }()
+
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+	fail := func(err error) {
+		once.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+
+	// 空きのある間だけ次のチャンクを始めるセマフォ
+	slots := make(chan struct{}, s.Parallel)
+	for _, chunk := range chunks {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		outputFile, err := s.createOutputFile(chunk.index)
+		if err != nil {
+			<-slots
+			fail(err)
+			break
+		}
+
+		wg.Add(1)
+		go func(chunk byteChunk, outputFile io.WriteCloser) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			section := io.NewSectionReader(readerAt, base+int64(chunk.start), int64(chunk.size))
+			if _, err := io.CopyN(outputFile, section, int64(chunk.size)); err != nil {
+				outputFile.Close()
+				if err == io.EOF {
+					err = io.ErrUnexpectedEOF
+				}
+				fail(err)
+				return
+			}
+			if err := outputFile.Close(); err != nil {
+				fail(err)
+			}
+		}(chunk, outputFile)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	// 書き込みの途中でエラーがなくても、呼び出し元の ctx がキャンセルされていればそのエラーとする
+	return ctx.Err()
+}
@@ -0,0 +1,132 @@
+package split
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestSplitParallelMatchesSequential(t *testing.T) {
+	inputFilePath := t.TempDir() + "/input"
+	if err := os.WriteFile(inputFilePath, bytes.Repeat([]byte("0123456789abcdef\n"), 1000), 0644); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	tests := []struct {
+		splitType SplitType
+		count     uint64
+		elide     bool
+	}{
+		{ByBytes, 1000, false},
+		{ByBytes, 1700, false},
+		{ByBytes, 20000, false},
+		{ByFiles, 1, false},
+		{ByFiles, 7, false},
+		{ByFiles, 20000, true},
+	}
+	for _, test := range tests {
+		for _, parallel := range []int{2, 4, 16} {
+			t.Run(fmt.Sprintf("%s %d parallel: %d", test.splitType, test.count, parallel), func(t *testing.T) {
+				created := map[int][]string{}
+				dirs := map[int]string{}
+				for _, workers := range []int{1, parallel} {
+					dirs[workers] = t.TempDir() + "/"
+					inputFile, err := os.Open(inputFilePath)
+					if err != nil {
+						t.Fatalf("Unexpected error: %s", err)
+					}
+					defer inputFile.Close()
+
+					splitter := NewSplitter(test.splitType, test.count, inputFile, dirs[workers]+"x")
+					splitter.Parallel = workers
+					splitter.ElideEmptyFiles = test.elide
+					if err := splitter.Split(); err != nil {
+						t.Fatalf("Unexpected error: %s", err)
+					}
+					created[workers] = splitter.Created()
+					for i, name := range created[workers] {
+						created[workers][i] = name[len(dirs[workers]):]
+					}
+
+					// 逐次の場合と同じく入力を読み終えた位置に移動している
+					if offset, err := inputFile.Seek(0, io.SeekCurrent); err != nil {
+						t.Fatalf("Unexpected error: %s", err)
+					} else if offset != 17000 {
+						t.Errorf("Unexpected input offset: got %d, expected %d", offset, 17000)
+					}
+				}
+
+				if !reflect.DeepEqual(created[parallel], created[1]) {
+					t.Fatalf("Unexpected created files: got %v, expected %v", created[parallel], created[1])
+				}
+				for _, name := range created[1] {
+					ok, err := compareFileHashes(dirs[parallel]+name, dirs[1]+name)
+					if err != nil {
+						t.Fatalf("Unexpected error: %s", err)
+					}
+					if !ok {
+						t.Errorf("%s differs between the parallel and sequential paths", name)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestSplitParallelCreationFailure(t *testing.T) {
+	input := bytes.Repeat([]byte("0123456789"), 10)
+	output := &memoryOutput{}
+	splitter := NewSplitter(ByBytes, 10, bytes.NewReader(input), "x")
+	splitter.Parallel = 3
+	splitter.WriterFactory = func(name string) (io.WriteCloser, error) {
+		if name == "xae" {
+			return nil, errors.New("no space left on device")
+		}
+		return output.create(name)
+	}
+	splitter.OutputRemover = func(name string) error {
+		delete(output.buffers, name)
+		return nil
+	}
+
+	err := splitter.Split()
+	if !errors.Is(err, OutputFileCreationFailed) {
+		t.Fatalf("Unexpected error: got %v, expected %s", err, OutputFileCreationFailed)
+	}
+	// 作成に失敗したファイル以降は作成せず、作成したファイルも削除する
+	if len(output.buffers) != 0 {
+		t.Errorf("Unexpected remaining files: %v", output.buffers)
+	}
+	if created := splitter.Created(); len(created) != 0 {
+		t.Errorf("Unexpected created files: %v", created)
+	}
+}
+
+func BenchmarkSplitParallel(b *testing.B) {
+	inputFilePath := b.TempDir() + "/input"
+	if err := os.WriteFile(inputFilePath, bytes.Repeat([]byte("0123456789abcdef\n"), 1<<19), 0644); err != nil {
+		b.Fatalf("Unexpected error: %s", err)
+	}
+
+	for _, parallel := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("parallel: %d", parallel), func(b *testing.B) {
+			outputDir := b.TempDir() + "/"
+			for i := 0; i < b.N; i++ {
+				inputFile, err := os.Open(inputFilePath)
+				if err != nil {
+					b.Fatalf("Unexpected error: %s", err)
+				}
+				splitter := NewSplitter(ByFiles, 8, inputFile, outputDir+"x")
+				splitter.Parallel = parallel
+				if err := splitter.Split(); err != nil {
+					b.Fatalf("Unexpected error: %s", err)
+				}
+				inputFile.Close()
+			}
+		})
+	}
+}
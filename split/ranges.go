@@ -0,0 +1,155 @@
+package split
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// LineRange は ByRanges で First 行目から Last 行目まで (1から数える) を Label の名前のファイルに出力する範囲
+type LineRange struct {
+	First uint64
+	Last  uint64
+	Label string
+}
+
+// ParseLineRanges は 1-100:intro,101-500:body のように、カンマで区切った 開始行-終了行:ラベル の一覧を解析する
+func ParseLineRanges(spec string) ([]LineRange, error) {
+	ranges := []LineRange{}
+	for _, element := range strings.Split(spec, ",") {
+		span, label, found := strings.Cut(element, ":")
+		if !found {
+			return nil, fmt.Errorf("%w: %s", InvalidRanges, element)
+		}
+		firstStr, lastStr, found := strings.Cut(span, "-")
+		if !found {
+			return nil, fmt.Errorf("%w: %s", InvalidRanges, element)
+		}
+		first, err := strconv.ParseUint(firstStr, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", InvalidRanges, element)
+		}
+		last, err := strconv.ParseUint(lastStr, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", InvalidRanges, element)
+		}
+		ranges = append(ranges, LineRange{First: first, Last: last, Label: label})
+	}
+	if err := validateLineRanges(ranges); err != nil {
+		return nil, err
+	}
+	return ranges, nil
+}
+
+// 範囲が昇順に並び、互いに重ならずに連続し、ラベルがファイル名として使えることを確認する
+// 範囲の間の行を黙って捨てないように、前の範囲の次の行から始まらない範囲は RangesNotContiguous とする
+func validateLineRanges(ranges []LineRange) error {
+	if len(ranges) == 0 {
+		return InvalidRanges
+	}
+	labels := map[string]bool{}
+	for i, r := range ranges {
+		if r.First == 0 || r.First > r.Last {
+			return fmt.Errorf("%w: %d-%d", InvalidRanges, r.First, r.Last)
+		}
+		if i > 0 && r.First <= ranges[i-1].Last {
+			return fmt.Errorf("%w: %d-%d overlaps %d-%d", InvalidRanges, r.First, r.Last, ranges[i-1].First, ranges[i-1].Last)
+		}
+		if i > 0 && r.First != ranges[i-1].Last+1 {
+			return fmt.Errorf("%w: %d-%d does not follow %d-%d", RangesNotContiguous, r.First, r.Last, ranges[i-1].First, ranges[i-1].Last)
+		}
+		if r.Label == "" || strings.ContainsAny(r.Label, "/"+string(os.PathSeparator)) || labels[r.Label] {
+			return fmt.Errorf("%w: invalid label %q", InvalidRanges, r.Label)
+		}
+		labels[r.Label] = true
+	}
+	return nil
+}
+
+// NewRangesSplitter は ranges のそれぞれの行の範囲を、outputPrefix とラベルをつなげた名前のファイルに分割する Splitter を作成する
+// 範囲は連続している必要があり、最初の範囲より前の行と最後の範囲より後の行は出力しない
+func NewRangesSplitter(ranges []LineRange, reader io.Reader, outputPrefix string) *Splitter {
+	splitter := NewSplitter(ByRanges, uint64(len(ranges)), reader, outputPrefix)
+	splitter.ranges = ranges
+	return splitter
+}
+
+// ByRanges の index 番目の出力ファイル名
+// 接尾辞の代わりにラベルを用い、接頭辞が空文字列の場合は他の分割方法と同じく DefaultPrefix とする
+func (s *Splitter) rangeFileName(index uint64) (string, error) {
+	if index >= uint64(len(s.ranges)) {
+		return "", InvalidIndex
+	}
+	if strings.ContainsAny(s.Suffix.Additional, "/"+string(os.PathSeparator)) {
+		return "", fmt.Errorf("%w: %s", InvalidAdditionalSuffix, s.Suffix.Additional)
+	}
+	return PrefixOrDefault(s.outputPrefix) + s.ranges[index].Label + s.Suffix.Additional, nil
+}
+
+// 範囲は SplitContext で検証済みとする
+func (s *Splitter) splitByRanges(ctx context.Context) error {
+	buffer := bufio.NewReader(s.reader)
+	lineNumber := uint64(0)
+	eof := false
+	for i, r := range s.ranges {
+		index := uint64(i)
+		// 範囲の前の行を読み飛ばす
+		for !eof && lineNumber+1 < r.First {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			line, _, err := s.readLine(buffer)
+			if len(line) > 0 {
+				lineNumber++
+			}
+			if err == io.EOF {
+				eof = true
+			} else if err != nil {
+				return err
+			}
+		}
+		// 入力が範囲の前で終わった場合は空のファイルとなる
+		// 前の範囲が入力の最後の行で終わった場合も、先読みして空のファイルを作成しない
+		if !eof && s.ElideEmptyFiles {
+			if _, err := buffer.Peek(1); err == io.EOF {
+				eof = true
+			}
+		}
+		if eof && s.ElideEmptyFiles {
+			continue
+		}
+
+		outputFile, err := s.createOutputFile(index)
+		if err != nil {
+			return err
+		}
+		for !eof && lineNumber < r.Last {
+			if err := ctx.Err(); err != nil {
+				s.discardOutputFile(outputFile, index)
+				return err
+			}
+			line, _, err := s.readLine(buffer)
+			if len(line) > 0 {
+				lineNumber++
+				if _, err := outputFile.Write(line); err != nil {
+					outputFile.Close()
+					return err
+				}
+			}
+			if err == io.EOF {
+				eof = true
+			} else if err != nil {
+				outputFile.Close()
+				return err
+			}
+		}
+		if err := outputFile.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package split
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestParseLineRanges(t *testing.T) {
+	tests := []struct {
+		valid    bool
+		input    string
+		expected []LineRange
+	}{
+		{true, "1-100:intro,101-500:body", []LineRange{{1, 100, "intro"}, {101, 500, "body"}}},
+		{true, "3-3:one,4-20:later", []LineRange{{3, 3, "one"}, {4, 20, "later"}}},
+		{false, "1-100:intro,50-200:body", nil},
+		{false, "101-500:body,1-100:intro", nil},
+		{false, "1-100:intro,101-500:intro", nil},
+		{false, "0-10:zero", nil},
+		{false, "10-1:reverse", nil},
+		{false, "1-10:", nil},
+		{false, "1-10:a/b", nil},
+		{false, "1-10", nil},
+		{false, "1:intro", nil},
+		{false, "a-10:intro", nil},
+		{false, "", nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			ranges, err := ParseLineRanges(test.input)
+			if err != nil && test.valid {
+				t.Errorf("Unexpected error: %s", err)
+			}
+			if err == nil && !test.valid {
+				t.Errorf("Unexpected result : error is nil, result is %v", ranges)
+			}
+			if !test.valid && err != nil && !errors.Is(err, InvalidRanges) {
+				t.Errorf("Unexpected error: got %v, expected %s", err, InvalidRanges)
+			}
+			if test.valid && fmt.Sprint(ranges) != fmt.Sprint(test.expected) {
+				t.Errorf("Unexpected result: got %v, expected %v", ranges, test.expected)
+			}
+		})
+	}
+
+	// 範囲の間の行は捨てずにエラーとする
+	for _, input := range []string{"3-3:one,10-20:later", "1-100:intro,102-500:body"} {
+		if _, err := ParseLineRanges(input); !errors.Is(err, RangesNotContiguous) {
+			t.Errorf("Unexpected error for %s: got %v, expected %s", input, err, RangesNotContiguous)
+		}
+	}
+}
+
+func TestSplitByRanges(t *testing.T) {
+	tests := []struct {
+		ranges   []LineRange
+		elide    bool
+		input    string
+		expected map[string]string
+	}{
+		{[]LineRange{{1, 2, "intro"}, {3, 5, "body"}}, false, "1\n2\n3\n4\n5\n6\n", map[string]string{"xintro": "1\n2\n", "xbody": "3\n4\n5\n"}},
+		{[]LineRange{{2, 2, "second"}, {3, 10, "rest"}}, false, "1\n2\n3\n4\n5", map[string]string{"xsecond": "2\n", "xrest": "3\n4\n5"}},
+		{[]LineRange{{1, 2, "first"}, {3, 6, "missing"}}, false, "1\n2\n", map[string]string{"xfirst": "1\n2\n", "xmissing": ""}},
+		{[]LineRange{{1, 2, "first"}, {3, 6, "missing"}}, true, "1\n2\n", map[string]string{"xfirst": "1\n2\n"}},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("ranges: %v input: %q", test.ranges, test.input), func(t *testing.T) {
+			output := &memoryOutput{}
+			splitter := NewRangesSplitter(test.ranges, strings.NewReader(test.input), "x")
+			splitter.ElideEmptyFiles = test.elide
+			splitter.WriterFactory = output.create
+			if err := splitter.Split(); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+
+			if len(output.names) != len(test.expected) {
+				t.Fatalf("Unexpected number of outputs: got %v, expected %d", output.names, len(test.expected))
+			}
+			for name, expected := range test.expected {
+				buffer, ok := output.buffers[name]
+				if !ok {
+					t.Fatalf("Output %s was not created: got %v", name, output.names)
+				}
+				if got := buffer.String(); got != expected {
+					t.Errorf("Unexpected content of %s: got %q, expected %q", name, got, expected)
+				}
+			}
+		})
+	}
+
+	overlapping := []LineRange{{1, 5, "a"}, {5, 6, "b"}}
+	if err := NewRangesSplitter(overlapping, strings.NewReader("1\n"), "x").Split(); !errors.Is(err, InvalidRanges) {
+		t.Errorf("Unexpected error for %v: got %v, expected %s", overlapping, err, InvalidRanges)
+	}
+	// 範囲のない Splitter は出力先を確認する前に InvalidRanges とする
+	if err := NewSplitter(ByRanges, 1, strings.NewReader("1\n"), t.TempDir()+"/x").Split(); !errors.Is(err, InvalidRanges) {
+		t.Errorf("Unexpected error for no ranges: got %v, expected %s", err, InvalidRanges)
+	}
+	// 接頭辞が空文字列の場合は他の分割方法と同じく DefaultPrefix を付ける
+	output := &memoryOutput{}
+	splitter := NewRangesSplitter([]LineRange{{1, 1, "intro"}}, strings.NewReader("1\n"), "")
+	splitter.WriterFactory = output.create
+	if err := splitter.Split(); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if expected := DefaultPrefix + "intro"; strings.Join(output.names, " ") != expected {
+		t.Errorf("Unexpected names: got %v, expected %s", output.names, expected)
+	}
+
+	gap := []LineRange{{1, 2, "a"}, {4, 6, "b"}}
+	if err := NewRangesSplitter(gap, strings.NewReader("1\n"), "x").Split(); !errors.Is(err, RangesNotContiguous) {
+		t.Errorf("Unexpected error for %v: got %v, expected %s", gap, err, RangesNotContiguous)
+	}
+}
@@ -0,0 +1,114 @@
+package split
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"io"
+)
+
+// ParseRecordMarker は 1e1f のように16進数で表したレコードの始まりのバイト列を解析する
+func ParseRecordMarker(input string) ([]byte, error) {
+	marker, err := hex.DecodeString(input)
+	if err != nil || len(marker) == 0 {
+		return nil, fmt.Errorf("%w: %s", InvalidRecordMarker, input)
+	}
+	return marker, nil
+}
+
+// NewRecordSplitter は marker で始まるレコードを count 個ずつ出力ファイルに分割する Splitter を作成する
+// 改行の代わりにレコードの先頭に目印を置く形式のための、行による分割の一般化
+// 最初の marker より前の内容も1つのレコードとして数える
+func NewRecordSplitter(marker []byte, count uint64, reader io.Reader, outputPrefix string) *Splitter {
+	splitter := NewSplitter(ByRecords, count, reader, outputPrefix)
+	splitter.marker = marker
+	return splitter
+}
+
+func (s *Splitter) splitByRecord(ctx context.Context) error {
+	if len(s.marker) == 0 {
+		return InvalidRecordMarker
+	}
+
+	fileIndex := uint64(0)
+	recordCount := uint64(0)
+	// 出力ファイルは次のレコードが実際に読み込まれた時点で作成する
+	var outputFile io.WriteCloser
+	defer func() {
+		if outputFile != nil {
+			outputFile.Close()
+		}
+	}()
+
+	buffer := bufio.NewReader(s.reader)
+	// 前のレコードの終わりを見つけるために読み込んだ、次のレコードの先頭の marker
+	next := []byte{}
+	for {
+		if err := ctx.Err(); err != nil {
+			if outputFile != nil {
+				s.discardOutputFile(outputFile, fileIndex)
+				outputFile = nil
+			}
+			return err
+		}
+
+		record, readErr := s.readRecord(buffer, next)
+		next = []byte{}
+		if readErr == nil {
+			// marker はレコードの一部として次のファイルの先頭に書き込むため、ファイルの境界で分かれない
+			next = append(next, s.marker...)
+		}
+		if len(record) > 0 {
+			if outputFile == nil {
+				var err error
+				outputFile, err = s.createOutputFile(fileIndex)
+				if err != nil {
+					return err
+				}
+			}
+			if _, err := outputFile.Write(record); err != nil {
+				return err
+			}
+			recordCount++
+
+			if recordCount%s.count == 0 {
+				if err := outputFile.Close(); err != nil {
+					outputFile = nil
+					return err
+				}
+				outputFile = nil
+				fileIndex++
+				recordCount = 0
+			}
+		}
+
+		if readErr != nil {
+			if readErr == io.EOF {
+				break
+			} else {
+				return readErr
+			}
+		}
+	}
+	return nil
+}
+
+// record に続けて次の marker の直前までを読み込み、1つのレコードとして返す
+// 次の marker を読み込んだ場合は、その marker を除いたレコードと nil を返す
+// record が marker で始まる場合、その marker と重なる位置はレコードの終わりとしない
+func (s *Splitter) readRecord(buffer *bufio.Reader, record []byte) ([]byte, error) {
+	last := s.marker[len(s.marker)-1]
+	for {
+		chunk, err := buffer.ReadBytes(last)
+		record = append(record, chunk...)
+		if err != nil {
+			return record, err
+		}
+		end := len(record) - len(s.marker)
+		if end > 0 && bytes.HasSuffix(record, s.marker) && (!bytes.HasPrefix(record, s.marker) || end >= len(s.marker)) {
+			return record[:end], nil
+		}
+	}
+}
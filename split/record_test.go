@@ -0,0 +1,102 @@
+package split
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestParseRecordMarker(t *testing.T) {
+	tests := []struct {
+		valid    bool
+		input    string
+		expected string
+	}{
+		{true, "1e1f", "\x1e\x1f"},
+		{true, "FF", "\xff"},
+		{false, "", ""},
+		{false, "1", ""},
+		{false, "zz", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			marker, err := ParseRecordMarker(test.input)
+			if err != nil && test.valid {
+				t.Errorf("Unexpected error: %s", err)
+			}
+			if err == nil && !test.valid {
+				t.Errorf("Unexpected result : error is nil, result is %v", marker)
+			}
+			if !test.valid && err != nil && !errors.Is(err, InvalidRecordMarker) {
+				t.Errorf("Unexpected error: got %v, expected %s", err, InvalidRecordMarker)
+			}
+			if test.valid && string(marker) != test.expected {
+				t.Errorf("Unexpected result: got %q, expected %q", marker, test.expected)
+			}
+		})
+	}
+}
+
+func TestSplitByRecord(t *testing.T) {
+	tests := []struct {
+		marker   string
+		count    uint64
+		input    string
+		expected []string
+	}{
+		{"\x1e\x1f", 3, "\x1e\x1fa\x1e\x1fbb\x1e\x1fc\x1e\x1fd\x1e\x1fe", []string{"\x1e\x1fa\x1e\x1fbb\x1e\x1fc", "\x1e\x1fd\x1e\x1fe"}},
+		{"\x1e\x1f", 3, "\x1e\x1fa\x1e\x1fb\x1e\x1fc", []string{"\x1e\x1fa\x1e\x1fb\x1e\x1fc"}},
+		{"\x1e\x1f", 1, "head\x1e\x1fa\x1e\x1f", []string{"head", "\x1e\x1fa", "\x1e\x1f"}},
+		{"\x1e\x1f", 2, "\x1e\x1e\x1fa\x1f\x1e\x1fb", []string{"\x1e\x1e\x1fa\x1f", "\x1e\x1fb"}},
+		{"aa", 1, "aaaaab", []string{"aa", "aaab"}},
+		{"\x1e\x1f", 3, "no marker", []string{"no marker"}},
+		{"\x1e\x1f", 3, "", []string{}},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("marker: %q count: %d input: %q", test.marker, test.count, test.input), func(t *testing.T) {
+			output := &memoryOutput{}
+			splitter := NewRecordSplitter([]byte(test.marker), test.count, strings.NewReader(test.input), "x")
+			splitter.WriterFactory = output.create
+			if err := splitter.Split(); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+
+			if len(output.names) != len(test.expected) {
+				t.Fatalf("Unexpected number of outputs: got %v, expected %d", output.names, len(test.expected))
+			}
+			for i, expected := range test.expected {
+				got := output.buffers[output.names[i]].String()
+				if got != expected {
+					t.Errorf("Unexpected content of %s: got %q, expected %q", output.names[i], got, expected)
+				}
+				// 2つ目以降のファイルは marker で始まり、marker がファイルの境界で分かれない
+				if i > 0 && !strings.HasPrefix(got, test.marker) {
+					t.Errorf("Output %s does not start with the marker: %q", output.names[i], got)
+				}
+			}
+		})
+	}
+
+	if err := NewRecordSplitter(nil, 1, strings.NewReader("1"), "x").Split(); !errors.Is(err, InvalidRecordMarker) {
+		t.Errorf("Unexpected error: got %v, expected %s", err, InvalidRecordMarker)
+	}
+}
+
+func TestReadRecordAcrossBuffer(t *testing.T) {
+	// bufio の既定のバッファより長いレコードでも marker を見落とさない
+	first := "\x1e\x1f" + strings.Repeat("a", 5000)
+	second := "\x1e\x1fb"
+	splitter := NewRecordSplitter([]byte("\x1e\x1f"), 1, nil, "x")
+	buffer := bufio.NewReaderSize(strings.NewReader(first+second), 16)
+	record, err := splitter.readRecord(buffer, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if string(record) != first {
+		t.Errorf("Unexpected record: got %d bytes, expected %d bytes", len(record), len(first))
+	}
+}
@@ -0,0 +1,53 @@
+package split
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// FileNamer は接頭辞と出力ファイルの番号、その出力ファイルを作成した時刻から出力ファイル名を求める
+type FileNamer func(prefix string, index uint64, opened time.Time) (string, error)
+
+// TimestampNamer は接頭辞に続けて、作成した時刻を layout の形式で表した文字列と出力ファイルの番号をつなげた名前とする FileNamer を返す
+// 同じ時刻に複数の出力ファイルを作成しても重ならないように、番号は常に付ける
+//
+//	x20261014T120000-0, x20261014T120005-1, ...
+//
+// 接頭辞が空文字列の場合は DefaultPrefix とし、SuffixOptions.Additional はその後に付ける
+func TimestampNamer(layout string) FileNamer {
+	return func(prefix string, index uint64, opened time.Time) (string, error) {
+		prefix = PrefixOrDefault(prefix)
+		timestamp := opened.Format(layout)
+		if strings.ContainsAny(timestamp, "/"+string(os.PathSeparator)) {
+			return "", fmt.Errorf("%w: %s", InvalidTimestampLayout, layout)
+		}
+		return fmt.Sprintf("%s%s-%d", prefix, timestamp, index), nil
+	}
+}
+
+// FileNamer による index 番目の出力ファイル名
+// 作成前の名前の確認では現在時刻を用いる
+func (s *Splitter) namerFileName(index uint64) (string, error) {
+	opened, ok := s.fileTimes[index]
+	if !ok {
+		opened = s.now()
+	}
+	name, err := s.FileNamer(s.outputPrefix, index, opened)
+	if err != nil {
+		return "", err
+	}
+	if strings.ContainsAny(s.Suffix.Additional, "/"+string(os.PathSeparator)) {
+		return "", fmt.Errorf("%w: %s", InvalidAdditionalSuffix, s.Suffix.Additional)
+	}
+	return name + s.Suffix.Additional, nil
+}
+
+// 現在時刻を返す
+func (s *Splitter) now() time.Time {
+	if s.Now != nil {
+		return s.Now()
+	}
+	return time.Now()
+}
@@ -0,0 +1,114 @@
+package split
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeClock は clockReader が1行を返すたびに step だけ進む時計
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+type clockReader struct {
+	lines []string
+	clock *fakeClock
+	step  time.Duration
+}
+
+func (r *clockReader) Read(p []byte) (int, error) {
+	if len(r.lines) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, r.lines[0])
+	r.lines = r.lines[1:]
+	r.clock.now = r.clock.now.Add(r.step)
+	return n, nil
+}
+
+func TestSplitByLineRotateInterval(t *testing.T) {
+	tests := []struct {
+		count         uint64
+		interval      time.Duration
+		expectedNames []string
+		expected      []string
+	}{
+		// 2秒ごとに切り替える
+		{1000, 2 * time.Second, []string{"log-120001-0.txt", "log-120003-1.txt", "log-120005-2.txt"}, []string{"1\n2\n", "3\n4\n", "5\n"}},
+		// 行数と時間のどちらか先に満たした方で切り替える
+		{1, 2 * time.Second, []string{"log-120001-0.txt", "log-120002-1.txt", "log-120003-2.txt", "log-120004-3.txt", "log-120005-4.txt"}, []string{"1\n", "2\n", "3\n", "4\n", "5\n"}},
+		{3, 10 * time.Second, []string{"log-120001-0.txt", "log-120004-1.txt"}, []string{"1\n2\n3\n", "4\n5\n"}},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("count: %d interval: %s", test.count, test.interval), func(t *testing.T) {
+			clock := &fakeClock{now: time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)}
+			reader := &clockReader{lines: []string{"1\n", "2\n", "3\n", "4\n", "5\n"}, clock: clock, step: time.Second}
+			output := &memoryOutput{}
+			splitter := NewSplitter(ByLines, test.count, reader, "log-")
+			splitter.RotateInterval = test.interval
+			splitter.FileNamer = TimestampNamer("150405")
+			splitter.Now = clock.Now
+			splitter.Suffix.Additional = ".txt"
+			splitter.WriterFactory = output.create
+			if err := splitter.Split(); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+
+			if strings.Join(output.names, ",") != strings.Join(test.expectedNames, ",") {
+				t.Fatalf("Unexpected outputs: got %v, expected %v", output.names, test.expectedNames)
+			}
+			for i, expected := range test.expected {
+				if got := output.buffers[output.names[i]].String(); got != expected {
+					t.Errorf("Unexpected content of %s: got %q, expected %q", output.names[i], got, expected)
+				}
+			}
+			// 作成した後に求める名前も作成した時刻による
+			created := splitter.Created()
+			if strings.Join(created, ",") != strings.Join(test.expectedNames, ",") {
+				t.Errorf("Unexpected created files: got %v, expected %v", created, test.expectedNames)
+			}
+		})
+	}
+}
+
+func TestTimestampNamer(t *testing.T) {
+	opened := time.Date(2026, 10, 14, 12, 34, 56, 0, time.UTC)
+	tests := []struct {
+		valid    bool
+		prefix   string
+		layout   string
+		index    uint64
+		expected string
+	}{
+		{true, "log-", "20060102T150405", 0, "log-20261014T123456-0"},
+		{true, "", "150405", 12, "x123456-12"},
+		{false, "log-", "2006/01/02", 0, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.layout, func(t *testing.T) {
+			name, err := TimestampNamer(test.layout)(test.prefix, test.index, opened)
+			if err != nil && test.valid {
+				t.Errorf("Unexpected error: %s", err)
+			}
+			if err == nil && !test.valid {
+				t.Errorf("Unexpected result : error is nil, result is %v", name)
+			}
+			if !test.valid && err != nil && !errors.Is(err, InvalidTimestampLayout) {
+				t.Errorf("Unexpected error: got %v, expected %s", err, InvalidTimestampLayout)
+			}
+			if test.valid && name != test.expected {
+				t.Errorf("Unexpected result: got %s, expected %s", name, test.expected)
+			}
+		})
+	}
+}
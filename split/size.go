@@ -0,0 +1,163 @@
+package split
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"regexp"
+	"strconv"
+)
+
+// 小数部として扱う最大の桁数
+// 10^19 は uint64 に収まる最大の10の累乗
+const maxFractionDigits = 19
+
+// 単位の解釈方法
+type UnitSystem int
+
+const (
+	// KB などは1000、 K や KiB などは1024の累乗とする (GNU split と同じ)
+	UnitsDefault UnitSystem = iota
+	// すべての単位を1000の累乗とする
+	UnitsSI
+	// すべての単位を1024の累乗とする
+	UnitsIEC
+)
+
+// byteSizeUnit はサイズの単位の累乗の指数と、1000の累乗かどうか
+type byteSizeUnit struct {
+	suffix   string
+	exponent int
+	decimal  bool
+}
+
+// ParseByteSizeWith が受け付ける単位
+// 単位のない場合は B と同じくバイト数とする
+// 小文字は従来から使われている k と m に限り、g、t、p や b は受け付けない
+// (1g などは誤記の可能性があり、大文字と同じ意味に決められないため)
+var byteSizeUnits = []byteSizeUnit{
+	{"B", 0, false},
+	{"K", 1, false}, {"k", 1, false}, {"KiB", 1, false}, {"kiB", 1, false}, {"KB", 1, true}, {"kB", 1, true},
+	{"M", 2, false}, {"m", 2, false}, {"MiB", 2, false}, {"miB", 2, false}, {"MB", 2, true}, {"mB", 2, true},
+	{"G", 3, false}, {"GiB", 3, false}, {"GB", 3, true},
+	{"T", 4, false}, {"TiB", 4, false}, {"TB", 4, true},
+	{"P", 5, false}, {"PiB", 5, false}, {"PB", 5, true},
+}
+
+func findByteSizeUnit(suffix string) (byteSizeUnit, bool) {
+	if suffix == "" {
+		return byteSizeUnits[0], true
+	}
+	for _, unit := range byteSizeUnits {
+		if unit.suffix == suffix {
+			return unit, true
+		}
+	}
+	return byteSizeUnit{}, false
+}
+
+// units に従った単位のバイト数
+func (u byteSizeUnit) multiplier(units UnitSystem) uint64 {
+	decimal := u.decimal
+	switch units {
+	case UnitsSI:
+		decimal = true
+	case UnitsIEC:
+		decimal = false
+	}
+	base := uint64(1024)
+	if decimal {
+		base = 1000
+	}
+	multiplier, _ := mulPow(1, base, u.exponent)
+	return multiplier
+}
+
+// Unit はサイズに付けられる単位と、その単位が表すバイト数
+type Unit struct {
+	Suffix     string
+	Multiplier uint64
+}
+
+// SupportedUnits は ParseByteSize が受け付ける単位とそのバイト数を返す
+func SupportedUnits() []Unit {
+	return SupportedUnitsWith(UnitsDefault)
+}
+
+// SupportedUnitsWith は ParseByteSizeWith が units で受け付ける単位とそのバイト数を返す
+// ヘルプの作成などで、解析する単位と説明がずれないように同じ表から求める
+func SupportedUnitsWith(units UnitSystem) []Unit {
+	supported := make([]Unit, 0, len(byteSizeUnits))
+	for _, unit := range byteSizeUnits {
+		supported = append(supported, Unit{Suffix: unit.suffix, Multiplier: unit.multiplier(units)})
+	}
+	return supported
+}
+
+// ParseByteSize は K, KB, KiB などの単位付きのサイズをバイト数に変換する
+// 1.5K のような小数は単位を掛けたうえで小数点以下を切り捨てる
+// 8進数のつもりの誤りを見逃さないように、007K のような先頭に0のつく2桁以上の整数部は受け付けない
+// 単位の小文字は k と m (kB, kiB, mB, miB を含む) に限る
+// B のみの単位はバイトを表す
+func ParseByteSize(input string) (uint64, error) {
+	return ParseByteSizeWith(input, UnitsDefault)
+}
+
+// ParseByteSizeWith は ParseByteSize と同じくサイズを変換するが、単位を units に従って解釈する
+func ParseByteSizeWith(input string, units UnitSystem) (uint64, error) {
+	// 受け付ける単位は byteSizeUnits の表だけで決める
+	re := regexp.MustCompile(`^(0|[1-9]\d*)(?:\.(\d+))?([A-Za-z]+)?$`)
+	matches := re.FindStringSubmatch(input)
+	if len(matches) != 4 {
+		return 0, fmt.Errorf("%w: %s", InvalidByteSizeFormat, input)
+	}
+	// 単位のない小数や B 単位の小数はバイト数として意味を持たない
+	if matches[2] != "" && (matches[3] == "" || matches[3] == "B") {
+		return 0, fmt.Errorf("%w: %s", InvalidByteSizeFormat, input)
+	}
+
+	size, err := strconv.ParseUint(matches[1], 10, 64)
+	if errors.Is(err, strconv.ErrRange) {
+		return 0, fmt.Errorf("%w: %s", OverflowHasOccured, input)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	unit, ok := findByteSizeUnit(matches[3])
+	if !ok {
+		// Ki などの B のない2進接頭辞や、1g などの表にない小文字の単位
+		return 0, fmt.Errorf("%w: %s", InvalidByteSizeFormat, input)
+	}
+	unitVal := unit.multiplier(units)
+	// 上位64ビットが0でなければ uint64 に収まらない
+	hi, result := bits.Mul64(size, unitVal)
+	if hi != 0 {
+		return 0, fmt.Errorf("%w: %s", OverflowHasOccured, input)
+	}
+
+	if fraction := matches[2]; fraction != "" {
+		if len(fraction) > maxFractionDigits {
+			fraction = fraction[:maxFractionDigits]
+		}
+		numerator, err := strconv.ParseUint(fraction, 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		denominator := uint64(1)
+		for range fraction {
+			denominator *= 10
+		}
+		// numerator < denominator のため、商は unitVal 未満となり Div64 は溢れない
+		hi, lo := bits.Mul64(numerator, unitVal)
+		fractionBytes, _ := bits.Div64(hi, lo, denominator)
+
+		sum, carry := bits.Add64(result, fractionBytes, 0)
+		if carry != 0 {
+			return 0, fmt.Errorf("%w: %s", OverflowHasOccured, input)
+		}
+		result = sum
+	}
+
+	return result, nil
+}
@@ -0,0 +1,212 @@
+package split
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		valid    bool
+		input    string
+		expected uint64
+	}{
+		{true, "1KB", 1000},
+		{true, "1kB", 1000},
+		{true, "20MB", 20 * 1000 * 1000},
+		{true, "20mB", 20 * 1000 * 1000},
+		{true, "300GB", 300 * 1000 * 1000 * 1000},
+		{true, "4000TB", 4000 * 1000 * 1000 * 1000 * 1000},
+		{true, "16383PB", 16383 * 1000 * 1000 * 1000 * 1000 * 1000},
+		{true, "1K", 1024},
+		{true, "1k", 1024},
+		{true, "1KiB", 1024},
+		{true, "1kiB", 1024},
+		{true, "20M", 20 * 1024 * 1024},
+		{true, "20MiB", 20 * 1024 * 1024},
+		{true, "20miB", 20 * 1024 * 1024},
+		{true, "300G", 300 * 1024 * 1024 * 1024},
+		{true, "4000T", 4000 * 1024 * 1024 * 1024 * 1024},
+		{true, "16383P", 16383 * 1024 * 1024 * 1024 * 1024 * 1024},
+		{true, "12345", 12345},
+		{true, "10B", 10},
+		{true, "1B", 1},
+		{true, "0B", 0},
+		{true, "0", 0},
+		{false, "00", 0},
+		{false, "007K", 0},
+		{false, "01", 0},
+		{false, "00.5K", 0},
+		{true, "1.5K", 1536},
+		{true, "0.5MiB", 512 * 1024},
+		{true, "1.5KB", 1500},
+		{true, "0.001K", 1},
+		{true, "0.0001K", 0},
+		{true, "2.25M", 2359296},
+		{true, "16383.9PB", 16383900 * 1000 * 1000 * 1000 * 1000},
+		{true, "0.99999999999999999999999K", 1023},
+		{false, "16384.5P", 0},
+		{true, "18446744073709551615", 18446744073709551615},
+		{false, "18446744073709551616", 0},
+		{true, "18014398509481983K", 18446744073709550592},
+		{false, "18014398509481984K", 0},
+		{true, "17592186044415M", 18446744073708503040},
+		{false, "17592186044416M", 0},
+		{true, "17179869183G", 18446744072635809792},
+		{false, "17179869184G", 0},
+		{true, "16777215T", 18446742974197923840},
+		{false, "16777216T", 0},
+		{true, "16383P", 18445618173802708992},
+		{false, "16384P", 0},
+		{true, "18446744073709551KB", 18446744073709551000},
+		{false, "18446744073709552KB", 0},
+		{true, "18446744073709MB", 18446744073709000000},
+		{false, "18446744073710MB", 0},
+		{true, "18446744073GB", 18446744073000000000},
+		{false, "18446744074GB", 0},
+		{true, "18446744TB", 18446744000000000000},
+		{false, "18446745TB", 0},
+		{true, "18446PB", 18446000000000000000},
+		{false, "18447PB", 0},
+		{false, "1.5", 0},
+		{false, "1.K", 0},
+		{false, ".5K", 0},
+		{false, "16384P", 0},
+		{false, "KB", 0},
+		{false, "K", 0},
+		{false, "B", 0},
+		{false, "1.5B", 0},
+		{false, "10iB", 0},
+		{false, "Ki", 0},
+		{false, "ki", 0},
+		{false, "10KA", 0},
+		{false, "10Ki", 0},
+		{false, "10ki", 0},
+		{false, "10g", 0},
+		{false, "10giB", 0},
+		{false, "10gB", 0},
+		{false, "10t", 0},
+		{false, "10tB", 0},
+		{false, "10tiB", 0},
+		{false, "10p", 0},
+		{false, "10pB", 0},
+		{false, "10piB", 0},
+		{false, "10b", 0},
+		{false, "10Kb", 0},
+		{false, "10KIB", 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			result, err := ParseByteSize(test.input)
+			if err != nil && test.valid {
+				t.Errorf("Error parsing byte size for input %s: %v", test.input, err)
+			}
+			if err == nil && !test.valid {
+				t.Errorf("Unexpected result : error is nil, result is %v", result)
+			}
+			if result != test.expected && test.valid {
+				t.Errorf("Unexpected result for input %s: got %d, expected %d", test.input, result, test.expected)
+			}
+		})
+	}
+}
+
+func TestParseByteSizeWith(t *testing.T) {
+	tests := []struct {
+		valid    bool
+		units    UnitSystem
+		input    string
+		expected uint64
+	}{
+		{true, UnitsDefault, "1K", 1024},
+		{true, UnitsDefault, "1KB", 1000},
+		{true, UnitsSI, "1K", 1000},
+		{true, UnitsSI, "1KB", 1000},
+		{true, UnitsSI, "1KiB", 1000},
+		{true, UnitsSI, "2M", 2 * 1000 * 1000},
+		{true, UnitsSI, "1.5k", 1500},
+		{true, UnitsSI, "16383P", 16383 * 1000 * 1000 * 1000 * 1000 * 1000},
+		{true, UnitsIEC, "1K", 1024},
+		{true, UnitsIEC, "1KB", 1024},
+		{true, UnitsIEC, "1kB", 1024},
+		{true, UnitsIEC, "3GB", 3 * 1024 * 1024 * 1024},
+		{true, UnitsIEC, "1.5KB", 1536},
+		{true, UnitsIEC, "16383PB", 16383 * 1024 * 1024 * 1024 * 1024 * 1024},
+		{true, UnitsSI, "10B", 10},
+		{true, UnitsIEC, "10", 10},
+		{false, UnitsIEC, "16384PB", 0},
+		{false, UnitsSI, "10Ki", 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			result, err := ParseByteSizeWith(test.input, test.units)
+			if err != nil && test.valid {
+				t.Errorf("Error parsing byte size for input %s: %v", test.input, err)
+			}
+			if err == nil && !test.valid {
+				t.Errorf("Unexpected result : error is nil, result is %v", result)
+			}
+			if result != test.expected && test.valid {
+				t.Errorf("Unexpected result for input %s: got %d, expected %d", test.input, result, test.expected)
+			}
+		})
+	}
+}
+
+func TestParseByteSizeOverflowMessage(t *testing.T) {
+	for _, input := range []string{"16384P", "18447PB", "18446744073709551616", "17179869184T"} {
+		t.Run(input, func(t *testing.T) {
+			_, err := ParseByteSize(input)
+			if !errors.Is(err, OverflowHasOccured) {
+				t.Fatalf("Unexpected error: got %v, expected %s", err, OverflowHasOccured)
+			}
+			// 単位を含む入力をそのままエラーに含める
+			if !strings.HasSuffix(err.Error(), ": "+input) {
+				t.Errorf("Error does not contain the input: %s", err)
+			}
+		})
+	}
+}
+
+func TestSupportedUnits(t *testing.T) {
+	for _, units := range []UnitSystem{UnitsDefault, UnitsSI, UnitsIEC} {
+		supported := map[string]uint64{}
+		for _, unit := range SupportedUnitsWith(units) {
+			supported[unit.Suffix] = unit.Multiplier
+			// 返した単位はすべてそのバイト数として解析できる
+			size, err := ParseByteSizeWith("1"+unit.Suffix, units)
+			if err != nil {
+				t.Errorf("Unexpected error for %s: %s", unit.Suffix, err)
+			}
+			if size != unit.Multiplier {
+				t.Errorf("Unexpected multiplier of %s: got %d, parsed %d", unit.Suffix, unit.Multiplier, size)
+			}
+		}
+
+		// 単位に用いる文字の組み合わせのうち、解析できるものはすべて一覧に含まれる
+		letters := "KMGTPkmgtpBbiI"
+		candidates := []string{}
+		for _, a := range letters {
+			candidates = append(candidates, string(a))
+			for _, b := range letters {
+				candidates = append(candidates, string(a)+string(b))
+				for _, c := range letters {
+					candidates = append(candidates, string(a)+string(b)+string(c))
+				}
+			}
+		}
+		for _, suffix := range candidates {
+			_, err := ParseByteSizeWith("1"+suffix, units)
+			if _, listed := supported[suffix]; (err == nil) != listed {
+				t.Errorf("Unit %s is parsed: %v, listed: %v", suffix, err == nil, listed)
+			}
+		}
+	}
+
+	if got := SupportedUnits(); len(got) == 0 || got[0].Suffix != "B" || got[0].Multiplier != 1 {
+		t.Errorf("Unexpected units: %v", got)
+	}
+}
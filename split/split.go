@@ -0,0 +1,2188 @@
+// Package split は入力をバイト数・行数・ファイル数などの単位で複数のファイルに分割する。
+//
+// 分割の方法と単位を指定して NewSplitter で Splitter を作成し、Split を呼び出すと
+// 任意の io.Reader から読み込んだ内容を連番の接尾辞を持つファイルに出力する。
+package split
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/md5"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"math/rand"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+	"unicode/utf8"
+)
+
+// ErrorMsg はこのパッケージが返すエラーの内容
+// 詳細を付加したエラーも errors.Is で判定できる
+type ErrorMsg string
+
+func (e ErrorMsg) Error() string {
+	return string(e)
+}
+
+const (
+	InvalidByteSizeFormat       ErrorMsg = "Invalid byte size format"
+	YouMustSpecifyOnlyOneOption ErrorMsg = "You must specify only one option"
+	CannotDetermineFileSize     ErrorMsg = "Cannot determine file size"
+	OverflowHasOccured          ErrorMsg = "Overflow has occured"
+	InvalidSplitSize            ErrorMsg = "Invalid split size"
+	InvalidIndex                ErrorMsg = "invalid index"
+	InvalidNumberOfChunks       ErrorMsg = "Invalid number of chunks"
+	InvalidChunkNumber          ErrorMsg = "Invalid chunk number"
+	FilterFailed                ErrorMsg = "Filter command failed"
+	InvalidSeparator            ErrorMsg = "Invalid record separator"
+	StdoutRequiresSingleOutput  ErrorMsg = "Writing to standard output requires a single output"
+	OutputDirectoryNotWritable  ErrorMsg = "Output directory is not writable"
+	OutputDirectoryNotFound     ErrorMsg = "Output directory does not exist"
+	OutputFileSuffixesExhausted ErrorMsg = "Output file suffixes exhausted"
+	InvalidSuffixLength         ErrorMsg = "Invalid suffix length"
+	InvalidSuffixStart          ErrorMsg = "Invalid suffix start value"
+	InvalidSuffixSeparator      ErrorMsg = "Invalid suffix separator"
+	InvalidSuffixAlphabet       ErrorMsg = "Invalid suffix alphabet"
+	InvalidAdditionalSuffix     ErrorMsg = "Invalid additional suffix"
+	InvalidOutputPattern        ErrorMsg = "Invalid output file name pattern"
+	InvalidLineEnding           ErrorMsg = "Invalid line ending"
+	TotalSizeMismatch           ErrorMsg = "Input size does not match the total size"
+	InvalidChecksumAlgorithm    ErrorMsg = "Invalid checksum algorithm"
+	ChecksumRequiresFiles       ErrorMsg = "Checksums require output files"
+	InvalidPattern              ErrorMsg = "Invalid separator pattern"
+	InvalidRanges               ErrorMsg = "Invalid line ranges"
+	OutputFileCreationFailed    ErrorMsg = "Failed to create output file"
+	InvalidRecordMarker         ErrorMsg = "Invalid record marker"
+	InvalidRotateInterval       ErrorMsg = "Invalid rotate interval"
+	InvalidTimestampLayout      ErrorMsg = "Invalid timestamp layout"
+	InvalidPercent              ErrorMsg = "Invalid percentage"
+	Interrupted                 ErrorMsg = "Interrupted"
+	FollowRequiresSingleFile    ErrorMsg = "--follow requires a single input file"
+	FollowWithChunks            ErrorMsg = "--follow cannot be used with -n"
+	ManifestRequiresByteSplit   ErrorMsg = "--manifest requires splitting by bytes"
+	OutputFileExists            ErrorMsg = "Output file already exists"
+	TooManyOutputFiles          ErrorMsg = "Too many output files"
+	InputIsDirectory            ErrorMsg = "Input is a directory"
+	InvalidDecompression        ErrorMsg = "Invalid decompression format"
+	InvalidCompressedInput      ErrorMsg = "Invalid compressed input"
+	InputMadeNoProgress         ErrorMsg = "Input returned no data without an error too many times"
+	VerificationFailed          ErrorMsg = "Split files do not match the original"
+	SplitFilesNotFound          ErrorMsg = "No split files found"
+	InvalidNumberOfArguments    ErrorMsg = "Invalid number of arguments"
+	SuffixStartTooLarge         ErrorMsg = "Numerical suffix start value is too large for the suffix length"
+	SuppressRequiresPattern     ErrorMsg = "--suppress-matched requires --separator-pattern"
+	MaxLineBytesRequiresLines   ErrorMsg = "--max-line-bytes requires splitting by lines"
+	TotalSizeRequiresChunks     ErrorMsg = "--total-size requires -n N or -n l/N"
+	JoinOutputIsInput           ErrorMsg = "Join output is one of the split files"
+	RangesNotContiguous         ErrorMsg = "Line ranges are not contiguous"
+	UTF8SafeRequiresByteSplit   ErrorMsg = "--utf8-safe requires -b"
+	MinChunkRequiresChunks      ErrorMsg = "--min-chunk requires -n N"
+	TrailerFileRequiresBytes    ErrorMsg = "--trailer-file requires --trailer-bytes"
+	TrailingNewlineNeedsLines   ErrorMsg = "--ensure-trailing-newline requires splitting by lines"
+	DefaultCount                uint64   = 1000
+	DefaultPrefix               string   = "x"
+	DefaultSuffixLength         int      = 2
+	AlphabeticSuffixAlphabet    string   = "abcdefghijklmnopqrstuvwxyz"
+	NumericSuffixAlphabet       string   = "0123456789"
+	HexSuffixAlphabet           string   = "0123456789abcdef"
+)
+
+// SplitType は分割の方法
+type SplitType int
+
+const (
+	ByBytes SplitType = iota
+	ByLines
+	ByFiles
+	ByLineFiles
+	ByRoundRobin
+	ByLineBytes
+	ByPattern
+	BySizes
+	ByBalancedLines
+	ByRanges
+	ByRecords
+)
+
+// String は JSON の出力などに用いる分割の方法の名前を返す
+func (t SplitType) String() string {
+	switch t {
+	case ByBytes:
+		return "bytes"
+	case ByLines:
+		return "lines"
+	case ByFiles:
+		return "files"
+	case ByLineFiles:
+		return "line_files"
+	case ByRoundRobin:
+		return "round_robin"
+	case ByLineBytes:
+		return "line_bytes"
+	case ByPattern:
+		return "pattern"
+	case BySizes:
+		return "sizes"
+	case ByBalancedLines:
+		return "balanced_lines"
+	case ByRanges:
+		return "ranges"
+	case ByRecords:
+		return "records"
+	}
+	return fmt.Sprintf("SplitType(%d)", int(t))
+}
+
+// LineEnding は行単位の分割方法で用いる行末の扱い
+type LineEnding int
+
+const (
+	// LF (Separator) を行末とする
+	LineEndingLF LineEnding = iota
+	// CR の直後の LF だけを行末とし、単独の LF は行の途中とみなす
+	LineEndingCRLF
+	// 最初の行の行末が CRLF であれば LineEndingCRLF、それ以外は LineEndingLF とする
+	LineEndingAuto
+)
+
+// ラウンドロビンで分割する際に同時に開いておく出力ファイル数の上限
+const maxOpenRoundRobinFiles = 256
+
+// Atomic の一時ファイルの名前が既存のファイルと重なった場合に作り直す回数の上限
+const maxAtomicTempTries = 10000
+
+// 出力ファイルごとの書き込みバッファのサイズ
+const outputBufferSize = 64 * 1024
+
+// 出力ファイル数があらかじめ決まっている分割方法か
+func (t SplitType) hasFixedFileCount() bool {
+	return t == ByFiles || t == ByLineFiles || t == ByRoundRobin || t == ByBalancedLines || t == ByRanges
+}
+
+// Splitter は reader から読み込んだ内容を outputPrefix から始まる名前のファイルに分割する
+type Splitter struct {
+	splitType    SplitType
+	count        uint64
+	reader       io.Reader
+	outputPrefix string
+	Suffix       SuffixOptions
+	// 出力先を作成する関数
+	// nil の場合は FileMode の権限でファイルを作成する
+	WriterFactory WriterFactory
+	// WriterFactory が nil の場合に作成するファイルの権限 (umask 適用前)
+	// 0の場合は os.Create と同じく 0666 とする
+	FileMode os.FileMode
+	// WriterFactory で作成した出力先を削除する関数
+	// WriterFactory が指定されていてこの関数が nil の場合、出力先は削除しない
+	OutputRemover func(name string) error
+	// WriterFactory が nil の場合に、既存のファイルを上書きせずにエラーとする
+	NoClobber bool
+	// 出力ファイルを gzip で圧縮し、ファイル名の末尾に ".gz" を付加する
+	Gzip bool
+	// nil でない場合、出力ファイルを作成するたびにファイル名を書き込む
+	Verbose io.Writer
+	// 出力ファイル数を指定する分割方法で、空になるファイルを作成しない
+	// 作成しなかったファイルの接尾辞は欠番とする
+	ElideEmptyFiles bool
+	// 空でない場合、出力ファイルを作成する代わりにシェルコマンドを実行して標準入力に書き込む
+	// コマンドには環境変数 FILE で出力ファイル名を渡す
+	Filter string
+	// Filter のコマンドの標準出力と標準エラー出力 (nil の場合は os.Stdout と os.Stderr)
+	FilterStdout io.Writer
+	FilterStderr io.Writer
+	// 行単位の分割方法で用いるレコードの区切り文字 (既定は改行)
+	Separator byte
+	// 空でない場合、Separator の代わりにこのバイト列を行単位の分割方法で用いるレコードの区切りとする
+	// \r\n\r\n のような複数バイトの区切りのためのもので、LineEnding は LineEndingLF に限る
+	MultiByteSeparator []byte
+	// nil でない場合、出力ファイルを閉じるたびにそれまでに書き込んだバイト数と作成したファイル数を渡して呼び出す
+	Progress func(bytesWritten uint64, filesCreated uint64)
+	// nil でない場合、出力ファイルを閉じ終えるたびに、そのファイル名と書き込んだ内容のバイト数と MD5 を渡して呼び出す
+	// バイト数とハッシュ値は Gzip で圧縮した後のファイルの内容から、書き込みながら求めるため、ファイルを読み直さない
+	// ByRoundRobin で開き直したファイルは閉じるたびに呼び出し、最後の呼び出しがファイル全体の値となる
+	// Progress と同じく同時には呼び出さない
+	OnChunkComplete func(name string, bytes uint64, md5sum string)
+	// 行単位の分割方法での行末の扱い
+	// 行の数え方だけが変わり、ByFiles などのバイト数による境界は変わらない
+	// LineEndingLF 以外は Separator が改行の場合に限る
+	LineEnding LineEnding
+	// 出力ファイルを同じディレクトリの一時ファイルに書き込み、書き終えてから名前を変更する
+	// 出力先を監視する他のプロセスが書き込み途中のファイルを読まないようにする
+	// WriterFactory が指定されている場合は作成した空の一時ファイルの名前で呼び出すため、その名前のファイルに書き込む必要がある
+	// ByRoundRobin で開き直したファイルには直接追記する
+	Atomic bool
+	// 0でない場合、ByLines で1行がこのバイト数を超えた時点で区切り文字がなくても次のファイルに切り替える
+	// 極端に長い行をすべてメモリに読み込まないようにする
+	MaxLineBytes uint64
+	// 空でない場合、書き込みながら求めた各出力ファイルのハッシュ値を、分割を終えた後に md5sum と同じ形式で
+	// <接頭辞>.<Checksum> に書き込む (ChecksumMD5 または ChecksumSHA256)
+	// 作成したファイルの一覧のため、ファイルを作成しない WriterFactory や Filter とは併用できない
+	Checksum string
+	// 0でない場合、ByFiles で各出力ファイルが少なくともこのバイト数になるように、入力のサイズに応じて出力ファイル数を count より減らす
+	// 入力がこのバイト数に満たない場合は1つのファイルとする
+	// 接尾辞の桁数は count から求めるため、減らしても変わらない
+	MinChunkSize uint64
+	// 0でない場合、シークできない入力を ByFiles や ByLineFiles で分割する際に、一時ファイルに書き出さずにこのサイズとして分割する
+	// 実際の入力のサイズと異なる場合は TotalSizeMismatch を返す
+	TotalSize uint64
+	// ByBytes で、UTF-8 の文字の途中で区切らずに、途切れる文字の直前までを1つのファイルとする
+	// 途切れた文字は次のファイルの先頭に含める
+	// count が文字のバイト数より小さい場合など、戻れない場合は count バイトで区切る
+	UTF8Safe bool
+	// ByLines で、入力の最後の行に区切り文字がない場合に付加し、すべてのファイルを区切り文字で終える
+	// LineEndingCRLF の場合は CRLF を付加する
+	EnsureTrailingNewline bool
+	// 0でない場合、ByLines で出力ファイルを作成してからこの時間が経過した後の最初の行で次のファイルに切り替える
+	// count 行に達した場合も切り替え、どちらか先に満たした方で分割する
+	RotateInterval time.Duration
+	// 空でない場合、接頭辞と SuffixOptions の代わりにこの書式に従って出力ファイル名を求める
+	// %d は出力ファイルの番号 (SuffixOptions.Start から始まる)、%s は既定の接尾辞で、書式は ValidateOutputPattern で確認する
+	// SuffixOptions.Additional は付けず、Gzip の ".gz" は付ける
+	// ByRanges では用いず、FileNamer より優先する
+	OutputPattern string
+	// nil でない場合、GenFileName の代わりに出力ファイル名を求める関数
+	// 返した名前に SuffixOptions.Additional を付け、出力ファイルごとに異なる名前を返す必要がある
+	FileNamer FileNamer
+	// 現在時刻を返す関数
+	// nil の場合は time.Now とする
+	Now func() time.Time
+	// SplitContext がキャンセルされた場合に、書き込み途中の出力ファイルを削除せずに閉じる
+	// キャンセルは行やレコードの間で確認するため、残したファイルはその境界で終わる
+	KeepPartialOnCancel bool
+	// 0でない場合、入力の最初の HeaderLines 行を見出しとして、すべての出力ファイルの先頭に書き込む
+	// 見出しの行は -l などの行数やバイト数には数えない
+	HeaderLines uint64
+	// ByPattern で、区切りとなる行を出力に含めない
+	SuppressMatched bool
+	// 分割の途中でエラーが発生した場合に、作成した出力ファイルを削除せずに残す
+	NoCleanup bool
+	// 出力先の作成が一時的なエラー (EINTR や EAGAIN など) で失敗した場合に再試行する回数
+	// ネットワーク上のファイルシステムなどで、一時的なエラーによって長い分割が中断しないようにする
+	// 容量不足や権限不足などのエラーは再試行しない
+	CreateRetries int
+	// 0でない場合、出力ファイルをこの数より多く作成しようとした時点で TooManyOutputFiles を返す
+	// -b 1 の指定誤りなどで大量のファイルを作成しないようにする
+	MaxFiles uint64
+	// 接頭辞に含まれるディレクトリが存在しない場合に作成する
+	// 作成したディレクトリはエラーが発生しても削除しない
+	CreateDirs bool
+	// 出力をバッファリングせず、書き込むたびに出力先へ書き込む
+	// 標準入力を ByRoundRobin で分割しながら出力を読む場合などに用いる
+	Unbuffered bool
+	// 1より大きい場合、ByBytes と ByFiles で io.ReaderAt を実装するシーク可能な入力から、最大 Parallel 個の出力ファイルを並行して書き込む
+	// 出力ファイルはチャンクの順に作成するが、閉じる順序や Progress を呼び出す順序は決まらない
+	// Progress は同時には呼び出さない
+	Parallel int
+	// Progress に渡す進捗
+	bytesWritten uint64
+	filesCreated uint64
+	// 出力ファイルの番号ごとの書き込んだバイト数
+	fileBytes map[uint64]uint64
+	// OnChunkComplete に渡す、出力ファイルの番号ごとの書き込んだ内容のハッシュ値
+	chunkHashes map[uint64]*chunkHash
+	// 作成した出力ファイルの番号
+	created []uint64
+	// 出力ファイルの先頭に書き込む見出し
+	header []byte
+	// FileNamer に渡す、出力ファイルの番号ごとの作成した時刻
+	fileTimes map[uint64]time.Time
+	// Parallel で並行して書き込んでいる間に進捗を守るロック
+	progressMu *sync.Mutex
+	// 作成に失敗した出力ファイルの番号
+	failedChunk uint64
+	chunkFailed bool
+	// ByPattern で新しいファイルを始める行
+	pattern *regexp.Regexp
+	// BySizes で出力ファイルごとのバイト数
+	sizes []uint64
+	// ByRanges で出力ファイルごとの行の範囲
+	ranges []LineRange
+	// ByRecords でレコードの始まりを表すバイト列
+	marker []byte
+	// 行末を CRLF として扱うか
+	crlf bool
+	// 最初の行から行末の扱いを決める必要があるか
+	autoLineEnding bool
+	// nil でない場合、出力先を作成する代わりに EachChunk の関数に渡すチャンクを始める
+	startChunk func(index uint64) io.WriteCloser
+}
+
+// WriterFactory は name に対応する出力先を作成する
+type WriterFactory func(name string) (io.WriteCloser, error)
+
+// CreateFile は name のファイルを mode の権限で書き込み用に作成する
+// mode が0の場合は 0666 とし、noClobber の場合は既存のファイルを上書きせずに OutputFileExists を返す
+func CreateFile(name string, mode os.FileMode, noClobber bool) (*os.File, error) {
+	if mode == 0 {
+		mode = 0666
+	}
+	flag := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if noClobber {
+		flag = os.O_CREATE | os.O_WRONLY | os.O_EXCL
+	}
+	file, err := os.OpenFile(name, flag, mode)
+	if noClobber && errors.Is(err, fs.ErrExist) {
+		return nil, fmt.Errorf("%w: %s", OutputFileExists, name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+// 既定の出力先として FileMode の権限でファイルを作成する
+func (s *Splitter) createFile(name string) (io.WriteCloser, error) {
+	outputFile, err := CreateFile(name, s.FileMode, s.NoClobber)
+	if err != nil {
+		return nil, err
+	}
+	return outputFile, nil
+}
+
+// NewSplitter は splitType に応じて count バイト・行・ファイルごとに分割する Splitter を作成する
+func NewSplitter(splitType SplitType, count uint64, reader io.Reader, outputPrefix string) *Splitter {
+	return &Splitter{
+		splitType:    splitType,
+		count:        count,
+		reader:       reader,
+		outputPrefix: outputPrefix,
+		Separator:    '\n',
+	}
+}
+
+// NewPatternSplitter は pattern に一致する行が現れるたびに新しいファイルに分割する Splitter を作成する
+// 一致した行は SuppressMatched が指定されていなければ次のファイルの先頭に含める
+func NewPatternSplitter(pattern *regexp.Regexp, reader io.Reader, outputPrefix string) *Splitter {
+	splitter := NewSplitter(ByPattern, 1, reader, outputPrefix)
+	splitter.pattern = pattern
+	return splitter
+}
+
+// NewSizesSplitter は sizes の順にそれぞれのバイト数で分割する Splitter を作成する
+// 入力が残っている場合は最後のサイズを繰り返す
+func NewSizesSplitter(sizes []uint64, reader io.Reader, outputPrefix string) *Splitter {
+	splitter := NewSplitter(BySizes, uint64(len(sizes)), reader, outputPrefix)
+	splitter.sizes = sizes
+	return splitter
+}
+
+// Split は入力を最後まで読み込み、出力ファイルに分割する
+// 途中でエラーが発生した場合は、NoCleanup が指定されていなければ作成した出力ファイルを削除する
+// 出力ファイルの作成に失敗した場合は OutputFileCreationFailed とその番号を含むエラーを返す
+// このとき入力がシーク可能で、出力を連結すると入力の内容になる分割方法であれば、
+// 入力の位置を残った出力ファイルの直後 (NoCleanup でなければ分割前の位置) に戻す
+//
+// 入力が空の場合は GNU split と同じく、ファイル数の決まらない分割方法 (ByBytes や ByLines など) では
+// 出力ファイルを作成せず、ファイル数の決まる分割方法 (ByFiles や ByRoundRobin など) では
+// count 個の空のファイルを作成する (ElideEmptyFiles が指定されていれば作成しない)
+func (s *Splitter) Split() error {
+	return s.SplitContext(context.Background())
+}
+
+// SplitContext は Split と同様に分割するが、ctx がキャンセルされた場合は
+// 書き込み途中の出力ファイルを削除して (KeepPartialOnCancel の場合は閉じて残して) ctx.Err() を返す
+func (s *Splitter) SplitContext(ctx context.Context) error {
+	// 分割数が0の場合はゼロ除算や無限ループになるため受け付けない
+	if s.count == 0 {
+		return InvalidSplitSize
+	}
+	// ByRanges の出力ファイル名は範囲のラベルから求めるため、出力先を確認する前に範囲を検証する
+	if s.splitType == ByRanges {
+		if err := validateLineRanges(s.ranges); err != nil {
+			return err
+		}
+	}
+	// 入力を読み込む前に出力先のディレクトリに書き込めることを確認する
+	if err := s.checkOutputDir(); err != nil {
+		return err
+	}
+	if s.LineEnding != LineEndingLF && (s.Separator != '\n' || len(s.MultiByteSeparator) > 0) {
+		return InvalidLineEnding
+	}
+	// ファイル数の決まる分割方法は書き込む前に上限を確認できる
+	if s.MaxFiles > 0 && s.splitType.hasFixedFileCount() && !s.ElideEmptyFiles && s.count > s.MaxFiles {
+		return fmt.Errorf("%w: %d > %d", TooManyOutputFiles, s.count, s.MaxFiles)
+	}
+	if s.Checksum != "" {
+		if _, err := newHash(s.Checksum); err != nil {
+			return err
+		}
+		if s.WriterFactory != nil || s.Filter != "" {
+			return ChecksumRequiresFiles
+		}
+	}
+	s.bytesWritten = 0
+	s.filesCreated = 0
+	s.fileBytes = nil
+	s.chunkHashes = nil
+	s.created = nil
+	s.failedChunk = 0
+	s.chunkFailed = false
+	s.fileTimes = nil
+	s.crlf = s.LineEnding == LineEndingCRLF
+	s.autoLineEnding = s.LineEnding == LineEndingAuto
+
+	if s.HeaderLines > 0 {
+		restore, err := s.readHeader()
+		if err != nil {
+			return err
+		}
+		defer restore()
+	}
+
+	seeker, seekable := s.reader.(io.Seeker)
+	start := int64(0)
+	if seekable {
+		offset, err := seeker.Seek(0, io.SeekCurrent)
+		seekable = err == nil
+		start = offset
+	}
+
+	err := s.split(ctx)
+	if err == nil && s.Checksum != "" {
+		err = s.writeChecksums()
+	}
+	// キャンセルされた場合は書き込み途中のファイルだけを削除し、完成したファイルは残す
+	if err != nil && !s.NoCleanup && ctx.Err() == nil {
+		for _, index := range append([]uint64(nil), s.created...) {
+			s.removeOutputFile(index)
+		}
+	}
+	// 出力ファイルの作成に失敗した場合、シーク可能な入力は残っている出力ファイルの直後に戻し、
+	// 書き込まれずに読み飛ばされた内容が残らないようにする
+	if err != nil && s.chunkFailed && seekable && s.contiguousOutput() {
+		written := int64(0)
+		for _, index := range s.created {
+			if index < s.failedChunk {
+				written += int64(s.fileBytes[index])
+			}
+		}
+		if _, seekErr := seeker.Seek(start+written, io.SeekStart); seekErr != nil {
+			return errors.Join(err, seekErr)
+		}
+	}
+	return err
+}
+
+// 入力の最初の HeaderLines 行を見出しとして読み込み、残りを分割するように s.reader を入れ替える
+// 返す関数は分割の後に s.reader を元に戻す
+func (s *Splitter) readHeader() (func(), error) {
+	reader := s.reader
+	buffer := bufio.NewReader(reader)
+	header := []byte{}
+	for i := uint64(0); i < s.HeaderLines; i++ {
+		line, err := s.readSeparated(buffer)
+		s.detectLineEnding(line)
+		header = append(header, line...)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	s.header = header
+
+	// シーク可能な入力は先読みした分だけ戻し、-n などでサイズを求められるようにシーク可能なまま分割する
+	if seeker, ok := reader.(io.Seeker); ok {
+		if _, err := seeker.Seek(-int64(buffer.Buffered()), io.SeekCurrent); err == nil {
+			return func() { s.header = nil }, nil
+		}
+	}
+	s.reader = buffer
+	return func() {
+		s.reader = reader
+		s.header = nil
+	}, nil
+}
+
+// 出力ファイルを番号順に連結すると入力をそのまま読んだ内容になるか
+func (s *Splitter) contiguousOutput() bool {
+	if s.HeaderLines > 0 {
+		return false
+	}
+	switch s.splitType {
+	case ByRoundRobin, ByRanges:
+		return false
+	case ByPattern:
+		return !s.SuppressMatched
+	}
+	return true
+}
+
+// 作成した出力ファイルのハッシュ値を <接頭辞>.<Checksum> に書き込む
+// ハッシュ値は書き込みながら求めたもので、出力ファイルは読み直さない
+// ファイル名は一覧と同じディレクトリからの相対パスとし、md5sum -c などで確認できるようにする
+// NoClobber の場合は既存の一覧を上書きしない
+func (s *Splitter) writeChecksums() error {
+	manifestName := PrefixOrDefault(s.outputPrefix) + "." + s.Checksum
+	manifest, err := CreateFile(manifestName, 0, s.NoClobber)
+	if err != nil {
+		return err
+	}
+	for _, index := range s.created {
+		name, err := s.outputFileName(index)
+		if err != nil {
+			manifest.Close()
+			return err
+		}
+		sum := fmt.Sprintf("%x", s.chunkHashes[index].checksum.Sum(nil))
+		if _, err := fmt.Fprintf(manifest, "%s  %s\n", sum, filepath.Base(name)); err != nil {
+			manifest.Close()
+			return err
+		}
+	}
+	return manifest.Close()
+}
+
+// Created は直前の Split で作成した出力ファイル名を作成した順に返す
+// 削除した出力ファイルは含まない
+// Filter が指定されている場合は、コマンドに FILE として渡した名前を返す
+func (s *Splitter) Created() []string {
+	names := make([]string, 0, len(s.created))
+	for _, index := range s.created {
+		if name, err := s.outputFileName(index); err == nil {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// OutputFile は作成した出力ファイルの名前と、そのファイルに書き込んだ入力のバイト数
+// Gzip を指定した場合も圧縮前のバイト数とする
+type OutputFile struct {
+	Name  string
+	Bytes uint64
+}
+
+// CreatedFiles は Created と同じ順に、出力ファイルごとの書き込んだバイト数を返す
+func (s *Splitter) CreatedFiles() []OutputFile {
+	files := make([]OutputFile, 0, len(s.created))
+	for _, index := range s.created {
+		if name, err := s.outputFileName(index); err == nil {
+			files = append(files, OutputFile{Name: name, Bytes: s.fileBytes[index]})
+		}
+	}
+	return files
+}
+
+// SplitType は分割の方法を返す
+func (s *Splitter) SplitType() SplitType {
+	return s.splitType
+}
+
+// Reset は分割の方法と各オプションを保ったまま、reader を outputPrefix から始まる名前のファイルに分割するようにする
+// 同じ設定で複数の入力を分割する場合に、Splitter を作り直さずに再利用できる
+// 前回の分割で作成したファイルの記録 (Created, CreatedFiles) も消去する
+func (s *Splitter) Reset(reader io.Reader, outputPrefix string) {
+	s.reader = reader
+	s.outputPrefix = outputPrefix
+	s.bytesWritten = 0
+	s.filesCreated = 0
+	s.fileBytes = nil
+	s.created = nil
+	s.fileTimes = nil
+	s.crlf = false
+	s.autoLineEnding = false
+}
+
+// splitType に応じた方法で分割する
+func (s *Splitter) split(ctx context.Context) error {
+	switch s.splitType {
+	case ByBytes:
+		return s.splitByByte(ctx)
+	case ByLines:
+		return s.splitByLine(ctx)
+	case ByFiles:
+		return s.splitByFile(ctx)
+	case ByLineFiles:
+		return s.splitByLineFile(ctx)
+	case ByRoundRobin:
+		return s.splitByRoundRobin(ctx)
+	case ByLineBytes:
+		return s.splitByLineByte(ctx)
+	case ByPattern:
+		return s.splitByPattern(ctx)
+	case BySizes:
+		return s.splitBySizes(ctx)
+	case ByBalancedLines:
+		return s.splitByBalancedLines(ctx)
+	case ByRanges:
+		return s.splitByRanges(ctx)
+	case ByRecords:
+		return s.splitByRecord(ctx)
+	}
+
+	return InvalidSplitSize
+}
+
+func (s *Splitter) splitByByte(ctx context.Context) error {
+	// シーク可能な入力はファイル数が分かるため、書き込む前に接尾辞が足りることを確認する
+	if size, ok, err := s.seekableSize(); err != nil {
+		return err
+	} else if ok && size > 0 {
+		if _, err := s.outputFileName((size - 1) / s.count); err != nil {
+			return err
+		}
+		if input, ok := s.parallelWritable(s.reader); ok && !s.UTF8Safe {
+			chunks := []byteChunk{}
+			for start := uint64(0); start < size; start += s.count {
+				chunks = append(chunks, byteChunk{index: start / s.count, start: start, size: min(s.count, size-start)})
+			}
+			return s.copyChunksParallel(ctx, input, chunks)
+		}
+		// ファイルからの入力は count バイトのバッファを確保せずに複写する
+		// UTF8Safe ではファイルの境界を内容から決めるため、バッファに読み込む
+		if file, isFile := s.reader.(*os.File); isFile && !s.UTF8Safe {
+			return s.copyByteChunks(ctx, file, size)
+		}
+	}
+
+	buffer := make([]byte, s.count)
+	reader := &progressReader{reader: s.reader}
+	fileIndex := uint64(0)
+	carried := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		// パイプなどは1回の Read で count バイトに満たないことがあるため、バッファが埋まるまで読み込む
+		// 前のファイルから持ち越した文字の途中までのバイト列は、バッファの先頭に置いてある
+		n, readErr := io.ReadFull(reader, buffer[carried:])
+		n += carried
+		carried = 0
+		// 末尾の count バイトに満たない部分も1つのファイルとして書き込む
+		if n > 0 {
+			cut := n
+			if s.UTF8Safe && readErr == nil {
+				cut = utf8Boundary(buffer[:n])
+			}
+			if err := s.writeOutputFile(fileIndex, buffer[:cut]); err != nil {
+				return err
+			}
+			fileIndex++
+			carried = copy(buffer, buffer[cut:n])
+		}
+
+		if readErr != nil {
+			if readErr == io.EOF || readErr == io.ErrUnexpectedEOF {
+				break
+			} else {
+				return readErr
+			}
+		}
+	}
+	return nil
+}
+
+// UTF8Safe で、buffer の末尾で途切れている UTF-8 の文字の直前の位置を返す
+// 途切れていない場合や、文字がバッファ全体より長く戻れない場合は len(buffer) を返す
+// UTF-8 として正しくないバイト列は文字の途中とみなさず、そのまま区切る
+func utf8Boundary(buffer []byte) int {
+	for i := len(buffer) - 1; i >= 0 && i >= len(buffer)-utf8.UTFMax; i-- {
+		if !utf8.RuneStart(buffer[i]) {
+			continue
+		}
+		// FullRune は正しくないバイト列も1バイトの文字とみなす
+		if i == 0 || utf8.FullRune(buffer[i:]) {
+			return len(buffer)
+		}
+		return i
+	}
+	return len(buffer)
+}
+
+// 入力ファイルの現在位置から size バイトを count バイトずつ出力ファイルに複写する
+// io.CopyN に任せることで、出力先もファイルの場合は copy_file_range などでカーネル内で複写される
+func (s *Splitter) copyByteChunks(ctx context.Context, file *os.File, size uint64) error {
+	for fileIndex := uint64(0); size > 0; fileIndex++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		chunkSize := min(s.count, size)
+		outputFile, err := s.createOutputFile(fileIndex)
+		if err != nil {
+			return err
+		}
+		if _, err := io.CopyN(outputFile, file, int64(chunkSize)); err != nil {
+			outputFile.Close()
+			if err == io.EOF {
+				return io.ErrUnexpectedEOF
+			}
+			return err
+		}
+		if err := outputFile.Close(); err != nil {
+			return err
+		}
+		size -= chunkSize
+	}
+	return nil
+}
+
+// 連続して何も返さない Read を許容する回数 (bufio と同じ)
+const maxEmptyReads = 100
+
+// progressReader は Read が0バイトとエラーなしを繰り返し返す場合に InputMadeNoProgress とする
+// io.ReadFull や io.Copy は0バイトの Read を繰り返すため、読み込みが終わらなくなることを防ぐ
+type progressReader struct {
+	reader io.Reader
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	for i := 0; i < maxEmptyReads; i++ {
+		n, err := r.reader.Read(p)
+		if n > 0 || err != nil {
+			return n, err
+		}
+	}
+	return 0, InputMadeNoProgress
+}
+
+func (s *Splitter) splitByLine(ctx context.Context) error {
+	fileIndex := uint64(0)
+	lineCount := uint64(0)
+	// RotateInterval で、現在の出力ファイルを作成した時刻
+	var opened time.Time
+	// 出力ファイルは次の行が実際に読み込まれた時点で作成する
+	// ファイルごとのバイト数は区切り文字や付加した行末を含めて progressWriteCloser が数える
+	var outputFile io.WriteCloser
+	defer func() {
+		if outputFile != nil {
+			outputFile.Close()
+		}
+	}()
+
+	buffer := bufio.NewReader(s.reader)
+	for {
+		if err := ctx.Err(); err != nil {
+			if outputFile != nil {
+				s.discardOutputFile(outputFile, fileIndex)
+				outputFile = nil
+			}
+			return err
+		}
+
+		line, truncated, readErr := s.readLine(buffer)
+		if len(line) > 0 {
+			// 入力が途絶えている間は空のファイルを作成しないように、次の行が届いた時点で切り替える
+			if outputFile != nil && s.RotateInterval > 0 && s.now().Sub(opened) >= s.RotateInterval {
+				if err := outputFile.Close(); err != nil {
+					outputFile = nil
+					return err
+				}
+				outputFile = nil
+				fileIndex++
+				lineCount = 0
+			}
+			if outputFile == nil {
+				var err error
+				outputFile, err = s.createOutputFile(fileIndex)
+				if err != nil {
+					return err
+				}
+				opened = s.now()
+			}
+
+			if _, err := outputFile.Write(line); err != nil {
+				return err
+			}
+			// 区切り文字で終わる行は EOF と同時には返らないため、EOF の行は区切り文字がない
+			if readErr == io.EOF && s.EnsureTrailingNewline {
+				if _, err := outputFile.Write(s.lineTerminator()); err != nil {
+					return err
+				}
+			}
+			// EOF で返る区切り文字のない最後の行も1行と数え、count 行目であればここでファイルを閉じる
+			// その後は EOF で終了するため、空のファイルは作成しない
+			if !truncated {
+				lineCount++
+			}
+
+			// 長すぎる行は途中で次のファイルに切り替える
+			if truncated || lineCount%s.count == 0 {
+				if err := outputFile.Close(); err != nil {
+					outputFile = nil
+					return err
+				}
+				outputFile = nil
+				fileIndex++
+				lineCount = 0
+			}
+		}
+
+		if readErr != nil {
+			if readErr == io.EOF {
+				break
+			} else {
+				return readErr
+			}
+		}
+	}
+	return nil
+}
+
+// sizes の順にそれぞれのバイト数で分割し、入力が残っている間は最後のサイズを繰り返す
+func (s *Splitter) splitBySizes(ctx context.Context) error {
+	// NewSplitter で作成して sizes がない場合も、サイズが0の場合と同じく扱う
+	if len(s.sizes) == 0 {
+		return InvalidSplitSize
+	}
+	for _, size := range s.sizes {
+		if size == 0 {
+			return InvalidSplitSize
+		}
+	}
+
+	buffer := bufio.NewReader(s.reader)
+	for fileIndex := uint64(0); ; fileIndex++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		// 入力が残っていない場合は空のファイルを作成しない
+		if _, err := buffer.Peek(1); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+
+		size := s.sizes[len(s.sizes)-1]
+		if fileIndex < uint64(len(s.sizes)) {
+			size = s.sizes[fileIndex]
+		}
+		outputFile, err := s.createOutputFile(fileIndex)
+		if err != nil {
+			return err
+		}
+		_, copyErr := io.CopyN(outputFile, buffer, int64(size))
+		if err := outputFile.Close(); err != nil && copyErr == nil {
+			copyErr = err
+		}
+		if copyErr == io.EOF {
+			return nil
+		}
+		if copyErr != nil {
+			return copyErr
+		}
+	}
+}
+
+// 行末として付加する区切り文字
+func (s *Splitter) lineTerminator() []byte {
+	if s.crlf {
+		return []byte("\r\n")
+	}
+	return s.recordSeparator()
+}
+
+// レコードの区切りのバイト列
+func (s *Splitter) recordSeparator() []byte {
+	if len(s.MultiByteSeparator) > 0 {
+		return s.MultiByteSeparator
+	}
+	return []byte{s.Separator}
+}
+
+// 区切りまでを読み込む
+// 複数バイトの区切りは最後のバイトごとに読み込み、bufio のバッファの境界をまたぐ区切りも見つける
+func (s *Splitter) readSeparated(buffer *bufio.Reader) ([]byte, error) {
+	if len(s.MultiByteSeparator) == 0 {
+		return buffer.ReadBytes(s.Separator)
+	}
+	last := s.MultiByteSeparator[len(s.MultiByteSeparator)-1]
+	line := []byte{}
+	for {
+		chunk, err := buffer.ReadBytes(last)
+		line = append(line, chunk...)
+		if err != nil || bytes.HasSuffix(line, s.MultiByteSeparator) {
+			return line, err
+		}
+	}
+}
+
+// 区切り文字までの1行を読み込む
+// 行末が CRLF の場合は CR の直後の LF だけを行の区切りとする
+// ByLines で MaxLineBytes が指定されている場合は、区切り文字がなくてもその長さで打ち切り、truncated を true とする
+func (s *Splitter) readLine(buffer *bufio.Reader) (line []byte, truncated bool, err error) {
+	limit := uint64(0)
+	if s.splitType == ByLines {
+		limit = s.MaxLineBytes
+	}
+	if limit == 0 && !s.crlf {
+		line, err = s.readSeparated(buffer)
+		s.detectLineEnding(line)
+		return line, false, err
+	}
+
+	for limit == 0 || uint64(len(line)) < limit {
+		// 読み込み済みの分だけを調べ、パイプなどで残りの入力を待たない
+		if buffer.Buffered() == 0 {
+			if _, err := buffer.Peek(1); err != nil {
+				return line, false, err
+			}
+		}
+		n := buffer.Buffered()
+		if limit > 0 {
+			if remaining := limit - uint64(len(line)); uint64(n) > remaining {
+				n = int(remaining)
+			}
+		}
+		peeked, _ := buffer.Peek(n)
+		if end := s.lineEnd(line, peeked); end > 0 {
+			line = append(line, peeked[:end]...)
+			buffer.Discard(end)
+			s.detectLineEnding(line)
+			return line, false, nil
+		}
+		line = append(line, peeked...)
+		buffer.Discard(n)
+	}
+	return line, true, nil
+}
+
+// peeked の先頭から行の区切りまでの長さを返し、区切りがない場合は0を返す
+// line は peeked より前に読み込んだ同じ行の内容
+func (s *Splitter) lineEnd(line []byte, peeked []byte) int {
+	if separator := s.MultiByteSeparator; len(separator) > 0 {
+		// 区切りの途中までが line の末尾にある場合も見つける
+		tail := line[max(0, len(line)-len(separator)+1):]
+		i := bytes.Index(append(append([]byte{}, tail...), peeked...), separator)
+		if i < 0 {
+			return 0
+		}
+		return i + len(separator) - len(tail)
+	}
+	start := 0
+	for {
+		i := bytes.IndexByte(peeked[start:], s.Separator)
+		if i < 0 {
+			return 0
+		}
+		i += start
+		if !s.crlf {
+			return i + 1
+		}
+		previous := byte(0)
+		if i > 0 {
+			previous = peeked[i-1]
+		} else if len(line) > 0 {
+			previous = line[len(line)-1]
+		}
+		if previous == '\r' {
+			return i + 1
+		}
+		start = i + 1
+	}
+}
+
+// LineEndingAuto の場合、最初の行の行末から CRLF かどうかを決める
+func (s *Splitter) detectLineEnding(line []byte) {
+	if !s.autoLineEnding || !bytes.HasSuffix(line, []byte{s.Separator}) {
+		return
+	}
+	s.autoLineEnding = false
+	s.crlf = bytes.HasSuffix(line, []byte("\r\n"))
+}
+
+// pattern に一致する行が現れるたびに新しいファイルに分割する
+// 一致する行の前に出力する内容がない場合は空のファイルを作成しない
+func (s *Splitter) splitByPattern(ctx context.Context) error {
+	if s.pattern == nil {
+		return InvalidPattern
+	}
+
+	fileIndex := uint64(0)
+	// 出力ファイルは出力する行が読み込まれた時点で作成する
+	var outputFile io.WriteCloser
+	defer func() {
+		if outputFile != nil {
+			outputFile.Close()
+		}
+	}()
+
+	buffer := bufio.NewReader(s.reader)
+	for {
+		if err := ctx.Err(); err != nil {
+			if outputFile != nil {
+				s.discardOutputFile(outputFile, fileIndex)
+				outputFile = nil
+			}
+			return err
+		}
+
+		line, _, readErr := s.readLine(buffer)
+		if len(line) > 0 {
+			content := bytes.TrimSuffix(line, s.recordSeparator())
+			if s.crlf {
+				content = bytes.TrimSuffix(content, []byte("\r"))
+			}
+			matched := s.pattern.Match(content)
+			if matched && outputFile != nil {
+				if err := outputFile.Close(); err != nil {
+					outputFile = nil
+					return err
+				}
+				outputFile = nil
+				fileIndex++
+			}
+
+			if !matched || !s.SuppressMatched {
+				if outputFile == nil {
+					var err error
+					outputFile, err = s.createOutputFile(fileIndex)
+					if err != nil {
+						return err
+					}
+				}
+				if _, err := outputFile.Write(line); err != nil {
+					return err
+				}
+			}
+		}
+
+		if readErr != nil {
+			if readErr == io.EOF {
+				break
+			} else {
+				return readErr
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Splitter) splitByFile(ctx context.Context) error {
+	fileSize, reader, cleanup, err := s.inputSize()
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	if input, ok := s.parallelWritable(reader); ok {
+		chunks := []byteChunk{}
+		for i := uint64(0); i < s.fileChunkCount(fileSize); i++ {
+			start, chunkSize := s.chunkRange(fileSize, i)
+			if s.ElideEmptyFiles && chunkSize == 0 {
+				continue
+			}
+			chunks = append(chunks, byteChunk{index: i, start: start, size: chunkSize})
+		}
+		if err := s.copyChunksParallel(ctx, input, chunks); err != nil {
+			return err
+		}
+		return verifyInputEnd(reader)
+	}
+	for i := uint64(0); i < s.fileChunkCount(fileSize); i++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		_, chunkSize := s.chunkRange(fileSize, i)
+		if s.ElideEmptyFiles && chunkSize == 0 {
+			continue
+		}
+
+		outputFile, err := s.createOutputFile(i)
+		if err != nil {
+			return err
+		}
+
+		if _, err := io.CopyN(outputFile, reader, int64(chunkSize)); err != nil {
+			outputFile.Close()
+			if err == io.EOF {
+				return io.ErrUnexpectedEOF
+			}
+			return err
+		}
+		if err := outputFile.Close(); err != nil {
+			return err
+		}
+	}
+
+	return verifyInputEnd(reader)
+}
+
+// WriteChunk は ByFiles で分割した場合の chunk 番目 (1から始まる) の内容だけを w に書き込む
+// 出力ファイルは作成しない
+func (s *Splitter) WriteChunk(w io.Writer, chunk uint64) error {
+	if s.splitType != ByFiles {
+		return InvalidSplitSize
+	}
+	if chunk < 1 || chunk > s.count {
+		return fmt.Errorf("%w: %d/%d", InvalidChunkNumber, chunk, s.count)
+	}
+
+	fileSize, reader, cleanup, err := s.inputSize()
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	// MinChunkSize で減らしたファイル数を超える番号は空のチャンクとなる
+	if chunk > s.fileChunkCount(fileSize) {
+		return nil
+	}
+	start, chunkSize := s.chunkRange(fileSize, chunk-1)
+
+	// シーク可能な場合は読み飛ばす代わりに移動する
+	skipped := false
+	if seeker, ok := reader.(io.Seeker); ok {
+		if _, err := seeker.Seek(int64(start), io.SeekCurrent); err == nil {
+			skipped = true
+		}
+	}
+	if !skipped {
+		if _, err := io.CopyN(io.Discard, reader, int64(start)); err != nil {
+			return err
+		}
+	}
+
+	if _, err := io.CopyN(w, reader, int64(chunkSize)); err != nil {
+		if err == io.EOF {
+			return io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	return nil
+}
+
+// OutputFileNames は Split で作成される出力ファイル名を、ファイルを作成せずに返す
+// シーク可能な入力では実際に分割して名前を求め、読み込み位置を元に戻す
+// シークできない入力では読み込むと内容が失われるため、ファイル数が決まる分割方法に限る
+func (s *Splitter) OutputFileNames() ([]string, error) {
+	if s.count == 0 {
+		return nil, InvalidSplitSize
+	}
+
+	seeker, seekable := s.reader.(io.Seeker)
+	current := int64(0)
+	if seekable {
+		offset, err := seeker.Seek(0, io.SeekCurrent)
+		seekable = err == nil
+		current = offset
+	}
+
+	if !seekable {
+		// 空のファイルを省略する場合は内容を読まないと決まらない
+		// MinChunkSize で減らすファイル数も入力のサイズによる
+		if !s.splitType.hasFixedFileCount() || s.ElideEmptyFiles || (s.splitType == ByFiles && s.MinChunkSize > 0) {
+			return nil, CannotDetermineFileSize
+		}
+		names := make([]string, 0, s.count)
+		for i := uint64(0); i < s.count; i++ {
+			name, err := s.outputFileName(i)
+			if err != nil {
+				return nil, err
+			}
+			names = append(names, name)
+		}
+		return names, nil
+	}
+
+	names := []string{}
+	dryRun := *s
+	dryRun.Filter = ""
+	dryRun.Verbose = nil
+	dryRun.Progress = nil
+	dryRun.Checksum = ""
+	dryRun.OnChunkComplete = nil
+	// 一時ファイルを作成して名前を変更すると、空の出力ファイルが残る
+	dryRun.Atomic = false
+	dryRun.WriterFactory = func(name string) (io.WriteCloser, error) {
+		names = append(names, name)
+		return discardWriteCloser{}, nil
+	}
+	err := dryRun.Split()
+	if _, seekErr := seeker.Seek(current, io.SeekStart); err == nil {
+		err = seekErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
+// discardWriteCloser は書き込まれた内容を破棄する
+type discardWriteCloser struct{}
+
+func (discardWriteCloser) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+func (discardWriteCloser) Close() error {
+	return nil
+}
+
+// ByFiles で fileSize バイトの入力を分割する出力ファイル数を求める
+// MinChunkSize が指定されている場合は、各ファイルがそのバイト数以上になる数まで減らす
+func (s *Splitter) fileChunkCount(fileSize uint64) uint64 {
+	if s.splitType != ByFiles || s.MinChunkSize == 0 {
+		return s.count
+	}
+	return max(1, min(s.count, fileSize/s.MinChunkSize))
+}
+
+// ByFiles で分割した場合の index 番目のファイルの開始位置とサイズを求める
+// 割り切れない分は先頭のファイルから1バイトずつ含め、各ファイルのサイズの差を1バイト以下にする
+func (s *Splitter) chunkRange(fileSize uint64, index uint64) (uint64, uint64) {
+	count := s.fileChunkCount(fileSize)
+	byteCount := fileSize / count
+	byteRemain := fileSize % count
+	start := byteCount*index + min(index, byteRemain)
+	if index < byteRemain {
+		return start, byteCount + 1
+	}
+	return start, byteCount
+}
+
+// シーク可能な入力の現在位置から末尾までのサイズを、読み込み位置を変えずに求める
+// シークできない場合は ok を false とする
+func (s *Splitter) seekableSize() (size uint64, ok bool, err error) {
+	seeker, ok := s.reader.(io.Seeker)
+	if !ok {
+		return 0, false, nil
+	}
+	current, err := seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, false, nil
+	}
+	end, err := seeker.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0, false, err
+	}
+	if _, err := seeker.Seek(current, io.SeekStart); err != nil {
+		return 0, false, err
+	}
+	return uint64(end - current), true, nil
+}
+
+// 入力のサイズを求める
+// シーク可能な入力は現在位置から末尾までのサイズを求めてそのまま読み込み、
+// TotalSize が指定されている場合はそのサイズとして読み込み、
+// それ以外の入力は一時ファイルに書き出してからサイズを求める
+// 返り値の関数は読み込みが終わった後に呼び出し、一時ファイルを削除する
+func (s *Splitter) inputSize() (uint64, io.Reader, func(), error) {
+	if size, ok, err := s.seekableSize(); err != nil {
+		return 0, nil, nil, err
+	} else if ok {
+		return size, s.reader, func() {}, nil
+	}
+	if s.TotalSize > 0 {
+		return s.TotalSize, &sizedReader{reader: s.reader, remaining: s.TotalSize}, func() {}, nil
+	}
+
+	spool, cleanup, err := s.spoolInput()
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	info, err := spool.Stat()
+	if err != nil {
+		cleanup()
+		return 0, nil, nil, err
+	}
+	return uint64(info.Size()), spool, cleanup, nil
+}
+
+// 入力を一時ファイルに書き出し、先頭にシークした一時ファイルを返す
+// 返り値の関数は読み込みが終わった後に呼び出し、一時ファイルを削除する
+func (s *Splitter) spoolInput() (*os.File, func(), error) {
+	spool, err := os.CreateTemp("", "split-*")
+	if err != nil {
+		return nil, nil, err
+	}
+	cleanup := func() {
+		spool.Close()
+		os.Remove(spool.Name())
+	}
+	if _, err := io.Copy(spool, &progressReader{reader: s.reader}); err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	if _, err := spool.Seek(0, io.SeekStart); err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	return spool, cleanup, nil
+}
+
+// sizedReader は remaining バイトを読み込んだ時点で EOF を返し、
+// それより前に入力が終わった場合は TotalSizeMismatch を返す
+type sizedReader struct {
+	reader    io.Reader
+	remaining uint64
+}
+
+func (r *sizedReader) Read(p []byte) (int, error) {
+	if r.remaining == 0 {
+		return 0, io.EOF
+	}
+	if uint64(len(p)) > r.remaining {
+		p = p[:r.remaining]
+	}
+	n, err := r.reader.Read(p)
+	r.remaining -= uint64(n)
+	if err == io.EOF && r.remaining > 0 {
+		return n, fmt.Errorf("%w: %d bytes short", TotalSizeMismatch, r.remaining)
+	}
+	if err == io.EOF {
+		err = nil
+	}
+	return n, err
+}
+
+// 指定したサイズを読み込んだ後に入力が残っていないことを確認する
+func (r *sizedReader) verifyEnd() error {
+	var extra [1]byte
+	for {
+		n, err := r.reader.Read(extra[:])
+		if n > 0 {
+			return fmt.Errorf("%w: input is longer", TotalSizeMismatch)
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// inputSize が返した reader を最後まで読み込んだ後に、TotalSize と入力のサイズが一致することを確認する
+func verifyInputEnd(reader io.Reader) error {
+	if sized, ok := reader.(*sizedReader); ok {
+		return sized.verifyEnd()
+	}
+	return nil
+}
+
+// 行の途中で区切らずに、1ファイルあたり count バイト以下になるように分割する
+// count バイトを超える行はそれだけで1つのファイルに出力する
+func (s *Splitter) splitByLineByte(ctx context.Context) error {
+	fileIndex := uint64(0)
+	fileBytes := uint64(0)
+	var outputFile io.WriteCloser
+	defer func() {
+		if outputFile != nil {
+			outputFile.Close()
+		}
+	}()
+
+	buffer := bufio.NewReader(s.reader)
+	for {
+		if err := ctx.Err(); err != nil {
+			if outputFile != nil {
+				s.discardOutputFile(outputFile, fileIndex)
+				outputFile = nil
+			}
+			return err
+		}
+
+		line, _, readErr := s.readLine(buffer)
+		if len(line) > 0 {
+			// 現在のファイルに収まらない場合は次のファイルに出力する
+			if outputFile != nil && fileBytes+uint64(len(line)) > s.count {
+				if err := outputFile.Close(); err != nil {
+					outputFile = nil
+					return err
+				}
+				outputFile = nil
+				fileIndex++
+				fileBytes = 0
+			}
+			if outputFile == nil {
+				var err error
+				outputFile, err = s.createOutputFile(fileIndex)
+				if err != nil {
+					return err
+				}
+			}
+
+			if _, err := outputFile.Write(line); err != nil {
+				return err
+			}
+			fileBytes += uint64(len(line))
+		}
+
+		if readErr != nil {
+			if readErr == io.EOF {
+				break
+			} else {
+				return readErr
+			}
+		}
+	}
+	return nil
+}
+
+// 入力のサイズから出力ファイルごとの目安の境界を求め、
+// 境界を含む行の終わりまでを同じファイルに出力する
+func (s *Splitter) splitByLineFile(ctx context.Context) error {
+	fileSize, reader, cleanup, err := s.inputSize()
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	byteCount := fileSize / s.count
+
+	buffer := bufio.NewReader(reader)
+	offset := uint64(0)
+	for i := uint64(0); i < s.count; i++ {
+		end := byteCount * (i + 1)
+		if i == s.count-1 {
+			end = fileSize
+		}
+		// 前のファイルが境界を越えて行を出力した場合は空になる
+		if s.ElideEmptyFiles && offset >= end {
+			continue
+		}
+
+		outputFile, err := s.createOutputFile(i)
+		if err != nil {
+			return err
+		}
+		for offset < end {
+			if err := ctx.Err(); err != nil {
+				s.discardOutputFile(outputFile, i)
+				return err
+			}
+
+			line, _, err := s.readLine(buffer)
+			if _, err := outputFile.Write(line); err != nil {
+				outputFile.Close()
+				return err
+			}
+			offset += uint64(len(line))
+			if err != nil {
+				if err == io.EOF {
+					break
+				} else {
+					outputFile.Close()
+					return err
+				}
+			}
+		}
+		if err := outputFile.Close(); err != nil {
+			return err
+		}
+	}
+
+	return verifyInputEnd(reader)
+}
+
+// 行数を数えてから、各ファイルの行数の差が1以下になるように count 個のファイルに分割する
+// 割り切れない場合は先頭のファイルから1行ずつ多くする
+// シークできない入力は2回読み込むために一時ファイルに書き出す
+func (s *Splitter) splitByBalancedLines(ctx context.Context) error {
+	var input io.ReadSeeker
+	start := int64(0)
+	if seeker, ok := s.reader.(io.ReadSeeker); ok {
+		if offset, err := seeker.Seek(0, io.SeekCurrent); err == nil {
+			input, start = seeker, offset
+		}
+	}
+	if input == nil {
+		spool, cleanup, err := s.spoolInput()
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+		input = spool
+	}
+
+	// 1回目は行数だけを数える
+	totalLines := uint64(0)
+	buffer := bufio.NewReader(input)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		line, _, err := s.readLine(buffer)
+		if len(line) > 0 {
+			totalLines++
+		}
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+	}
+	if _, err := input.Seek(start, io.SeekStart); err != nil {
+		return err
+	}
+
+	lineCount := totalLines / s.count
+	extraLines := totalLines % s.count
+	buffer.Reset(input)
+	for i := uint64(0); i < s.count; i++ {
+		lines := lineCount
+		if i < extraLines {
+			lines++
+		}
+		if s.ElideEmptyFiles && lines == 0 {
+			continue
+		}
+
+		outputFile, err := s.createOutputFile(i)
+		if err != nil {
+			return err
+		}
+		for ; lines > 0; lines-- {
+			if err := ctx.Err(); err != nil {
+				s.discardOutputFile(outputFile, i)
+				return err
+			}
+
+			line, _, err := s.readLine(buffer)
+			if _, err := outputFile.Write(line); err != nil {
+				outputFile.Close()
+				return err
+			}
+			if err != nil && err != io.EOF {
+				outputFile.Close()
+				return err
+			}
+		}
+		if err := outputFile.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 行を順番に N 個のファイルへ振り分ける
+// ファイル数が多い場合は最も古く開いたファイルを閉じ、必要になった時点で追記モードで開き直す
+// WriterFactory や Filter が指定されている場合は開き直せないため、すべての出力先を開いたままにする
+func (s *Splitter) splitByRoundRobin(ctx context.Context) error {
+	maxOpenFiles := uint64(maxOpenRoundRobinFiles)
+	if s.WriterFactory != nil || s.Filter != "" {
+		maxOpenFiles = s.count
+	}
+	outputFiles := make([]io.WriteCloser, s.count)
+	created := make([]bool, s.count)
+	openIndexes := []uint64{}
+	defer func() {
+		for _, outputFile := range outputFiles {
+			if outputFile != nil {
+				outputFile.Close()
+			}
+		}
+	}()
+
+	// 入力の行数が N より少ない場合でも N 個のファイルを作成する
+	// 空のファイルを作成しない場合は、最初の行を書き込む時点で作成する
+	for i := uint64(0); i < s.count && !s.ElideEmptyFiles; i++ {
+		outputFile, err := s.createOutputFile(i)
+		if err != nil {
+			return err
+		}
+		created[i] = true
+		if i < maxOpenFiles {
+			outputFiles[i] = outputFile
+			openIndexes = append(openIndexes, i)
+		} else if err := outputFile.Close(); err != nil {
+			return err
+		}
+	}
+
+	buffer := bufio.NewReader(s.reader)
+	lineNumber := uint64(0)
+	for {
+		// 振り分け途中のファイルはすべて書き込み途中となる
+		if err := ctx.Err(); err != nil {
+			for i, outputFile := range outputFiles {
+				if outputFile != nil {
+					outputFile.Close()
+					outputFiles[i] = nil
+				}
+				if created[i] {
+					s.removeOutputFile(uint64(i))
+				}
+			}
+			return err
+		}
+
+		line, _, readErr := s.readLine(buffer)
+		if len(line) > 0 {
+			i := lineNumber % s.count
+			if outputFiles[i] == nil {
+				if uint64(len(openIndexes)) >= maxOpenFiles {
+					oldest := openIndexes[0]
+					openIndexes = openIndexes[1:]
+					if err := outputFiles[oldest].Close(); err != nil {
+						outputFiles[oldest] = nil
+						return err
+					}
+					outputFiles[oldest] = nil
+				}
+				var outputFile io.WriteCloser
+				var err error
+				if created[i] {
+					outputFile, err = s.reopenOutputFile(i)
+				} else {
+					outputFile, err = s.createOutputFile(i)
+				}
+				if err != nil {
+					return err
+				}
+				created[i] = true
+				outputFiles[i] = outputFile
+				openIndexes = append(openIndexes, i)
+			}
+
+			if _, err := outputFiles[i].Write(line); err != nil {
+				return err
+			}
+			lineNumber++
+		}
+
+		if readErr != nil {
+			if readErr == io.EOF {
+				break
+			} else {
+				return readErr
+			}
+		}
+	}
+
+	for i, outputFile := range outputFiles {
+		if outputFile == nil {
+			continue
+		}
+		outputFiles[i] = nil
+		if err := outputFile.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 出力ファイルを作成するディレクトリが存在し、書き込み可能であることを確認する
+// WriterFactory や Filter が指定されている場合はファイルを作成しないため確認しない
+func (s *Splitter) checkOutputDir() error {
+	if s.WriterFactory != nil || s.Filter != "" {
+		return nil
+	}
+
+	outputFileName, err := s.outputFileName(0)
+	if err != nil {
+		return err
+	}
+	outputDir := filepath.Dir(outputFileName)
+	if s.CreateDirs {
+		if err := os.MkdirAll(outputDir, 0777); err != nil {
+			return fmt.Errorf("%w: %s: %w", OutputDirectoryNotWritable, outputDir, err)
+		}
+	}
+	info, err := os.Stat(outputDir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%w: %s", OutputDirectoryNotFound, outputDir)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %s: %w", OutputDirectoryNotWritable, outputDir, err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%w: %s: not a directory", OutputDirectoryNotWritable, outputDir)
+	}
+
+	// 権限の判定は環境によって異なるため、実際に一時ファイルを作成して確認する
+	probe, err := os.CreateTemp(outputDir, ".split-*")
+	if err != nil {
+		return fmt.Errorf("%w: %s: %w", OutputDirectoryNotWritable, outputDir, err)
+	}
+	probe.Close()
+	return os.Remove(probe.Name())
+}
+
+// index 番目の出力ファイル名を求める
+func (s *Splitter) outputFileName(index uint64) (string, error) {
+	var outputFileName string
+	var err error
+	if s.splitType == ByRanges {
+		outputFileName, err = s.rangeFileName(index)
+	} else if s.OutputPattern != "" {
+		outputFileName, err = formatOutputPattern(s.OutputPattern, index, s.count, s.splitType, s.Suffix)
+	} else if s.FileNamer != nil {
+		outputFileName, err = s.namerFileName(index)
+	} else {
+		outputFileName, err = GenFileName(s.outputPrefix, index, s.count, s.splitType, s.Suffix)
+	}
+	if err != nil {
+		return "", err
+	}
+	if s.Gzip {
+		outputFileName += ".gz"
+	}
+	return outputFileName, nil
+}
+
+func (s *Splitter) createOutputFile(index uint64) (io.WriteCloser, error) {
+	outputFile, err := s.openOutputFile(index)
+	if err != nil {
+		s.failedChunk = index
+		s.chunkFailed = true
+		return nil, fmt.Errorf("%w: chunk %d: %w", OutputFileCreationFailed, index, err)
+	}
+	if len(s.header) > 0 {
+		if _, err := outputFile.Write(s.header); err != nil {
+			outputFile.Close()
+			return nil, err
+		}
+	}
+	return outputFile, nil
+}
+
+func (s *Splitter) openOutputFile(index uint64) (io.WriteCloser, error) {
+	if s.MaxFiles > 0 && s.filesCreated >= s.MaxFiles {
+		return nil, fmt.Errorf("%w: %d", TooManyOutputFiles, s.MaxFiles)
+	}
+	if s.FileNamer != nil {
+		if s.fileTimes == nil {
+			s.fileTimes = map[uint64]time.Time{}
+		}
+		s.fileTimes[index] = s.now()
+	}
+	outputFileName, err := s.outputFileName(index)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.startChunk != nil {
+		s.addCreated(index)
+		return s.wrapOutputFile(s.startChunk(index), index), nil
+	}
+
+	if s.Filter != "" {
+		if s.Verbose != nil {
+			fmt.Fprintf(s.Verbose, "executing with FILE=%s\n", outputFileName)
+		}
+		outputFile, err := s.startFilter(outputFileName)
+		if err != nil {
+			return nil, err
+		}
+		s.addCreated(index)
+		return s.wrapOutputFile(outputFile, index), nil
+	}
+
+	if s.Verbose != nil {
+		fmt.Fprintf(s.Verbose, "creating file '%s'\n", outputFileName)
+	}
+
+	writerFactory := s.WriterFactory
+	if writerFactory == nil {
+		writerFactory = s.createFile
+	}
+	if !s.Atomic {
+		outputFile, err := s.createWithRetry(writerFactory, outputFileName)
+		if err != nil {
+			return nil, err
+		}
+		s.addCreated(index)
+		return s.wrapOutputFile(outputFile, index), nil
+	}
+
+	// 一時ファイルに書き込み、Close で出力ファイル名に変更する
+	// NoClobber は一時ファイルではなく出力ファイル名について確認する
+	if s.NoClobber {
+		if _, err := os.Lstat(outputFileName); err == nil {
+			return nil, fmt.Errorf("%w: %s", OutputFileExists, outputFileName)
+		}
+	}
+	tempFile, err := s.createAtomicTemp(outputFileName)
+	if err != nil {
+		return nil, err
+	}
+	tempName := tempFile.Name()
+	var outputFile io.WriteCloser = tempFile
+	// WriterFactory には作成した一時ファイルの名前を渡し、その名前で書き込ませる
+	if s.WriterFactory != nil {
+		tempFile.Close()
+		outputFile, err = s.createWithRetry(s.WriterFactory, tempName)
+		if err != nil {
+			os.Remove(tempName)
+			return nil, err
+		}
+	}
+	s.addCreated(index)
+	return s.wrapOutputFile(&atomicWriteCloser{WriteCloser: outputFile, tempName: tempName, name: outputFileName, noClobber: s.NoClobber}, index), nil
+}
+
+// index 番目の出力ファイルを作成したことを記録する
+func (s *Splitter) addCreated(index uint64) {
+	unlock := s.lockProgress()
+	defer unlock()
+	s.filesCreated++
+	s.created = append(s.created, index)
+}
+
+// Parallel で並行して書き込んでいる間は進捗をロックする
+// 返す関数でロックを解除する
+func (s *Splitter) lockProgress() func() {
+	if s.progressMu == nil {
+		return func() {}
+	}
+	s.progressMu.Lock()
+	return s.progressMu.Unlock
+}
+
+// 出力先の作成を再試行する前に待つ時間 (再試行のたびに倍にする)
+var createRetryDelay = 10 * time.Millisecond
+
+// writerFactory で出力先を作成し、一時的なエラーの場合は CreateRetries 回まで再試行する
+func (s *Splitter) createWithRetry(writerFactory WriterFactory, name string) (io.WriteCloser, error) {
+	delay := createRetryDelay
+	for attempt := 0; ; attempt++ {
+		outputFile, err := writerFactory(name)
+		if err == nil || attempt >= s.CreateRetries || !isRetryableCreateError(err) {
+			return outputFile, err
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// 再試行すれば成功する可能性のあるエラーか
+func isRetryableCreateError(err error) bool {
+	for _, errno := range []syscall.Errno{syscall.EINTR, syscall.EAGAIN, syscall.EBUSY, syscall.ETIMEDOUT} {
+		if errors.Is(err, errno) {
+			return true
+		}
+	}
+	return false
+}
+
+// name に書き込む前の一時ファイルを作成する
+// 名前の変更が同じファイルシステム内で完結するように、同じディレクトリに置く
+// 同時に実行した他の split や、中断した実行が残した一時ファイルと重ならないように乱数を含む名前とし、
+// 既存のファイルと重なった場合は名前を変えて作り直す
+// 権限は直接作成する場合と同じく FileMode とし、umask はカーネルに適用させる
+func (s *Splitter) createAtomicTemp(name string) (*os.File, error) {
+	dir, base := filepath.Dir(name), filepath.Base(name)
+	for try := 0; ; try++ {
+		tempName := filepath.Join(dir, "."+base+"."+strconv.FormatUint(uint64(rand.Uint32()), 10)+".tmp")
+		tempFile, err := CreateFile(tempName, s.FileMode, true)
+		if errors.Is(err, OutputFileExists) && try < maxAtomicTempTries {
+			continue
+		}
+		return tempFile, err
+	}
+}
+
+// atomicWriteCloser は一時ファイルに書き込み、Close で name に名前を変更する
+// noClobber の場合は既存のファイルを置き換えないように、リンクを作成してから一時ファイルを削除する
+type atomicWriteCloser struct {
+	io.WriteCloser
+	tempName  string
+	name      string
+	noClobber bool
+}
+
+func (w *atomicWriteCloser) Close() error {
+	if err := w.WriteCloser.Close(); err != nil {
+		os.Remove(w.tempName)
+		return err
+	}
+	if !w.noClobber {
+		return os.Rename(w.tempName, w.name)
+	}
+	defer os.Remove(w.tempName)
+	if err := os.Link(w.tempName, w.name); err != nil {
+		if errors.Is(err, fs.ErrExist) {
+			return fmt.Errorf("%w: %s", OutputFileExists, w.name)
+		}
+		return err
+	}
+	return nil
+}
+
+// 出力オプションに応じて書き込み先をラップする
+func (s *Splitter) wrapOutputFile(outputFile io.WriteCloser, index uint64) io.WriteCloser {
+	if s.OnChunkComplete != nil || s.Checksum != "" {
+		// 開き直したファイルは以前の内容に続けて求める
+		if s.chunkHashes == nil {
+			s.chunkHashes = map[uint64]*chunkHash{}
+		}
+		if s.chunkHashes[index] == nil {
+			s.chunkHashes[index] = s.newChunkHash()
+		}
+		name, _ := s.outputFileName(index)
+		outputFile = &hashWriteCloser{WriteCloser: outputFile, splitter: s, name: name, sum: s.chunkHashes[index]}
+	}
+	outputFile = shortWriteCloser{outputFile}
+	if !s.Unbuffered {
+		outputFile = &bufferedWriteCloser{Writer: bufio.NewWriterSize(outputFile, outputBufferSize), file: outputFile}
+	}
+	if s.Gzip {
+		outputFile = &gzipWriteCloser{Writer: gzip.NewWriter(outputFile), file: outputFile}
+	}
+	return &progressWriteCloser{WriteCloser: outputFile, splitter: s, index: index}
+}
+
+// progressWriteCloser は書き込んだバイト数をファイルごとに数え、Close で Progress を呼び出す
+// 圧縮の有無によらず入力から書き込んだバイト数とするため、最も外側に置く
+type progressWriteCloser struct {
+	io.WriteCloser
+	splitter *Splitter
+	index    uint64
+}
+
+func (w *progressWriteCloser) Write(p []byte) (int, error) {
+	n, err := w.WriteCloser.Write(p)
+	w.count(int64(n))
+	return n, err
+}
+
+// 内側の io.ReaderFrom による最適化を妨げないようにする
+func (w *progressWriteCloser) ReadFrom(r io.Reader) (int64, error) {
+	var n int64
+	var err error
+	if readerFrom, ok := w.WriteCloser.(io.ReaderFrom); ok {
+		n, err = readerFrom.ReadFrom(r)
+	} else {
+		n, err = io.Copy(struct{ io.Writer }{w.WriteCloser}, r)
+	}
+	w.count(n)
+	return n, err
+}
+
+func (w *progressWriteCloser) count(n int64) {
+	unlock := w.splitter.lockProgress()
+	defer unlock()
+	w.splitter.bytesWritten += uint64(n)
+	if w.splitter.fileBytes == nil {
+		w.splitter.fileBytes = map[uint64]uint64{}
+	}
+	w.splitter.fileBytes[w.index] += uint64(n)
+}
+
+func (w *progressWriteCloser) Close() error {
+	if err := w.WriteCloser.Close(); err != nil {
+		return err
+	}
+	unlock := w.splitter.lockProgress()
+	defer unlock()
+	if w.splitter.Progress != nil {
+		w.splitter.Progress(w.splitter.bytesWritten, w.splitter.filesCreated)
+	}
+	return nil
+}
+
+// chunkHash は出力ファイルに書き込んだ内容のバイト数とハッシュ値
+// hash は OnChunkComplete に渡す MD5、checksum は Checksum のアルゴリズムによるもので、それぞれ指定がない場合は nil とする
+type chunkHash struct {
+	hash     hash.Hash
+	checksum hash.Hash
+	bytes    uint64
+}
+
+func (s *Splitter) newChunkHash() *chunkHash {
+	sum := &chunkHash{}
+	if s.OnChunkComplete != nil {
+		sum.hash = md5.New()
+	}
+	if s.Checksum != "" {
+		// アルゴリズムは Split の始めに確認している
+		sum.checksum, _ = newHash(s.Checksum)
+	}
+	return sum
+}
+
+// hashWriteCloser は出力先に書き込んだ内容からハッシュ値を求め、閉じ終えた後に OnChunkComplete を呼び出す
+// 圧縮した後の内容を求めるため、最も内側に置く
+type hashWriteCloser struct {
+	io.WriteCloser
+	splitter *Splitter
+	name     string
+	sum      *chunkHash
+}
+
+func (w *hashWriteCloser) Write(p []byte) (int, error) {
+	n, err := w.WriteCloser.Write(p)
+	if w.sum.hash != nil {
+		w.sum.hash.Write(p[:n])
+	}
+	if w.sum.checksum != nil {
+		w.sum.checksum.Write(p[:n])
+	}
+	w.sum.bytes += uint64(n)
+	return n, err
+}
+
+func (w *hashWriteCloser) Close() error {
+	if err := w.WriteCloser.Close(); err != nil {
+		return err
+	}
+	if w.splitter.OnChunkComplete == nil {
+		return nil
+	}
+	unlock := w.splitter.lockProgress()
+	defer unlock()
+	w.splitter.OnChunkComplete(w.name, w.sum.bytes, fmt.Sprintf("%x", w.sum.hash.Sum(nil)))
+	return nil
+}
+
+// shortWriteCloser はエラーを返さずに一部しか書き込まなかった場合を io.ErrShortWrite とする
+// WriterFactory が返す io.Writer が io.Writer の規約に従わない場合に備える
+type shortWriteCloser struct {
+	io.WriteCloser
+}
+
+func (w shortWriteCloser) Write(p []byte) (int, error) {
+	n, err := w.WriteCloser.Write(p)
+	if err == nil && n < len(p) {
+		err = io.ErrShortWrite
+	}
+	return n, err
+}
+
+// 出力先が io.ReaderFrom を実装している場合は、ファイル間の複写などの最適化をそのまま使う
+func (w shortWriteCloser) ReadFrom(r io.Reader) (int64, error) {
+	if readerFrom, ok := w.WriteCloser.(io.ReaderFrom); ok {
+		return readerFrom.ReadFrom(r)
+	}
+	// ReadFrom を隠して Write で書き込む
+	return io.Copy(struct{ io.Writer }{w}, r)
+}
+
+// bufferedWriteCloser は file への書き込みをバッファリングし、Close でフラッシュしてから file を閉じる
+type bufferedWriteCloser struct {
+	*bufio.Writer
+	file io.WriteCloser
+}
+
+func (w *bufferedWriteCloser) Close() error {
+	if err := w.Writer.Flush(); err != nil {
+		w.file.Close()
+		return err
+	}
+	return w.file.Close()
+}
+
+// gzipWriteCloser は圧縮した内容を file に書き込み、Close で gzip と file の両方を閉じる
+type gzipWriteCloser struct {
+	*gzip.Writer
+	file io.WriteCloser
+}
+
+func (w *gzipWriteCloser) Close() error {
+	if err := w.Writer.Close(); err != nil {
+		w.file.Close()
+		return err
+	}
+	return w.file.Close()
+}
+
+// 出力ファイルを作成して data を書き込み、すぐに閉じる
+func (s *Splitter) writeOutputFile(index uint64, data []byte) error {
+	outputFile, err := s.createOutputFile(index)
+	if err != nil {
+		return err
+	}
+
+	if _, err := outputFile.Write(data); err != nil {
+		outputFile.Close()
+		return err
+	}
+	return outputFile.Close()
+}
+
+// Filter をシェルで実行し、その標準入力を返す
+func (s *Splitter) startFilter(outputFileName string) (io.WriteCloser, error) {
+	cmd := exec.Command("sh", "-c", s.Filter)
+	cmd.Env = append(os.Environ(), "FILE="+outputFileName)
+	cmd.Stdout = os.Stdout
+	if s.FilterStdout != nil {
+		cmd.Stdout = s.FilterStdout
+	}
+	cmd.Stderr = os.Stderr
+	if s.FilterStderr != nil {
+		cmd.Stderr = s.FilterStderr
+	}
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return &filterWriteCloser{WriteCloser: stdin, cmd: cmd, name: outputFileName}, nil
+}
+
+// filterWriteCloser はコマンドの標準入力に書き込み、Close でコマンドの終了を待つ
+type filterWriteCloser struct {
+	io.WriteCloser
+	cmd  *exec.Cmd
+	name string
+}
+
+func (w *filterWriteCloser) Close() error {
+	closeErr := w.WriteCloser.Close()
+	if err := w.cmd.Wait(); err != nil {
+		return fmt.Errorf("%w: %s: %w", FilterFailed, w.name, err)
+	}
+	return closeErr
+}
+
+// 書き込み途中の出力ファイルを閉じて削除する
+// KeepPartialOnCancel の場合は、それまでに書き込んだ内容で閉じて残す
+func (s *Splitter) discardOutputFile(outputFile io.WriteCloser, index uint64) {
+	outputFile.Close()
+	if s.KeepPartialOnCancel {
+		return
+	}
+	s.removeOutputFile(index)
+}
+
+// 出力ファイルを削除する
+// Filter が指定されている場合や、WriterFactory に対応する OutputRemover がない場合は出力先を削除できないため何もしない
+func (s *Splitter) removeOutputFile(index uint64) {
+	if s.Filter != "" || (s.WriterFactory != nil && s.OutputRemover == nil) {
+		return
+	}
+	outputFileName, err := s.outputFileName(index)
+	if err != nil {
+		return
+	}
+	remove := os.Remove
+	if s.WriterFactory != nil {
+		remove = s.OutputRemover
+	}
+	if err := remove(outputFileName); err != nil {
+		return
+	}
+
+	// 削除したファイルは Created に含めない
+	for i, created := range s.created {
+		if created == index {
+			s.created = append(s.created[:i], s.created[i+1:]...)
+			break
+		}
+	}
+}
+
+// 作成済みの出力ファイルを追記モードで開き直す
+func (s *Splitter) reopenOutputFile(index uint64) (io.WriteCloser, error) {
+	outputFileName, err := s.outputFileName(index)
+	if err != nil {
+		return nil, err
+	}
+
+	// gzip の場合は新しいメンバーとして追記する
+	outputFile, err := os.OpenFile(outputFileName, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		return nil, err
+	}
+	return s.wrapOutputFile(outputFile, index), nil
+}
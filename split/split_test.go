@@ -0,0 +1,2676 @@
+package split
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestSplitterSplit(t *testing.T) {
+	wd, _ := os.Getwd()
+	wd += "/"
+	inputTestFileDir := wd + "testfiles/input/"
+	outputTestFileDir := wd + "testfiles/output/"
+	tests := []struct {
+		name                string
+		splitType           SplitType
+		count               uint64
+		inputFileName       string
+		outputFilePrefix    string
+		expectedOutputNames []string
+	}{
+		{
+			name:                "split by bytes",
+			splitType:           ByBytes,
+			count:               50,
+			inputFileName:       "sample.txt",
+			outputFilePrefix:    "bytes_",
+			expectedOutputNames: []string{"bytes_aa", "bytes_ab", "bytes_ac", "bytes_ad", "bytes_ae", "bytes_af", "bytes_ag", "bytes_ah"},
+		},
+		{
+			name:                "split by lines",
+			splitType:           ByLines,
+			count:               2,
+			inputFileName:       "sample.txt",
+			outputFilePrefix:    "lines_",
+			expectedOutputNames: []string{"lines_aa", "lines_ab", "lines_ac"},
+		},
+		{
+			name:                "split by files",
+			splitType:           ByFiles,
+			count:               3,
+			inputFileName:       "sample.txt",
+			outputFilePrefix:    "files_",
+			expectedOutputNames: []string{"files_aa", "files_ab", "files_ac"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			inputFile, _ := os.Open(inputTestFileDir + test.inputFileName)
+			defer inputFile.Close()
+
+			splitter := NewSplitter(test.splitType, test.count, inputFile, test.outputFilePrefix)
+			splitter.Split()
+
+			for _, expectedOutputName := range test.expectedOutputNames {
+				expectedOutputFilePath := outputTestFileDir + expectedOutputName
+				outputFilePath := wd + expectedOutputName
+				defer os.Remove(outputFilePath)
+
+				ok, err := compareFileHashes(expectedOutputFilePath, outputFilePath)
+				if err != nil {
+					t.Errorf("Unexpected error: %s", err)
+					return
+				}
+				if !ok {
+					t.Errorf("The result of comparing %v and %v is not as expected.", expectedOutputFilePath, outputFilePath)
+					return
+				}
+			}
+		})
+	}
+}
+
+// dataWithEOFReader は最後のデータと io.EOF を同時に返す
+type dataWithEOFReader struct {
+	data []byte
+}
+
+func (r *dataWithEOFReader) Read(p []byte) (int, error) {
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	if len(r.data) == 0 {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func TestSplitByByteDataWithEOF(t *testing.T) {
+	outputDir := t.TempDir() + "/"
+	reader := &dataWithEOFReader{data: []byte("0123456789abc")}
+
+	splitter := NewSplitter(ByBytes, 5, reader, outputDir+"x")
+	if err := splitter.Split(); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	expected := map[string]string{"xaa": "01234", "xab": "56789", "xac": "abc"}
+	for name, content := range expected {
+		data, err := os.ReadFile(outputDir + name)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if string(data) != content {
+			t.Errorf("Unexpected content of %s: got %q, expected %q", name, data, content)
+		}
+	}
+}
+
+func TestSplitByLineNoTrailingEmptyFile(t *testing.T) {
+	outputDir := t.TempDir() + "/"
+	reader := strings.NewReader("line1\nline2\nline3\nline4\n")
+
+	splitter := NewSplitter(ByLines, 2, reader, outputDir+"x")
+	if err := splitter.Split(); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	entries, err := os.ReadDir(outputDir)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("Unexpected number of output files: got %d, expected 2", len(entries))
+	}
+	if _, err := os.Stat(outputDir + "xac"); !os.IsNotExist(err) {
+		t.Errorf("xac should not exist")
+	}
+}
+
+func TestSplitterSplitZeroCount(t *testing.T) {
+	for _, splitType := range []SplitType{ByBytes, ByLines, ByFiles} {
+		t.Run(fmt.Sprintf("splitType: %v", splitType), func(t *testing.T) {
+			splitter := NewSplitter(splitType, 0, strings.NewReader("data\n"), t.TempDir()+"/x")
+			err := splitter.Split()
+			if err == nil || err.Error() != string(InvalidSplitSize) {
+				t.Errorf("Unexpected error: got %v, expected %s", err, InvalidSplitSize)
+			}
+		})
+	}
+}
+
+func TestSplitterSplitBySizesWithoutSizes(t *testing.T) {
+	splitter := NewSplitter(BySizes, 1, strings.NewReader("data\n"), t.TempDir()+"/x")
+	if err := splitter.Split(); !errors.Is(err, InvalidSplitSize) {
+		t.Errorf("Unexpected error: got %v, expected %s", err, InvalidSplitSize)
+	}
+}
+
+func TestSplitByLineFile(t *testing.T) {
+	input, err := os.ReadFile("testfiles/input/sample.txt")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	for _, count := range []uint64{1, 2, 3, 4, 10} {
+		t.Run(fmt.Sprintf("count: %d", count), func(t *testing.T) {
+			outputDir := t.TempDir() + "/"
+			splitter := NewSplitter(ByLineFiles, count, bytes.NewReader(input), outputDir+"x")
+			if err := splitter.Split(); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+
+			joined := []byte{}
+			for i := uint64(0); i < count; i++ {
+				name, _ := GenFileName(outputDir+"x", i, count, ByLineFiles, SuffixOptions{})
+				data, err := os.ReadFile(name)
+				if err != nil {
+					t.Fatalf("Unexpected error: %s", err)
+				}
+				joined = append(joined, data...)
+				// 入力の最後の行以外は改行で終わっている必要がある
+				if len(data) > 0 && data[len(data)-1] != '\n' && len(joined) != len(input) {
+					t.Errorf("%s ends in the middle of a line", name)
+				}
+			}
+			if !bytes.Equal(joined, input) {
+				t.Errorf("Concatenated output does not match the input")
+			}
+		})
+	}
+}
+
+func TestSplitByRoundRobin(t *testing.T) {
+	tests := []struct {
+		name      string
+		count     uint64
+		lineCount int
+	}{
+		{"more lines than files", 4, 10},
+		{"fewer lines than files", 10, 3},
+		{"more files than the open file limit", maxOpenRoundRobinFiles + 10, (maxOpenRoundRobinFiles + 10) * 2},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			lines := make([]string, test.lineCount)
+			for i := range lines {
+				lines[i] = fmt.Sprintf("line%d\n", i)
+			}
+
+			outputDir := t.TempDir() + "/"
+			splitter := NewSplitter(ByRoundRobin, test.count, strings.NewReader(strings.Join(lines, "")), outputDir+"x")
+			if err := splitter.Split(); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+
+			for i := uint64(0); i < test.count; i++ {
+				expected := ""
+				for j := int(i); j < test.lineCount; j += int(test.count) {
+					expected += lines[j]
+				}
+				name, _ := GenFileName(outputDir+"x", i, test.count, ByRoundRobin, SuffixOptions{})
+				data, err := os.ReadFile(name)
+				if err != nil {
+					t.Fatalf("Unexpected error: %s", err)
+				}
+				if string(data) != expected {
+					t.Errorf("Unexpected content of %s: got %q, expected %q", name, data, expected)
+				}
+			}
+		})
+	}
+}
+
+func TestSplitByLineByte(t *testing.T) {
+	tests := []struct {
+		name          string
+		count         uint64
+		input         string
+		expectedFiles []string
+	}{
+		{"lines fit exactly", 6, "ab\ncd\nef\ngh\n", []string{"ab\ncd\n", "ef\ngh\n"}},
+		{"line does not fit", 5, "ab\ncd\nef\n", []string{"ab\n", "cd\n", "ef\n"}},
+		{"oversized single line", 4, "ab\nabcdefgh\ncd\n", []string{"ab\n", "abcdefgh\n", "cd\n"}},
+		{"no trailing newline", 8, "abc\ndef\nghi", []string{"abc\ndef\n", "ghi"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			outputDir := t.TempDir() + "/"
+			splitter := NewSplitter(ByLineBytes, test.count, strings.NewReader(test.input), outputDir+"x")
+			if err := splitter.Split(); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+
+			entries, err := os.ReadDir(outputDir)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if len(entries) != len(test.expectedFiles) {
+				t.Fatalf("Unexpected number of output files: got %d, expected %d", len(entries), len(test.expectedFiles))
+			}
+			for i, expected := range test.expectedFiles {
+				name, _ := GenFileName(outputDir+"x", uint64(i), 0, ByLineBytes, SuffixOptions{})
+				data, err := os.ReadFile(name)
+				if err != nil {
+					t.Fatalf("Unexpected error: %s", err)
+				}
+				if string(data) != expected {
+					t.Errorf("Unexpected content of %s: got %q, expected %q", name, data, expected)
+				}
+			}
+		})
+	}
+}
+
+// nonSeekableReader はシーク可能な入力を通常の io.Reader として扱わせる
+type nonSeekableReader struct {
+	io.Reader
+}
+
+func TestSplitByFileSeekableMatchesBuffered(t *testing.T) {
+	input, err := os.ReadFile("testfiles/input/sample.txt")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	for _, count := range []uint64{1, 3, 7, 500} {
+		t.Run(fmt.Sprintf("count: %d", count), func(t *testing.T) {
+			inputFile, err := os.Open("testfiles/input/sample.txt")
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			defer inputFile.Close()
+
+			seekableDir := t.TempDir() + "/"
+			if err := NewSplitter(ByFiles, count, inputFile, seekableDir+"x").Split(); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			bufferedDir := t.TempDir() + "/"
+			if err := NewSplitter(ByFiles, count, nonSeekableReader{bytes.NewReader(input)}, bufferedDir+"x").Split(); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+
+			for i := uint64(0); i < count; i++ {
+				name, _ := GenFileName("x", i, count, ByFiles, SuffixOptions{})
+				ok, err := compareFileHashes(seekableDir+name, bufferedDir+name)
+				if err != nil {
+					t.Fatalf("Unexpected error: %s", err)
+				}
+				if !ok {
+					t.Errorf("%s differs between the seekable and buffered paths", name)
+				}
+			}
+		})
+	}
+}
+
+func TestSplitByByteFile(t *testing.T) {
+	inputFilePath := t.TempDir() + "/input"
+	if err := os.WriteFile(inputFilePath, bytes.Repeat([]byte("0123456789abcdef\n"), 1000), 0644); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	// ファイルからの複写と通常の読み込みで同じ結果になる
+	for _, count := range []uint64{1000, 1700, 17000, 20000} {
+		for _, gzip := range []bool{false, true} {
+			t.Run(fmt.Sprintf("count: %d gzip: %v", count, gzip), func(t *testing.T) {
+				fileDir := t.TempDir() + "/"
+				genericDir := t.TempDir() + "/"
+				for _, dir := range []string{fileDir, genericDir} {
+					inputFile, err := os.Open(inputFilePath)
+					if err != nil {
+						t.Fatalf("Unexpected error: %s", err)
+					}
+					defer inputFile.Close()
+
+					var reader io.Reader = inputFile
+					if dir == genericDir {
+						reader = nonSeekableReader{inputFile}
+					}
+					splitter := NewSplitter(ByBytes, count, reader, dir+"x")
+					splitter.Gzip = gzip
+					if err := splitter.Split(); err != nil {
+						t.Fatalf("Unexpected error: %s", err)
+					}
+				}
+
+				fileEntries, err := os.ReadDir(fileDir)
+				if err != nil {
+					t.Fatalf("Unexpected error: %s", err)
+				}
+				genericEntries, err := os.ReadDir(genericDir)
+				if err != nil {
+					t.Fatalf("Unexpected error: %s", err)
+				}
+				if len(fileEntries) != len(genericEntries) {
+					t.Fatalf("Unexpected number of files: got %d, expected %d", len(fileEntries), len(genericEntries))
+				}
+				for _, entry := range genericEntries {
+					ok, err := compareFileHashes(fileDir+entry.Name(), genericDir+entry.Name())
+					if err != nil {
+						t.Fatalf("Unexpected error: %s", err)
+					}
+					if !ok {
+						t.Errorf("%s differs between the file and generic paths", entry.Name())
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestSplitByByteFileProgress(t *testing.T) {
+	inputFilePath := t.TempDir() + "/input"
+	if err := os.WriteFile(inputFilePath, []byte("1\n2\n3\n4\n5\n"), 0644); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	inputFile, err := os.Open(inputFilePath)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	defer inputFile.Close()
+
+	// ファイルからの複写でも Progress に書き込んだバイト数を渡す
+	splitter := NewSplitter(ByBytes, 4, inputFile, t.TempDir()+"/x")
+	got := []string{}
+	splitter.Progress = func(bytesWritten uint64, filesCreated uint64) {
+		got = append(got, fmt.Sprintf("%d/%d", bytesWritten, filesCreated))
+	}
+	if err := splitter.Split(); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if expected := "4/1 8/2 10/3"; strings.Join(got, " ") != expected {
+		t.Errorf("Unexpected progress: got %v, expected %s", got, expected)
+	}
+}
+
+func BenchmarkSplitByByte(b *testing.B) {
+	inputFilePath := b.TempDir() + "/input"
+	if err := os.WriteFile(inputFilePath, bytes.Repeat([]byte("0123456789abcdef\n"), 1<<19), 0644); err != nil {
+		b.Fatalf("Unexpected error: %s", err)
+	}
+
+	benchmarks := []struct {
+		name string
+		wrap func(*os.File) io.Reader
+	}{
+		{"file", func(f *os.File) io.Reader { return f }},
+		{"generic", func(f *os.File) io.Reader { return nonSeekableReader{f} }},
+	}
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			outputDir := b.TempDir() + "/"
+			for i := 0; i < b.N; i++ {
+				inputFile, err := os.Open(inputFilePath)
+				if err != nil {
+					b.Fatalf("Unexpected error: %s", err)
+				}
+				if err := NewSplitter(ByBytes, 1<<20, bm.wrap(inputFile), outputDir+"x").Split(); err != nil {
+					b.Fatalf("Unexpected error: %s", err)
+				}
+				inputFile.Close()
+			}
+		})
+	}
+}
+
+func BenchmarkSplitByFile(b *testing.B) {
+	inputFilePath := b.TempDir() + "/input"
+	if err := os.WriteFile(inputFilePath, bytes.Repeat([]byte("0123456789abcdef\n"), 1<<19), 0644); err != nil {
+		b.Fatalf("Unexpected error: %s", err)
+	}
+
+	benchmarks := []struct {
+		name string
+		wrap func(*os.File) io.Reader
+	}{
+		{"seekable", func(f *os.File) io.Reader { return f }},
+		{"buffered", func(f *os.File) io.Reader { return nonSeekableReader{f} }},
+	}
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			outputDir := b.TempDir() + "/"
+			for i := 0; i < b.N; i++ {
+				inputFile, err := os.Open(inputFilePath)
+				if err != nil {
+					b.Fatalf("Unexpected error: %s", err)
+				}
+				if err := NewSplitter(ByFiles, 4, bm.wrap(inputFile), outputDir+"x").Split(); err != nil {
+					b.Fatalf("Unexpected error: %s", err)
+				}
+				inputFile.Close()
+			}
+		})
+	}
+}
+
+// memoryOutput は WriterFactory の出力をメモリ上に保持する
+type memoryOutput struct {
+	names   []string
+	buffers map[string]*bytes.Buffer
+}
+
+type memoryWriteCloser struct {
+	*bytes.Buffer
+}
+
+func (memoryWriteCloser) Close() error {
+	return nil
+}
+
+func (m *memoryOutput) create(name string) (io.WriteCloser, error) {
+	if m.buffers == nil {
+		m.buffers = map[string]*bytes.Buffer{}
+	}
+	buffer := new(bytes.Buffer)
+	m.names = append(m.names, name)
+	m.buffers[name] = buffer
+	return memoryWriteCloser{buffer}, nil
+}
+
+func TestSplitterWriterFactory(t *testing.T) {
+	tests := []struct {
+		splitType SplitType
+		count     uint64
+		expected  []string
+	}{
+		{ByBytes, 4, []string{"1\n2\n", "3\n4\n", "5\n"}},
+		{ByLines, 3, []string{"1\n2\n3\n", "4\n5\n"}},
+		{ByFiles, 2, []string{"1\n2\n3", "\n4\n5\n"}},
+		{ByLineFiles, 2, []string{"1\n2\n3\n", "4\n5\n"}},
+		{ByRoundRobin, 2, []string{"1\n3\n5\n", "2\n4\n"}},
+		{ByLineBytes, 5, []string{"1\n2\n", "3\n4\n", "5\n"}},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("splitType: %v", test.splitType), func(t *testing.T) {
+			output := &memoryOutput{}
+			splitter := NewSplitter(test.splitType, test.count, strings.NewReader("1\n2\n3\n4\n5\n"), "mem-")
+			splitter.WriterFactory = output.create
+			if err := splitter.Split(); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+
+			if len(output.names) != len(test.expected) {
+				t.Fatalf("Unexpected number of outputs: got %v, expected %d", output.names, len(test.expected))
+			}
+			for i, expected := range test.expected {
+				name, _ := GenFileName("mem-", uint64(i), test.count, test.splitType, SuffixOptions{})
+				if output.names[i] != name {
+					t.Errorf("Unexpected output name: got %s, expected %s", output.names[i], name)
+				}
+				if got := output.buffers[name].String(); got != expected {
+					t.Errorf("Unexpected content of %s: got %q, expected %q", name, got, expected)
+				}
+			}
+		})
+	}
+}
+
+// shortWriter は書き込みを要求された半分だけ書き込み、エラーを返さない
+type shortWriter struct{}
+
+func (shortWriter) Write(p []byte) (int, error) {
+	return len(p) / 2, nil
+}
+
+func (shortWriter) Close() error {
+	return nil
+}
+
+func TestSplitterShortWrite(t *testing.T) {
+	for _, splitType := range []SplitType{ByBytes, ByLines, ByFiles, ByLineFiles, ByRoundRobin, ByLineBytes} {
+		for _, unbuffered := range []bool{false, true} {
+			t.Run(fmt.Sprintf("splitType: %v unbuffered: %v", splitType, unbuffered), func(t *testing.T) {
+				splitter := NewSplitter(splitType, 2, strings.NewReader("1\n2\n3\n4\n5\n"), "short-")
+				splitter.WriterFactory = func(name string) (io.WriteCloser, error) {
+					return shortWriter{}, nil
+				}
+				splitter.Unbuffered = unbuffered
+				if err := splitter.Split(); !errors.Is(err, io.ErrShortWrite) {
+					t.Errorf("Unexpected error: got %v, expected %v", err, io.ErrShortWrite)
+				}
+			})
+		}
+	}
+}
+
+// cancelingReader は1回の Read で1行ずつ返し、cancelAfter 行を返した時点で cancel を呼ぶ
+type cancelingReader struct {
+	lines       []string
+	cancelAfter int
+	cancel      context.CancelFunc
+	read        int
+}
+
+func (r *cancelingReader) Read(p []byte) (int, error) {
+	if r.read >= len(r.lines) {
+		return 0, io.EOF
+	}
+	n := copy(p, r.lines[r.read])
+	r.read++
+	if r.read == r.cancelAfter {
+		r.cancel()
+	}
+	return n, nil
+}
+
+func TestSplitterSplitContextCanceled(t *testing.T) {
+	tests := []struct {
+		splitType     SplitType
+		count         uint64
+		expectedFiles []string
+	}{
+		{ByBytes, 4, []string{"xaa", "xab"}},
+		{ByLines, 2, []string{"xaa"}},
+		{ByLineBytes, 4, []string{"xaa"}},
+		{ByRoundRobin, 2, []string{}},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("splitType: %v", test.splitType), func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			reader := &cancelingReader{
+				lines:       []string{"1\n", "2\n", "3\n", "4\n", "5\n", "6\n"},
+				cancelAfter: 3,
+				cancel:      cancel,
+			}
+
+			outputDir := t.TempDir() + "/"
+			splitter := NewSplitter(test.splitType, test.count, reader, outputDir+"x")
+			if err := splitter.SplitContext(ctx); !errors.Is(err, context.Canceled) {
+				t.Fatalf("Unexpected error: got %v, expected %v", err, context.Canceled)
+			}
+
+			entries, err := os.ReadDir(outputDir)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			names := []string{}
+			for _, entry := range entries {
+				names = append(names, entry.Name())
+			}
+			if strings.Join(names, ",") != strings.Join(test.expectedFiles, ",") {
+				t.Errorf("Unexpected output files: got %v, expected %v", names, test.expectedFiles)
+			}
+		})
+	}
+}
+
+func TestSplitterGzip(t *testing.T) {
+	input, err := os.ReadFile("testfiles/input/sample.txt")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	tests := []struct {
+		splitType SplitType
+		count     uint64
+	}{
+		{ByBytes, 50},
+		{ByLines, 2},
+		{ByFiles, 3},
+		{ByRoundRobin, maxOpenRoundRobinFiles + 1},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("splitType: %v", test.splitType), func(t *testing.T) {
+			outputDir := t.TempDir() + "/"
+			splitter := NewSplitter(test.splitType, test.count, bytes.NewReader(input), outputDir+"x")
+			splitter.Gzip = true
+			if err := splitter.Split(); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+
+			entries, err := os.ReadDir(outputDir)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			decompressed := []string{}
+			for _, entry := range entries {
+				if !strings.HasSuffix(entry.Name(), ".gz") {
+					t.Errorf("%s does not have the .gz suffix", entry.Name())
+				}
+				compressed, err := os.Open(outputDir + entry.Name())
+				if err != nil {
+					t.Fatalf("Unexpected error: %s", err)
+				}
+				gzipReader, err := gzip.NewReader(compressed)
+				if err != nil {
+					t.Fatalf("Unexpected error: %s", err)
+				}
+				data, err := io.ReadAll(gzipReader)
+				if err != nil {
+					t.Fatalf("Unexpected error: %s", err)
+				}
+				compressed.Close()
+				decompressed = append(decompressed, string(data))
+			}
+
+			// ラウンドロビンの場合は行が各ファイルに振り分けられるため、行の集合で比較する
+			if test.splitType == ByRoundRobin {
+				got := strings.Split(strings.Join(decompressed, ""), "\n")
+				expected := strings.Split(string(input), "\n")
+				sort.Strings(got)
+				sort.Strings(expected)
+				if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+					t.Errorf("Decompressed output does not match the input")
+				}
+				return
+			}
+			if strings.Join(decompressed, "") != string(input) {
+				t.Errorf("Decompressed output does not match the input")
+			}
+		})
+	}
+}
+
+func TestSplitterVerbose(t *testing.T) {
+	outputDir := t.TempDir() + "/"
+	verbose := new(bytes.Buffer)
+	splitter := NewSplitter(ByFiles, 3, strings.NewReader("abcdef"), outputDir+"x")
+	splitter.Verbose = verbose
+	if err := splitter.Split(); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	expected := fmt.Sprintf("creating file '%[1]sxaa'\ncreating file '%[1]sxab'\ncreating file '%[1]sxac'\n", outputDir)
+	if verbose.String() != expected {
+		t.Errorf("Unexpected verbose output: got %q, expected %q", verbose.String(), expected)
+	}
+}
+
+func TestSplitterFormatLikePrefix(t *testing.T) {
+	// 接頭辞は書式として解釈せず、そのままファイル名や出力に含める
+	for _, prefix := range []string{"100%-", "%d%s%v-", "%!%%"} {
+		t.Run(prefix, func(t *testing.T) {
+			outputDir := t.TempDir() + "/"
+			verbose := new(bytes.Buffer)
+			splitter := NewSplitter(ByLines, 1, strings.NewReader("1\n2\n"), outputDir+prefix)
+			splitter.Verbose = verbose
+			splitter.Checksum = ChecksumMD5
+			if err := splitter.Split(); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+
+			expectedNames := []string{outputDir + prefix + "aa", outputDir + prefix + "ab"}
+			if created := splitter.Created(); !reflect.DeepEqual(created, expectedNames) {
+				t.Errorf("Unexpected created files: got %q, expected %q", created, expectedNames)
+			}
+			expected := "creating file '" + expectedNames[0] + "'\ncreating file '" + expectedNames[1] + "'\n"
+			if verbose.String() != expected {
+				t.Errorf("Unexpected verbose output: got %q, expected %q", verbose.String(), expected)
+			}
+			manifest, err := os.ReadFile(outputDir + prefix + "." + ChecksumMD5)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if !strings.Contains(string(manifest), "  "+prefix+"aa\n") || !strings.Contains(string(manifest), "  "+prefix+"ab\n") {
+				t.Errorf("Unexpected checksum manifest: %q", manifest)
+			}
+
+			// 時刻による名前でも同じく接頭辞をそのまま用いる
+			name, err := TimestampNamer("20060102")(prefix, 3, time.Date(2026, 10, 14, 0, 0, 0, 0, time.UTC))
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if name != prefix+"20261014-3" {
+				t.Errorf("Unexpected timestamp name: got %q, expected %q", name, prefix+"20261014-3")
+			}
+		})
+	}
+}
+
+func TestSplitterElideEmptyFiles(t *testing.T) {
+	tests := []struct {
+		splitType     SplitType
+		count         uint64
+		input         string
+		expectedFiles map[string]string
+	}{
+		{ByFiles, 10, "abc", map[string]string{"xaa": "a", "xab": "b", "xac": "c"}},
+		{ByLineFiles, 3, "abcdef\ng\n", map[string]string{"xaa": "abcdef\n", "xac": "g\n"}},
+		{ByRoundRobin, 10, "1\n2\n3\n", map[string]string{"xaa": "1\n", "xab": "2\n", "xac": "3\n"}},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("splitType: %v", test.splitType), func(t *testing.T) {
+			outputDir := t.TempDir() + "/"
+			splitter := NewSplitter(test.splitType, test.count, strings.NewReader(test.input), outputDir+"x")
+			splitter.ElideEmptyFiles = true
+			if err := splitter.Split(); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+
+			entries, err := os.ReadDir(outputDir)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if len(entries) != len(test.expectedFiles) {
+				t.Errorf("Unexpected number of output files: got %d, expected %d", len(entries), len(test.expectedFiles))
+			}
+			for name, expected := range test.expectedFiles {
+				data, err := os.ReadFile(outputDir + name)
+				if err != nil {
+					t.Fatalf("Unexpected error: %s", err)
+				}
+				if string(data) != expected {
+					t.Errorf("Unexpected content of %s: got %q, expected %q", name, data, expected)
+				}
+			}
+		})
+	}
+}
+
+func TestSplitterEmptyInput(t *testing.T) {
+	tests := []struct {
+		name     string
+		splitter func(reader io.Reader) *Splitter
+		elide    bool
+		expected []string
+	}{
+		{"bytes", func(r io.Reader) *Splitter { return NewSplitter(ByBytes, 3, r, "") }, false, []string{}},
+		{"lines", func(r io.Reader) *Splitter { return NewSplitter(ByLines, 3, r, "") }, false, []string{}},
+		{"line bytes", func(r io.Reader) *Splitter { return NewSplitter(ByLineBytes, 3, r, "") }, false, []string{}},
+		{"sizes", func(r io.Reader) *Splitter { return NewSizesSplitter([]uint64{3}, r, "") }, false, []string{}},
+		{"pattern", func(r io.Reader) *Splitter { return NewPatternSplitter(regexp.MustCompile("^#"), r, "") }, false, []string{}},
+		{"files", func(r io.Reader) *Splitter { return NewSplitter(ByFiles, 3, r, "") }, false, []string{"xaa", "xab", "xac"}},
+		{"line files", func(r io.Reader) *Splitter { return NewSplitter(ByLineFiles, 3, r, "") }, false, []string{"xaa", "xab", "xac"}},
+		{"round robin", func(r io.Reader) *Splitter { return NewSplitter(ByRoundRobin, 3, r, "") }, false, []string{"xaa", "xab", "xac"}},
+		{"balanced lines", func(r io.Reader) *Splitter { return NewSplitter(ByBalancedLines, 3, r, "") }, false, []string{"xaa", "xab", "xac"}},
+		{"files with elide", func(r io.Reader) *Splitter { return NewSplitter(ByFiles, 3, r, "") }, true, []string{}},
+		{"round robin with elide", func(r io.Reader) *Splitter { return NewSplitter(ByRoundRobin, 3, r, "") }, true, []string{}},
+	}
+
+	for _, test := range tests {
+		for _, seekable := range []bool{true, false} {
+			t.Run(fmt.Sprintf("%s seekable: %v", test.name, seekable), func(t *testing.T) {
+				var reader io.Reader = strings.NewReader("")
+				if !seekable {
+					reader = nonSeekableReader{reader}
+				}
+				output := &memoryOutput{}
+				splitter := test.splitter(reader)
+				splitter.ElideEmptyFiles = test.elide
+				splitter.WriterFactory = output.create
+				if err := splitter.Split(); err != nil {
+					t.Fatalf("Unexpected error: %s", err)
+				}
+
+				if strings.Join(output.names, ",") != strings.Join(test.expected, ",") {
+					t.Errorf("Unexpected output files: got %v, expected %v", output.names, test.expected)
+				}
+				for _, name := range output.names {
+					if output.buffers[name].Len() != 0 {
+						t.Errorf("%s is not empty", name)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestSplitterMaxFiles(t *testing.T) {
+	tests := []struct {
+		name     string
+		splitter func(reader io.Reader) *Splitter
+		valid    bool
+		expected []string
+	}{
+		{"bytes", func(r io.Reader) *Splitter { return NewSplitter(ByBytes, 1, r, "") }, false, []string{"xaa", "xab", "xac"}},
+		{"lines", func(r io.Reader) *Splitter { return NewSplitter(ByLines, 1, r, "") }, false, []string{"xaa", "xab", "xac"}},
+		{"sizes", func(r io.Reader) *Splitter { return NewSizesSplitter([]uint64{2}, r, "") }, false, []string{"xaa", "xab", "xac"}},
+		{"lines within the limit", func(r io.Reader) *Splitter { return NewSplitter(ByLines, 2, r, "") }, true, []string{"xaa", "xab", "xac"}},
+		// ファイル数の決まる分割方法は1つも作成せずにエラーとする
+		{"files", func(r io.Reader) *Splitter { return NewSplitter(ByFiles, 4, r, "") }, false, []string{}},
+		{"round robin", func(r io.Reader) *Splitter { return NewSplitter(ByRoundRobin, 4, r, "") }, false, []string{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output := &memoryOutput{}
+			splitter := test.splitter(strings.NewReader("1\n2\n3\n4\n5\n"))
+			splitter.MaxFiles = 3
+			splitter.WriterFactory = output.create
+			err := splitter.Split()
+			if err != nil && test.valid {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if err == nil && !test.valid {
+				t.Fatalf("Unexpected result: error is nil")
+			}
+			if !test.valid && !errors.Is(err, TooManyOutputFiles) {
+				t.Errorf("Unexpected error: got %v, expected %s", err, TooManyOutputFiles)
+			}
+
+			if strings.Join(output.names, ",") != strings.Join(test.expected, ",") {
+				t.Errorf("Unexpected output files: got %v, expected %v", output.names, test.expected)
+			}
+		})
+	}
+
+	// 上限に達するまでに作成したファイルは通常のエラーと同じく削除する
+	outputDir := t.TempDir() + "/"
+	splitter := NewSplitter(ByBytes, 1, strings.NewReader("12345"), outputDir+"x")
+	splitter.MaxFiles = 2
+	if err := splitter.Split(); !errors.Is(err, TooManyOutputFiles) {
+		t.Errorf("Unexpected error: got %v, expected %s", err, TooManyOutputFiles)
+	}
+	entries, err := os.ReadDir(outputDir)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("Output files were left: %v", entries)
+	}
+}
+
+func TestSplitterCreateRetries(t *testing.T) {
+	defer func(delay time.Duration) { createRetryDelay = delay }(createRetryDelay)
+	createRetryDelay = 0
+
+	tests := []struct {
+		name     string
+		err      error
+		failures int
+		retries  int
+		valid    bool
+		attempts int
+	}{
+		{"temporary error", syscall.EINTR, 1, 2, true, 2},
+		{"temporary error until the last retry", syscall.EAGAIN, 2, 2, true, 3},
+		{"too many temporary errors", syscall.EAGAIN, 3, 2, false, 3},
+		{"without retries", syscall.EINTR, 1, 0, false, 1},
+		{"no space", syscall.ENOSPC, 1, 2, false, 1},
+		{"permission denied", syscall.EACCES, 1, 2, false, 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output := &memoryOutput{}
+			attempts := 0
+			failures := map[string]int{}
+			splitter := NewSplitter(ByLines, 1, strings.NewReader("1\n2\n"), "")
+			splitter.CreateRetries = test.retries
+			splitter.WriterFactory = func(name string) (io.WriteCloser, error) {
+				attempts++
+				// 最初のファイルだけが一時的に作成できない
+				if name == "xaa" && failures[name] < test.failures {
+					failures[name]++
+					return nil, &fs.PathError{Op: "open", Path: name, Err: test.err}
+				}
+				return output.create(name)
+			}
+			err := splitter.Split()
+			if err != nil && test.valid {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if err == nil && !test.valid {
+				t.Fatalf("Unexpected result: error is nil")
+			}
+			if !test.valid && !errors.Is(err, test.err) {
+				t.Errorf("Unexpected error: got %v, expected %s", err, test.err)
+			}
+			if test.valid {
+				if strings.Join(output.names, ",") != "xaa,xab" {
+					t.Errorf("Unexpected output files: %v", output.names)
+				}
+				// 2つ目のファイルの作成を含める
+				attempts--
+			}
+			if attempts != test.attempts {
+				t.Errorf("Unexpected number of attempts: got %d, expected %d", attempts, test.attempts)
+			}
+		})
+	}
+}
+
+func TestSplitByLineEnsureTrailingNewline(t *testing.T) {
+	tests := []struct {
+		input      string
+		count      uint64
+		separator  byte
+		lineEnding LineEnding
+		ensure     bool
+		expected   []string
+	}{
+		{"1\n2\n3", 2, '\n', LineEndingLF, false, []string{"1\n2\n", "3"}},
+		{"1\n2\n3", 2, '\n', LineEndingLF, true, []string{"1\n2\n", "3\n"}},
+		{"1\n2\n3\n", 2, '\n', LineEndingLF, true, []string{"1\n2\n", "3\n"}},
+		{"1\n2", 2, '\n', LineEndingLF, true, []string{"1\n2\n"}},
+		{"1\x002", 1, 0, LineEndingLF, true, []string{"1\x00", "2\x00"}},
+		{"1\r\n2", 1, '\n', LineEndingCRLF, true, []string{"1\r\n", "2\r\n"}},
+		{"", 1, '\n', LineEndingLF, true, []string{}},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%q ensure: %v", test.input, test.ensure), func(t *testing.T) {
+			output := &memoryOutput{}
+			splitter := NewSplitter(ByLines, test.count, strings.NewReader(test.input), "")
+			splitter.Separator = test.separator
+			splitter.LineEnding = test.lineEnding
+			splitter.EnsureTrailingNewline = test.ensure
+			splitter.WriterFactory = output.create
+			if err := splitter.Split(); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+
+			if len(output.names) != len(test.expected) {
+				t.Fatalf("Unexpected number of files: got %v, expected %d", output.names, len(test.expected))
+			}
+			for i, expected := range test.expected {
+				if got := output.buffers[output.names[i]].String(); got != expected {
+					t.Errorf("Unexpected content of %s: got %q, expected %q", output.names[i], got, expected)
+				}
+			}
+		})
+	}
+}
+
+func TestSplitterFilter(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available")
+	}
+
+	outputDir := t.TempDir() + "/"
+	splitter := NewSplitter(ByLines, 2, strings.NewReader("1\n2\n3\n"), outputDir+"x")
+	splitter.Filter = `tr 0-9 a-j > "$FILE.txt"`
+	if err := splitter.Split(); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	expected := map[string]string{"xaa.txt": "b\nc\n", "xab.txt": "d\n"}
+	entries, err := os.ReadDir(outputDir)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if len(entries) != len(expected) {
+		t.Errorf("Unexpected number of output files: got %d, expected %d", len(entries), len(expected))
+	}
+	for name, content := range expected {
+		data, err := os.ReadFile(outputDir + name)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if string(data) != content {
+			t.Errorf("Unexpected content of %s: got %q, expected %q", name, data, content)
+		}
+	}
+
+	// コマンドの標準出力と標準エラー出力は FilterStdout と FilterStderr に書き込む
+	stdout := new(bytes.Buffer)
+	stderr := new(bytes.Buffer)
+	splitter = NewSplitter(ByLines, 2, strings.NewReader("1\n2\n3\n"), outputDir+"z")
+	splitter.Filter = `cat; echo "$FILE" >&2`
+	splitter.FilterStdout = stdout
+	splitter.FilterStderr = stderr
+	if err := splitter.Split(); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if expected := "1\n2\n3\n"; stdout.String() != expected {
+		t.Errorf("Unexpected filter output: got %q, expected %q", stdout.String(), expected)
+	}
+	if expected := outputDir + "zaa\n" + outputDir + "zab\n"; stderr.String() != expected {
+		t.Errorf("Unexpected filter error output: got %q, expected %q", stderr.String(), expected)
+	}
+
+	splitter = NewSplitter(ByLines, 2, strings.NewReader("1\n2\n3\n"), outputDir+"y")
+	splitter.Filter = "cat > /dev/null; exit 3"
+	if err := splitter.Split(); err == nil || !strings.HasPrefix(err.Error(), string(FilterFailed)) {
+		t.Errorf("Unexpected error: got %v, expected %s", err, FilterFailed)
+	}
+}
+
+func TestSplitterSeparator(t *testing.T) {
+	tests := []struct {
+		splitType SplitType
+		count     uint64
+		expected  []string
+	}{
+		{ByLines, 2, []string{"1\x002\x00", "3\x00"}},
+		{ByLineBytes, 4, []string{"1\x002\x00", "3\x00"}},
+		{ByRoundRobin, 2, []string{"1\x003\x00", "2\x00"}},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("splitType: %v", test.splitType), func(t *testing.T) {
+			output := &memoryOutput{}
+			splitter := NewSplitter(test.splitType, test.count, strings.NewReader("1\x002\x003\x00"), "x")
+			splitter.WriterFactory = output.create
+			splitter.Separator = 0
+			if err := splitter.Split(); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+
+			if len(output.names) != len(test.expected) {
+				t.Fatalf("Unexpected number of outputs: got %v, expected %d", output.names, len(test.expected))
+			}
+			for i, expected := range test.expected {
+				if got := output.buffers[output.names[i]].String(); got != expected {
+					t.Errorf("Unexpected content of %s: got %q, expected %q", output.names[i], got, expected)
+				}
+			}
+		})
+	}
+}
+
+func TestSplitterMultiByteSeparator(t *testing.T) {
+	// bufio の既定のバッファ (4096バイト) の境界をまたぐ区切り
+	long := strings.Repeat("a", 4094)
+	tests := []struct {
+		name         string
+		separator    string
+		splitType    SplitType
+		count        uint64
+		maxLineBytes uint64
+		input        string
+		expected     []string
+	}{
+		{"two bytes", "\r\n", ByLines, 2, 0, "1\r\n2\n\r\n3\r\n", []string{"1\r\n2\n\r\n", "3\r\n"}},
+		{"four bytes", "\r\n\r\n", ByLines, 1, 0, "a\r\nb\r\n\r\nc\r\n\r\nd", []string{"a\r\nb\r\n\r\n", "c\r\n\r\n", "d"}},
+		{"overlapping", "abab", ByLines, 1, 0, "xabababy", []string{"xabab", "aby"}},
+		{"straddling buffer", "\r\n\r\n", ByLines, 1, 0, long + "\r\n\r\nb", []string{long + "\r\n\r\n", "b"}},
+		{"straddling buffer with max line bytes", "\r\n\r\n", ByLines, 1, 1 << 20, long + "\r\n\r\nb", []string{long + "\r\n\r\n", "b"}},
+		// MaxLineBytes で打ち切った位置をまたぐ区切りは、CRLF の場合と同じく区切りとしない
+		{"straddling max line bytes", "\r\n", ByLines, 1, 3, "ab\r\nc\r\n", []string{"ab\r", "\nc\r", "\n"}},
+		{"line bytes", "\r\n", ByLineBytes, 8, 0, "12\r\n34\r\n56\r\n", []string{"12\r\n34\r\n", "56\r\n"}},
+		{"round robin", "--", ByRoundRobin, 2, 0, "1--2--3--", []string{"1--3--", "2--"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output := &memoryOutput{}
+			splitter := NewSplitter(test.splitType, test.count, strings.NewReader(test.input), "x")
+			splitter.WriterFactory = output.create
+			splitter.MultiByteSeparator = []byte(test.separator)
+			splitter.MaxLineBytes = test.maxLineBytes
+			if err := splitter.Split(); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+
+			got := []string{}
+			for _, name := range output.names {
+				got = append(got, output.buffers[name].String())
+			}
+			if !reflect.DeepEqual(got, test.expected) {
+				t.Errorf("Unexpected outputs: got %q, expected %q", got, test.expected)
+			}
+		})
+	}
+
+	splitter := NewSplitter(ByLines, 1, strings.NewReader("a"), "x")
+	splitter.MultiByteSeparator = []byte("\r\n\r\n")
+	splitter.LineEnding = LineEndingCRLF
+	if err := splitter.Split(); !errors.Is(err, InvalidLineEnding) {
+		t.Errorf("Unexpected error: got %v, expected %s", err, InvalidLineEnding)
+	}
+}
+
+func TestSplitByFileRemainder(t *testing.T) {
+	tests := []struct {
+		input    string
+		count    uint64
+		expected []string
+	}{
+		// 割り切れない分は先頭のファイルから1バイトずつ含める
+		{"0123456789", 3, []string{"0123", "456", "789"}},
+		{"0123456789", 4, []string{"012", "345", "67", "89"}},
+		{"012345", 3, []string{"01", "23", "45"}},
+		{"01", 3, []string{"0", "1", ""}},
+	}
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%s/%d", test.input, test.count), func(t *testing.T) {
+			output := &memoryOutput{}
+			splitter := NewSplitter(ByFiles, test.count, strings.NewReader(test.input), "")
+			splitter.WriterFactory = output.create
+			if err := splitter.Split(); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if len(output.names) != len(test.expected) {
+				t.Fatalf("Unexpected number of files: got %v, expected %d", output.names, len(test.expected))
+			}
+			for i, expected := range test.expected {
+				if got := output.buffers[output.names[i]].String(); got != expected {
+					t.Errorf("Unexpected content of %s: got %q, expected %q", output.names[i], got, expected)
+				}
+			}
+		})
+	}
+}
+
+func TestSplitByFileMinChunkSize(t *testing.T) {
+	input := strings.Repeat("0123456789", 10)
+	tests := []struct {
+		count        uint64
+		minChunkSize uint64
+		expected     []int
+	}{
+		// 各ファイルが30バイト以上となる3つまで減らす
+		{50, 30, []int{34, 33, 33}},
+		{2, 30, []int{50, 50}},
+		{50, 100, []int{100}},
+		// 入力が MinChunkSize に満たない場合も1つは作成する
+		{50, 200, []int{100}},
+		{4, 0, []int{25, 25, 25, 25}},
+	}
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%d/%d", test.count, test.minChunkSize), func(t *testing.T) {
+			output := &memoryOutput{}
+			splitter := NewSplitter(ByFiles, test.count, strings.NewReader(input), "")
+			splitter.MinChunkSize = test.minChunkSize
+			splitter.WriterFactory = output.create
+			if err := splitter.Split(); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			sizes := []int{}
+			for _, name := range output.names {
+				sizes = append(sizes, output.buffers[name].Len())
+			}
+			if !reflect.DeepEqual(sizes, test.expected) {
+				t.Errorf("Unexpected sizes: got %v, expected %v", sizes, test.expected)
+			}
+
+			// K/N のチャンクも同じ境界で区切る
+			for i, size := range test.expected {
+				var chunk bytes.Buffer
+				splitter.Reset(strings.NewReader(input), "")
+				if err := splitter.WriteChunk(&chunk, uint64(i+1)); err != nil {
+					t.Fatalf("Unexpected error: %s", err)
+				}
+				if chunk.Len() != size {
+					t.Errorf("Unexpected size of chunk %d: got %d, expected %d", i+1, chunk.Len(), size)
+				}
+			}
+		})
+	}
+}
+
+func TestSplitterWriteChunk(t *testing.T) {
+	input := "0123456789"
+	for _, reader := range []func() io.Reader{
+		func() io.Reader { return strings.NewReader(input) },
+		func() io.Reader { return nonSeekableReader{strings.NewReader(input)} },
+	} {
+		for chunk, expected := range map[uint64]string{1: "0123", 2: "456", 3: "789"} {
+			output := new(bytes.Buffer)
+			splitter := NewSplitter(ByFiles, 3, reader(), "x")
+			if err := splitter.WriteChunk(output, chunk); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if output.String() != expected {
+				t.Errorf("Unexpected content of chunk %d: got %q, expected %q", chunk, output.String(), expected)
+			}
+		}
+	}
+
+	splitter := NewSplitter(ByFiles, 3, strings.NewReader(input), "x")
+	if err := splitter.WriteChunk(io.Discard, 4); err == nil {
+		t.Errorf("Expected error for an out of range chunk")
+	}
+}
+
+// recordingReader は Read が呼ばれたかどうかを記録する
+type recordingReader struct {
+	io.Reader
+	read bool
+}
+
+func (r *recordingReader) Read(p []byte) (int, error) {
+	r.read = true
+	return r.Reader.Read(p)
+}
+
+func TestSplitterUnwritableOutputDir(t *testing.T) {
+	readOnlyDir := t.TempDir() + "/readonly"
+	if err := os.Mkdir(readOnlyDir, 0555); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	defer os.Chmod(readOnlyDir, 0755)
+
+	tests := []struct {
+		name     string
+		prefix   string
+		readOnly bool
+		expected ErrorMsg
+	}{
+		{"missing directory", t.TempDir() + "/missing/x", false, OutputDirectoryNotFound},
+		{"read-only directory", readOnlyDir + "/x", true, OutputDirectoryNotWritable},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// root は権限に関係なく書き込めるため確認できない
+			if test.readOnly && os.Geteuid() == 0 {
+				t.Skip("Permissions are not enforced for root")
+			}
+
+			reader := &recordingReader{Reader: strings.NewReader("1\n2\n")}
+			splitter := NewSplitter(ByLines, 1, reader, test.prefix)
+			err := splitter.Split()
+			if !errors.Is(err, test.expected) {
+				t.Errorf("Unexpected error: got %v, expected %s", err, test.expected)
+			}
+			if reader.read {
+				t.Errorf("Input was read before the output directory was checked")
+			}
+		})
+	}
+}
+
+func TestSplitterCreateDirs(t *testing.T) {
+	outputDir := t.TempDir() + "/"
+	prefix := outputDir + "out/parts/part-"
+
+	splitter := NewSplitter(ByLines, 1, strings.NewReader("1\n2\n"), prefix)
+	err := splitter.Split()
+	// 存在しないディレクトリの名前をエラーに含める
+	if !errors.Is(err, OutputDirectoryNotFound) || !strings.Contains(err.Error(), outputDir+"out/parts") {
+		t.Errorf("Unexpected error: got %v, expected %s", err, OutputDirectoryNotFound)
+	}
+
+	splitter = NewSplitter(ByLines, 1, strings.NewReader("1\n2\n"), prefix)
+	splitter.CreateDirs = true
+	if err := splitter.Split(); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	for _, name := range []string{"part-aa", "part-ab"} {
+		if _, err := os.Stat(outputDir + "out/parts/" + name); err != nil {
+			t.Errorf("Unexpected error: %s", err)
+		}
+	}
+}
+
+func BenchmarkSplitByLine(b *testing.B) {
+	input := bytes.Repeat([]byte("short line\n"), 1<<18)
+
+	for _, unbuffered := range []bool{false, true} {
+		name := "buffered"
+		if unbuffered {
+			name = "unbuffered"
+		}
+		b.Run(name, func(b *testing.B) {
+			outputDir := b.TempDir() + "/"
+			for i := 0; i < b.N; i++ {
+				splitter := NewSplitter(ByLines, 100000, bytes.NewReader(input), outputDir+"x")
+				splitter.Unbuffered = unbuffered
+				if err := splitter.Split(); err != nil {
+					b.Fatalf("Unexpected error: %s", err)
+				}
+			}
+		})
+	}
+}
+
+func TestSplitterOutputFileNames(t *testing.T) {
+	tests := []struct {
+		valid     bool
+		splitType SplitType
+		count     uint64
+		seekable  bool
+		elide     bool
+		expected  []string
+	}{
+		{true, ByBytes, 4, true, false, []string{"xaa", "xab", "xac"}},
+		{true, ByLines, 2, true, false, []string{"xaa", "xab", "xac"}},
+		{true, ByFiles, 2, true, false, []string{"xaa", "xab"}},
+		{true, ByFiles, 12, true, true, []string{"xaa", "xab", "xac", "xad", "xae", "xaf", "xag", "xah", "xai", "xaj"}},
+		{true, ByRoundRobin, 3, false, false, []string{"xaa", "xab", "xac"}},
+		{false, ByBytes, 4, false, false, nil},
+		{false, ByLineFiles, 3, false, true, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("splitType: %v seekable: %v elide: %v", test.splitType, test.seekable, test.elide), func(t *testing.T) {
+			var reader io.Reader = strings.NewReader("1\n2\n3\n4\n5\n")
+			if !test.seekable {
+				reader = nonSeekableReader{reader}
+			}
+			splitter := NewSplitter(test.splitType, test.count, reader, "")
+			splitter.ElideEmptyFiles = test.elide
+			names, err := splitter.OutputFileNames()
+			if err != nil && test.valid {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if err == nil && !test.valid {
+				t.Fatalf("Unexpected result : error is nil, result is %v", names)
+			}
+			if strings.Join(names, " ") != strings.Join(test.expected, " ") {
+				t.Errorf("Unexpected names: got %v, expected %v", names, test.expected)
+			}
+
+			// シーク可能な入力は読み込み位置が戻り、続けて分割できる
+			if test.seekable {
+				output := &memoryOutput{}
+				splitter.WriterFactory = output.create
+				if err := splitter.Split(); err != nil {
+					t.Fatalf("Unexpected error: %s", err)
+				}
+				if strings.Join(output.names, " ") != strings.Join(names, " ") {
+					t.Errorf("Unexpected names: got %v, expected %v", output.names, names)
+				}
+			}
+		})
+	}
+}
+
+func TestSplitterOutputFileNamesCallbacks(t *testing.T) {
+	splitter := NewSplitter(ByBytes, 2, strings.NewReader("abcdef"), "")
+	calls := 0
+	splitter.OnChunkComplete = func(name string, bytes uint64, md5sum string) {
+		calls++
+	}
+	splitter.Progress = func(bytesWritten uint64, filesCreated uint64) {
+		calls++
+	}
+
+	// 名前を求めるための分割では、書き込んでいない出力ファイルについて呼び出さない
+	names, err := splitter.OutputFileNames()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if expected := "xaa xab xac"; strings.Join(names, " ") != expected {
+		t.Errorf("Unexpected names: got %v, expected %s", names, expected)
+	}
+	if calls != 0 {
+		t.Errorf("Unexpected number of calls: got %d, expected 0", calls)
+	}
+}
+
+func TestSplitterProgress(t *testing.T) {
+	type progress struct {
+		bytesWritten uint64
+		filesCreated uint64
+	}
+	tests := []struct {
+		splitType SplitType
+		count     uint64
+		gzip      bool
+		expected  []progress
+	}{
+		{ByBytes, 4, false, []progress{{4, 1}, {8, 2}, {10, 3}}},
+		{ByLines, 2, false, []progress{{4, 1}, {8, 2}, {10, 3}}},
+		{ByFiles, 2, false, []progress{{5, 1}, {10, 2}}},
+		{ByLines, 3, true, []progress{{6, 1}, {10, 2}}},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("splitType: %v gzip: %v", test.splitType, test.gzip), func(t *testing.T) {
+			output := &memoryOutput{}
+			splitter := NewSplitter(test.splitType, test.count, strings.NewReader("1\n2\n3\n4\n5\n"), "")
+			splitter.WriterFactory = output.create
+			splitter.Gzip = test.gzip
+			got := []progress{}
+			splitter.Progress = func(bytesWritten uint64, filesCreated uint64) {
+				got = append(got, progress{bytesWritten, filesCreated})
+			}
+			if err := splitter.Split(); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(test.expected) {
+				t.Errorf("Unexpected progress: got %v, expected %v", got, test.expected)
+			}
+		})
+	}
+}
+
+// pipeReader は1回の Read で1行ずつ返し、2回目以降の Read の前に check を呼ぶ
+// 入力の途中で出力ファイルの内容を確認するために用いる
+type pipeReader struct {
+	lines []string
+	reads int
+	check func(reads int)
+}
+
+func (r *pipeReader) Read(p []byte) (int, error) {
+	if r.reads > 0 {
+		r.check(r.reads)
+	}
+	if r.reads >= len(r.lines) {
+		return 0, io.EOF
+	}
+	n := copy(p, r.lines[r.reads])
+	r.reads++
+	return n, nil
+}
+
+func TestSplitterUnbuffered(t *testing.T) {
+	for _, splitType := range []SplitType{ByLines, ByRoundRobin} {
+		t.Run(fmt.Sprintf("splitType: %v", splitType), func(t *testing.T) {
+			outputDir := t.TempDir() + "/"
+			lines := []string{"1\n", "2\n", "3\n", "4\n"}
+			reader := &pipeReader{lines: lines}
+			splitter := NewSplitter(splitType, 2, reader, outputDir+"x")
+			splitter.Unbuffered = true
+			reader.check = func(reads int) {
+				// 読み込んだ行がすでに出力ファイルに書き込まれている
+				written := map[string]string{}
+				for i := 0; i < reads; i++ {
+					index := uint64(i / 2)
+					if splitType == ByRoundRobin {
+						index = uint64(i % 2)
+					}
+					name, _ := GenFileName(outputDir+"x", index, 2, splitType, SuffixOptions{})
+					written[name] += lines[i]
+				}
+				for name, expected := range written {
+					data, err := os.ReadFile(name)
+					if err != nil {
+						t.Fatalf("Unexpected error: %s", err)
+					}
+					if string(data) != expected {
+						t.Errorf("Unexpected content of %s after %d reads: got %q, expected %q", name, reads, data, expected)
+					}
+				}
+			}
+			if err := splitter.Split(); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+		})
+	}
+}
+
+// failingWriteCloser は limit バイトを超える書き込みでエラーを返す
+type failingWriteCloser struct {
+	io.WriteCloser
+	limit int
+}
+
+func (w *failingWriteCloser) Write(p []byte) (int, error) {
+	if len(p) > w.limit {
+		return 0, errors.New("disk full")
+	}
+	w.limit -= len(p)
+	return w.WriteCloser.Write(p)
+}
+
+func TestSplitterCleanupOnError(t *testing.T) {
+	for _, splitType := range []SplitType{ByBytes, ByLines, ByFiles, ByLineFiles, ByRoundRobin, ByLineBytes} {
+		for _, noCleanup := range []bool{false, true} {
+			t.Run(fmt.Sprintf("splitType: %v noCleanup: %v", splitType, noCleanup), func(t *testing.T) {
+				outputDir := t.TempDir() + "/"
+				splitter := NewSplitter(splitType, 2, strings.NewReader("1\n2\n3\n4\n5\n"), outputDir+"x")
+				// 2つ目の出力ファイルへの書き込みでエラーとする
+				created := 0
+				splitter.WriterFactory = func(name string) (io.WriteCloser, error) {
+					file, err := os.Create(name)
+					if err != nil {
+						return nil, err
+					}
+					created++
+					if created == 2 {
+						return &failingWriteCloser{WriteCloser: file}, nil
+					}
+					return file, nil
+				}
+				splitter.OutputRemover = os.Remove
+				splitter.NoCleanup = noCleanup
+				splitter.Unbuffered = true
+				if err := splitter.Split(); err == nil {
+					t.Fatalf("Unexpected result: error is nil")
+				}
+
+				entries, err := os.ReadDir(outputDir)
+				if err != nil {
+					t.Fatalf("Unexpected error: %s", err)
+				}
+				if noCleanup && len(entries) == 0 {
+					t.Errorf("Output files were removed")
+				}
+				if !noCleanup && len(entries) != 0 {
+					t.Errorf("Unexpected files were left: %v", entries)
+				}
+			})
+		}
+	}
+}
+
+// oneByteReader は1回の Read で1バイトずつ返す
+type oneByteReader struct {
+	reader io.Reader
+}
+
+func (r oneByteReader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	return r.reader.Read(p[:1])
+}
+
+// emptyReadsReader は data を返す前に empty 回だけ0バイトとエラーなしを返す
+// empty が負の場合は常に0バイトを返す
+type emptyReadsReader struct {
+	data  io.Reader
+	empty int
+}
+
+func (r *emptyReadsReader) Read(p []byte) (int, error) {
+	if r.empty != 0 {
+		r.empty--
+		return 0, nil
+	}
+	return r.data.Read(p)
+}
+
+func TestSplitByByteEmptyReads(t *testing.T) {
+	tests := []struct {
+		name      string
+		splitType SplitType
+		empty     int
+		valid     bool
+		expected  []string
+	}{
+		{"bytes with a few empty reads", ByBytes, 3, true, []string{"1234", "5678", "90"}},
+		{"bytes with endless empty reads", ByBytes, -1, false, []string{}},
+		{"files with a few empty reads", ByFiles, 3, true, []string{"1234", "567", "890"}},
+		{"files with endless empty reads", ByFiles, -1, false, []string{}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			count := uint64(4)
+			if test.splitType == ByFiles {
+				count = 3
+			}
+			output := &memoryOutput{}
+			reader := &emptyReadsReader{data: strings.NewReader("1234567890"), empty: test.empty}
+			splitter := NewSplitter(test.splitType, count, reader, "")
+			splitter.WriterFactory = output.create
+			err := splitter.Split()
+			if err != nil && test.valid {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if !test.valid && !errors.Is(err, InputMadeNoProgress) {
+				t.Fatalf("Unexpected error: got %v, expected %s", err, InputMadeNoProgress)
+			}
+
+			// 空のファイルを作成し続けない
+			if len(output.names) != len(test.expected) {
+				t.Fatalf("Unexpected output files: got %v, expected %d files", output.names, len(test.expected))
+			}
+			for i, expected := range test.expected {
+				if got := output.buffers[output.names[i]].String(); got != expected {
+					t.Errorf("Unexpected content of %s: got %q, expected %q", output.names[i], got, expected)
+				}
+			}
+		})
+	}
+}
+
+func TestSplitByByteShortReads(t *testing.T) {
+	output := &memoryOutput{}
+	splitter := NewSplitter(ByBytes, 4, oneByteReader{strings.NewReader("1234567890")}, "")
+	splitter.WriterFactory = output.create
+	if err := splitter.Split(); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	expected := []string{"1234", "5678", "90"}
+	if len(output.names) != len(expected) {
+		t.Fatalf("Unexpected number of outputs: got %v, expected %d", output.names, len(expected))
+	}
+	for i, name := range output.names {
+		if got := output.buffers[name].String(); got != expected[i] {
+			t.Errorf("Unexpected content of %s: got %q, expected %q", name, got, expected[i])
+		}
+	}
+}
+
+func TestSplitByByteUTF8Safe(t *testing.T) {
+	tests := []struct {
+		input    string
+		count    uint64
+		expected []string
+	}{
+		{"abあいう", 4, []string{"ab", "あ", "い", "う"}},
+		{"aあい", 4, []string{"aあ", "い"}},
+		{"日本語のテキスト", 10, []string{"日本語", "のテキ", "スト"}},
+		{"a😀b", 4, []string{"a", "😀", "b"}},
+		// 文字が count より長い場合は count バイトで区切る
+		{"😀", 3, []string{"\xf0\x9f\x98", "\x80"}},
+		// UTF-8 として正しくないバイト列はそのまま区切る
+		{"\xff\xff\xff\xff\xff", 2, []string{"\xff\xff", "\xff\xff", "\xff"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			readers := map[string]io.Reader{
+				"seekable":     strings.NewReader(test.input),
+				"non-seekable": oneByteReader{strings.NewReader(test.input)},
+			}
+			for name, reader := range readers {
+				output := &memoryOutput{}
+				splitter := NewSplitter(ByBytes, test.count, reader, "")
+				splitter.UTF8Safe = true
+				splitter.WriterFactory = output.create
+				if err := splitter.Split(); err != nil {
+					t.Fatalf("Unexpected error: %s", err)
+				}
+
+				got := []string{}
+				for _, outputName := range output.names {
+					got = append(got, output.buffers[outputName].String())
+				}
+				if !reflect.DeepEqual(got, test.expected) {
+					t.Errorf("Unexpected outputs with %s input: got %q, expected %q", name, got, test.expected)
+				}
+				// count が UTF-8 の最大のバイト数以上であれば、正しい入力の文字を分けることはない
+				if utf8.ValidString(test.input) && test.count >= utf8.UTFMax {
+					for _, content := range got {
+						if !utf8.ValidString(content) {
+							t.Errorf("Output with %s input is not valid UTF-8: %q", name, content)
+						}
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestSplitByByteFileUTF8Safe(t *testing.T) {
+	outputDir := t.TempDir() + "/"
+	input := strings.Repeat("aあい😀", 100)
+	if err := os.WriteFile(outputDir+"input", []byte(input), 0644); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	inputFile, err := os.Open(outputDir + "input")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	defer inputFile.Close()
+
+	// ファイルからの入力でも複写せずに文字の境界で区切る
+	splitter := NewSplitter(ByBytes, 5, inputFile, outputDir+"x")
+	splitter.UTF8Safe = true
+	splitter.Suffix = SuffixOptions{Length: 3}
+	if err := splitter.Split(); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	joined := ""
+	for _, name := range splitter.Created() {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if !utf8.Valid(data) {
+			t.Errorf("%s is not valid UTF-8: %q", name, data)
+		}
+		if len(data) > 5 {
+			t.Errorf("%s is larger than the count: %d bytes", name, len(data))
+		}
+		joined += string(data)
+	}
+	if joined != input {
+		t.Errorf("Unexpected joined content: got %q, expected %q", joined, input)
+	}
+}
+
+func TestSplitByPattern(t *testing.T) {
+	sections := "intro\n## one\na\nb\n## two\n## three\nc"
+	tests := []struct {
+		input           string
+		suppressMatched bool
+		expected        []string
+	}{
+		{sections, false, []string{"intro\n", "## one\na\nb\n", "## two\n", "## three\nc"}},
+		{sections, true, []string{"intro\n", "a\nb\n", "c"}},
+		{"## one\na\n", false, []string{"## one\na\n"}},
+		{"## one\na\n", true, []string{"a\n"}},
+		{"a\nb\n", false, []string{"a\nb\n"}},
+		{"", false, []string{}},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("input: %q suppressMatched: %v", test.input, test.suppressMatched), func(t *testing.T) {
+			output := &memoryOutput{}
+			splitter := NewPatternSplitter(regexp.MustCompile(`^## `), strings.NewReader(test.input), "x")
+			splitter.WriterFactory = output.create
+			splitter.SuppressMatched = test.suppressMatched
+			if err := splitter.Split(); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+
+			if len(output.names) != len(test.expected) {
+				t.Fatalf("Unexpected number of outputs: got %v, expected %d", output.names, len(test.expected))
+			}
+			for i, expected := range test.expected {
+				name, _ := GenFileName("x", uint64(i), 0, ByPattern, SuffixOptions{})
+				if output.names[i] != name {
+					t.Errorf("Unexpected output name: got %s, expected %s", output.names[i], name)
+				}
+				if got := output.buffers[name].String(); got != expected {
+					t.Errorf("Unexpected content of %s: got %q, expected %q", name, got, expected)
+				}
+			}
+		})
+	}
+}
+
+func TestSplitHeaderLines(t *testing.T) {
+	input := "id,name\n1,a\n2,b\n3,c\n4,d\n5,e\n"
+	tests := []struct {
+		name        string
+		splitter    func(r io.Reader) *Splitter
+		headerLines uint64
+		seekable    bool
+		expected    []string
+	}{
+		{"lines", func(r io.Reader) *Splitter { return NewSplitter(ByLines, 2, r, "x") }, 1, true, []string{"id,name\n1,a\n2,b\n", "id,name\n3,c\n4,d\n", "id,name\n5,e\n"}},
+		{"lines non-seekable", func(r io.Reader) *Splitter { return NewSplitter(ByLines, 2, r, "x") }, 1, false, []string{"id,name\n1,a\n2,b\n", "id,name\n3,c\n4,d\n", "id,name\n5,e\n"}},
+		{"two header lines", func(r io.Reader) *Splitter { return NewSplitter(ByLines, 3, r, "x") }, 2, true, []string{"id,name\n1,a\n2,b\n3,c\n4,d\n", "id,name\n1,a\n5,e\n"}},
+		{"line files", func(r io.Reader) *Splitter { return NewSplitter(ByLineFiles, 2, r, "x") }, 1, true, []string{"id,name\n1,a\n2,b\n3,c\n", "id,name\n4,d\n5,e\n"}},
+		{"header only", func(r io.Reader) *Splitter { return NewSplitter(ByLines, 2, r, "x") }, 10, true, []string{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var reader io.Reader = strings.NewReader(input)
+			if !test.seekable {
+				reader = nonSeekableReader{reader}
+			}
+			output := &memoryOutput{}
+			splitter := test.splitter(reader)
+			splitter.HeaderLines = test.headerLines
+			splitter.WriterFactory = output.create
+			if err := splitter.Split(); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+
+			if len(output.names) != len(test.expected) {
+				t.Fatalf("Unexpected number of outputs: got %v, expected %d", output.names, len(test.expected))
+			}
+			for i, expected := range test.expected {
+				if got := output.buffers[output.names[i]].String(); got != expected {
+					t.Errorf("Unexpected content of %s: got %q, expected %q", output.names[i], got, expected)
+				}
+			}
+		})
+	}
+}
+
+func TestSplitCreationFailure(t *testing.T) {
+	input := "1\n2\n3\n4\n5\n6\n"
+	tests := []struct {
+		name              string
+		splitter          func(r io.Reader) *Splitter
+		noCleanup         bool
+		expectedOutputs   []string
+		expectedRemaining string
+	}{
+		{"lines", func(r io.Reader) *Splitter { return NewSplitter(ByLines, 2, r, "x") }, true, []string{"1\n2\n", "3\n4\n"}, "5\n6\n"},
+		{"bytes", func(r io.Reader) *Splitter { return NewSplitter(ByBytes, 3, r, "x") }, true, []string{"1\n2", "\n3\n"}, "4\n5\n6\n"},
+		{"files", func(r io.Reader) *Splitter { return NewSplitter(ByFiles, 4, r, "x") }, true, []string{"1\n2", "\n3\n"}, "4\n5\n6\n"},
+		{"line_bytes", func(r io.Reader) *Splitter { return NewSplitter(ByLineBytes, 5, r, "x") }, true, []string{"1\n2\n", "3\n4\n"}, "5\n6\n"},
+		{"cleanup", func(r io.Reader) *Splitter { return NewSplitter(ByLines, 2, r, "x") }, false, nil, input},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reader := strings.NewReader(input)
+			output := &memoryOutput{}
+			splitter := test.splitter(reader)
+			splitter.NoCleanup = test.noCleanup
+			// 3つ目 (番号2) の出力ファイルの作成に失敗させる
+			splitter.WriterFactory = func(name string) (io.WriteCloser, error) {
+				if len(output.names) == 2 {
+					return nil, errors.New("injected failure")
+				}
+				return output.create(name)
+			}
+			splitter.OutputRemover = func(name string) error {
+				delete(output.buffers, name)
+				return nil
+			}
+
+			err := splitter.Split()
+			if !errors.Is(err, OutputFileCreationFailed) || !strings.Contains(err.Error(), "chunk 2") {
+				t.Fatalf("Unexpected error: got %v, expected %s for chunk 2", err, OutputFileCreationFailed)
+			}
+
+			created := splitter.CreatedFiles()
+			if len(created) != len(test.expectedOutputs) {
+				t.Fatalf("Unexpected created files: got %+v, expected %d files", created, len(test.expectedOutputs))
+			}
+			for i, file := range created {
+				if got := output.buffers[file.Name].String(); got != test.expectedOutputs[i] {
+					t.Errorf("Unexpected content of %s: got %q, expected %q", file.Name, got, test.expectedOutputs[i])
+				}
+			}
+			// 書き込まれなかった内容は入力に残る
+			remaining, err := io.ReadAll(reader)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if string(remaining) != test.expectedRemaining {
+				t.Errorf("Unexpected remaining input: got %q, expected %q", remaining, test.expectedRemaining)
+			}
+		})
+	}
+}
+
+func TestSplitterReset(t *testing.T) {
+	outputDir := t.TempDir() + "/"
+	splitter := NewSplitter(ByLines, 2, strings.NewReader("1\n2\n3\n4\n5\n"), outputDir+"first-")
+	splitter.Suffix = SuffixOptions{Numeric: true, Additional: ".txt"}
+	if err := splitter.Split(); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	// 分割の方法とオプションは保ったまま、入力と接頭辞だけを入れ替える
+	splitter.Reset(strings.NewReader("a\nb\n"), outputDir+"second-")
+	if got := splitter.Created(); len(got) != 0 {
+		t.Errorf("Unexpected created files after Reset: %v", got)
+	}
+	if err := splitter.Split(); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"first-00.txt", "1\n2\n"},
+		{"first-01.txt", "3\n4\n"},
+		{"first-02.txt", "5\n"},
+		{"second-00.txt", "a\nb\n"},
+	}
+	for _, test := range tests {
+		data, err := os.ReadFile(outputDir + test.name)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if string(data) != test.expected {
+			t.Errorf("Unexpected content of %s: got %q, expected %q", test.name, data, test.expected)
+		}
+	}
+	if got, expected := splitter.Created(), []string{outputDir + "second-00.txt"}; strings.Join(got, ",") != strings.Join(expected, ",") {
+		t.Errorf("Unexpected created files: got %v, expected %v", got, expected)
+	}
+	if _, err := os.Stat(outputDir + "second-01.txt"); !os.IsNotExist(err) {
+		t.Errorf("Unexpected output file second-01.txt: %v", err)
+	}
+}
+
+func TestSplitterCreated(t *testing.T) {
+	tests := []struct {
+		splitType SplitType
+		count     uint64
+		elide     bool
+	}{
+		{ByBytes, 4, false},
+		{ByLines, 2, false},
+		{ByFiles, 3, false},
+		{ByFiles, 12, true},
+		{ByLineFiles, 2, false},
+		{ByRoundRobin, 3, false},
+		{ByLineBytes, 4, false},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("splitType: %v elide: %v", test.splitType, test.elide), func(t *testing.T) {
+			outputDir := t.TempDir() + "/"
+			splitter := NewSplitter(test.splitType, test.count, strings.NewReader("1\n2\n3\n4\n5\n"), outputDir+"x")
+			splitter.ElideEmptyFiles = test.elide
+			if err := splitter.Split(); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+
+			entries, err := os.ReadDir(outputDir)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			expected := []string{}
+			for _, entry := range entries {
+				expected = append(expected, outputDir+entry.Name())
+			}
+			if got := splitter.Created(); strings.Join(got, ",") != strings.Join(expected, ",") {
+				t.Errorf("Unexpected created files: got %v, expected %v", got, expected)
+			}
+
+			// ファイルごとのバイト数は出力ファイルのサイズと一致する
+			files := splitter.CreatedFiles()
+			if len(files) != len(entries) {
+				t.Fatalf("Unexpected number of created files: got %d, expected %d", len(files), len(entries))
+			}
+			for i, file := range files {
+				info, err := entries[i].Info()
+				if err != nil {
+					t.Fatalf("Unexpected error: %s", err)
+				}
+				if file.Name != expected[i] || file.Bytes != uint64(info.Size()) {
+					t.Errorf("Unexpected created file: got %+v, expected %s with %d bytes", file, expected[i], info.Size())
+				}
+			}
+		})
+	}
+}
+
+func TestSplitByLineFinalLine(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{"trailing newline", "1\n2\n3\n4\n5\n", []string{"1\n2\n", "3\n4\n", "5\n"}},
+		{"no trailing newline", "1\n2\n3\n4\n5", []string{"1\n2\n", "3\n4\n", "5"}},
+		// 最後の行が count 行目になる場合も、その行でファイルを閉じて空のファイルを作成しない
+		{"no trailing newline at count", "1\n2\n3\n4", []string{"1\n2\n", "3\n4"}},
+		{"trailing newline at count", "1\n2\n3\n4\n", []string{"1\n2\n", "3\n4\n"}},
+		{"single line without newline", "1", []string{"1"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output := &memoryOutput{}
+			splitter := NewSplitter(ByLines, 2, strings.NewReader(test.input), "x")
+			splitter.WriterFactory = output.create
+			if err := splitter.Split(); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+
+			if len(output.names) != len(test.expected) {
+				t.Fatalf("Unexpected number of outputs: got %v, expected %d", output.names, len(test.expected))
+			}
+			for i, expected := range test.expected {
+				if got := output.buffers[output.names[i]].String(); got != expected {
+					t.Errorf("Unexpected content of %s: got %q, expected %q", output.names[i], got, expected)
+				}
+			}
+		})
+	}
+}
+
+func TestSplitByLineFileBytes(t *testing.T) {
+	tests := []struct {
+		lineEnding      LineEnding
+		ensureTrailing  bool
+		input           string
+		expectedOutputs []string
+	}{
+		{LineEndingLF, false, "1\n22\n333\n4444\n5", []string{"1\n22\n", "333\n4444\n", "5"}},
+		{LineEndingCRLF, false, "1\r\n22\r\n333\r\n4444\r\n5", []string{"1\r\n22\r\n", "333\r\n4444\r\n", "5"}},
+		{LineEndingCRLF, true, "1\r\n22\r\n333", []string{"1\r\n22\r\n", "333\r\n"}},
+		{LineEndingAuto, true, "1\r\n2\n\r\n3", []string{"1\r\n2\n\r\n", "3\r\n"}},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%q", test.input), func(t *testing.T) {
+			output := &memoryOutput{}
+			splitter := NewSplitter(ByLines, 2, strings.NewReader(test.input), "x")
+			splitter.LineEnding = test.lineEnding
+			splitter.EnsureTrailingNewline = test.ensureTrailing
+			splitter.WriterFactory = output.create
+			if err := splitter.Split(); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+
+			// ファイルごとのバイト数は区切り文字を含めた書き込んだ行の長さの合計で、ファイルごとに数え直す
+			files := splitter.CreatedFiles()
+			if len(files) != len(test.expectedOutputs) {
+				t.Fatalf("Unexpected number of created files: got %+v, expected %d", files, len(test.expectedOutputs))
+			}
+			for i, file := range files {
+				written := output.buffers[file.Name].String()
+				if written != test.expectedOutputs[i] {
+					t.Errorf("Unexpected content of %s: got %q, expected %q", file.Name, written, test.expectedOutputs[i])
+				}
+				if file.Bytes != uint64(len(test.expectedOutputs[i])) {
+					t.Errorf("Unexpected bytes of %s: got %d, expected %d", file.Name, file.Bytes, len(test.expectedOutputs[i]))
+				}
+			}
+		})
+	}
+}
+
+func TestSplitterCreatedCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	reader := &cancelingReader{
+		lines:       []string{"1\n", "2\n", "3\n", "4\n", "5\n", "6\n"},
+		cancelAfter: 3,
+		cancel:      cancel,
+	}
+
+	outputDir := t.TempDir() + "/"
+	splitter := NewSplitter(ByLines, 2, reader, outputDir+"x")
+	if err := splitter.SplitContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Unexpected error: got %v, expected %v", err, context.Canceled)
+	}
+	// 書き込み途中で削除した xab は含まない
+	if got := splitter.Created(); strings.Join(got, ",") != outputDir+"xaa" {
+		t.Errorf("Unexpected created files: got %v, expected %v", got, []string{outputDir + "xaa"})
+	}
+}
+
+func TestSplitterKeepPartialOnCancel(t *testing.T) {
+	for _, splitType := range []SplitType{ByLines, ByLineBytes} {
+		t.Run(splitType.String(), func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			reader := &cancelingReader{
+				lines:       []string{"1\n", "2\n", "3\n", "4\n", "5\n", "6\n"},
+				cancelAfter: 3,
+				cancel:      cancel,
+			}
+
+			outputDir := t.TempDir() + "/"
+			splitter := NewSplitter(splitType, map[SplitType]uint64{ByLines: 2, ByLineBytes: 5}[splitType], reader, outputDir+"x")
+			splitter.KeepPartialOnCancel = true
+			if err := splitter.SplitContext(ctx); !errors.Is(err, context.Canceled) {
+				t.Fatalf("Unexpected error: got %v, expected %v", err, context.Canceled)
+			}
+
+			// 書き込み途中の xab は削除せず、最後に書き込んだ行の終わりで閉じる
+			expected := []string{outputDir + "xaa", outputDir + "xab"}
+			if got := splitter.Created(); strings.Join(got, ",") != strings.Join(expected, ",") {
+				t.Errorf("Unexpected created files: got %v, expected %v", got, expected)
+			}
+			for name, content := range map[string]string{"xaa": "1\n2\n", "xab": "3\n"} {
+				data, err := os.ReadFile(outputDir + name)
+				if err != nil {
+					t.Fatalf("Unexpected error: %s", err)
+				}
+				if string(data) != content {
+					t.Errorf("Unexpected content of %s: got %q, expected %q", name, data, content)
+				}
+			}
+		})
+	}
+}
+
+func TestSplitByLineMaxLineBytes(t *testing.T) {
+	const limit = 64 * 1024
+	longLine := bytes.Repeat([]byte("a"), 1024*1024)
+	tests := []struct {
+		input         []byte
+		count         uint64
+		expectedFiles int
+	}{
+		{longLine, 1000, 16},
+		{append(append([]byte("1\n"), longLine...), "\n2\n"...), 1000, 17},
+		{[]byte("1\n2\n3\n"), 2, 2},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("size: %d", len(test.input)), func(t *testing.T) {
+			output := &memoryOutput{}
+			splitter := NewSplitter(ByLines, test.count, bytes.NewReader(test.input), "x")
+			splitter.WriterFactory = output.create
+			splitter.MaxLineBytes = limit
+			if err := splitter.Split(); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+
+			if len(output.names) != test.expectedFiles {
+				t.Fatalf("Unexpected number of outputs: got %d, expected %d", len(output.names), test.expectedFiles)
+			}
+			joined := []byte{}
+			for _, name := range output.names {
+				// 行数の上限に達しない限り、出力は上限の長さで区切られる
+				if output.buffers[name].Len() > limit+2 {
+					t.Errorf("Unexpected size of %s: %d", name, output.buffers[name].Len())
+				}
+				joined = append(joined, output.buffers[name].Bytes()...)
+			}
+			if !bytes.Equal(joined, test.input) {
+				t.Errorf("Joined output does not match the input")
+			}
+		})
+	}
+}
+
+func TestSplitterLineEnding(t *testing.T) {
+	crlf := "a\r\nb\nc\r\nd\r\n"
+	tests := []struct {
+		splitType  SplitType
+		lineEnding LineEnding
+		input      string
+		expected   []string
+	}{
+		{ByLines, LineEndingLF, crlf, []string{"a\r\nb\n", "c\r\nd\r\n"}},
+		{ByLines, LineEndingCRLF, crlf, []string{"a\r\nb\nc\r\n", "d\r\n"}},
+		{ByLines, LineEndingAuto, crlf, []string{"a\r\nb\nc\r\n", "d\r\n"}},
+		{ByLines, LineEndingAuto, "a\nb\r\nc\n", []string{"a\nb\r\n", "c\n"}},
+		{ByLines, LineEndingCRLF, "a\nb", []string{"a\nb"}},
+		{ByRoundRobin, LineEndingCRLF, crlf, []string{"a\r\nd\r\n", "b\nc\r\n"}},
+		{ByLineBytes, LineEndingCRLF, crlf, []string{"a\r\n", "b\nc\r\n", "d\r\n"}},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("splitType: %v lineEnding: %v input: %q", test.splitType, test.lineEnding, test.input), func(t *testing.T) {
+			output := &memoryOutput{}
+			splitter := NewSplitter(test.splitType, 2, strings.NewReader(test.input), "x")
+			if test.splitType == ByLineBytes {
+				splitter = NewSplitter(test.splitType, 6, strings.NewReader(test.input), "x")
+			}
+			splitter.WriterFactory = output.create
+			splitter.LineEnding = test.lineEnding
+			if err := splitter.Split(); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+
+			if len(output.names) != len(test.expected) {
+				t.Fatalf("Unexpected number of outputs: got %v, expected %d", output.names, len(test.expected))
+			}
+			for i, expected := range test.expected {
+				if got := output.buffers[output.names[i]].String(); got != expected {
+					t.Errorf("Unexpected content of %s: got %q, expected %q", output.names[i], got, expected)
+				}
+			}
+		})
+	}
+
+	splitter := NewSplitter(ByLines, 2, strings.NewReader(crlf), "x")
+	splitter.Separator = 0
+	splitter.LineEnding = LineEndingCRLF
+	if err := splitter.Split(); err == nil || err.Error() != string(InvalidLineEnding) {
+		t.Errorf("Unexpected error: got %v, expected %s", err, InvalidLineEnding)
+	}
+}
+
+// observingWriteCloser は書き込むたびに check を呼ぶ
+type observingWriteCloser struct {
+	io.WriteCloser
+	check func()
+}
+
+func (w *observingWriteCloser) Write(p []byte) (int, error) {
+	w.check()
+	return w.WriteCloser.Write(p)
+}
+
+func TestSplitterOnChunkComplete(t *testing.T) {
+	input := strings.Repeat("0123456789abcdef\n", 1000)
+	tests := []struct {
+		name      string
+		splitType SplitType
+		count     uint64
+		configure func(*Splitter)
+	}{
+		{"lines", ByLines, 300, func(s *Splitter) {}},
+		{"bytes", ByBytes, 4096, func(s *Splitter) {}},
+		{"files", ByFiles, 3, func(s *Splitter) {}},
+		{"gzip", ByLines, 300, func(s *Splitter) { s.Gzip = true }},
+		{"atomic", ByLines, 300, func(s *Splitter) { s.Atomic = true }},
+		{"header", ByLines, 300, func(s *Splitter) { s.HeaderLines = 1 }},
+		{"parallel", ByFiles, 5, func(s *Splitter) { s.Parallel = 3 }},
+		// 開き直したファイルは最後の呼び出しがファイル全体となる
+		{"round robin reopened", ByRoundRobin, maxOpenRoundRobinFiles + 2, func(s *Splitter) {}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			outputDir := t.TempDir() + "/"
+			inputFilePath := outputDir + "input"
+			if err := os.WriteFile(inputFilePath, []byte(input), 0644); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			inputFile, err := os.Open(inputFilePath)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			defer inputFile.Close()
+
+			type completed struct {
+				bytes  uint64
+				md5sum string
+			}
+			got := map[string]completed{}
+			splitter := NewSplitter(test.splitType, test.count, inputFile, outputDir+"x")
+			test.configure(splitter)
+			splitter.OnChunkComplete = func(name string, bytes uint64, md5sum string) {
+				got[name] = completed{bytes, md5sum}
+			}
+			if err := splitter.Split(); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+
+			created := splitter.Created()
+			if len(got) != len(created) {
+				t.Fatalf("Unexpected number of completed files: got %d, expected %d", len(got), len(created))
+			}
+			for _, name := range created {
+				expected, err := getFileHash(name)
+				if err != nil {
+					t.Fatalf("Unexpected error: %s", err)
+				}
+				info, err := os.Stat(name)
+				if err != nil {
+					t.Fatalf("Unexpected error: %s", err)
+				}
+				if got[name].md5sum != expected {
+					t.Errorf("Unexpected md5sum of %s: got %s, expected %s", name, got[name].md5sum, expected)
+				}
+				if got[name].bytes != uint64(info.Size()) {
+					t.Errorf("Unexpected bytes of %s: got %d, expected %d", name, got[name].bytes, info.Size())
+				}
+			}
+		})
+	}
+}
+
+func TestSplitterAtomic(t *testing.T) {
+	for _, splitType := range []SplitType{ByBytes, ByLines, ByFiles} {
+		t.Run(fmt.Sprintf("splitType: %v", splitType), func(t *testing.T) {
+			outputDir := t.TempDir() + "/"
+			splitter := NewSplitter(splitType, 2, strings.NewReader("1\n2\n3\n4\n5\n"), outputDir+"x")
+			splitter.Atomic = true
+			splitter.Unbuffered = true
+			names := []string{}
+			splitter.WriterFactory = func(name string) (io.WriteCloser, error) {
+				file, err := os.Create(name)
+				if err != nil {
+					return nil, err
+				}
+				names = append(names, name)
+				index := uint64(len(names) - 1)
+				finalName, _ := GenFileName(outputDir+"x", index, 2, splitType, SuffixOptions{})
+				// 書き込み中は最終的なファイル名が存在しない
+				return &observingWriteCloser{WriteCloser: file, check: func() {
+					if _, err := os.Stat(finalName); !errors.Is(err, os.ErrNotExist) {
+						t.Errorf("%s exists before it is fully written", finalName)
+					}
+				}}, nil
+			}
+			splitter.OutputRemover = os.Remove
+			if err := splitter.Split(); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+
+			created := splitter.Created()
+			if len(created) != len(names) {
+				t.Fatalf("Unexpected number of outputs: got %v, expected %d", created, len(names))
+			}
+			for i, name := range created {
+				if names[i] == name {
+					t.Errorf("Output was written directly to %s", name)
+				}
+				if _, err := os.Stat(name); err != nil {
+					t.Errorf("Unexpected error: %s", err)
+				}
+				if _, err := os.Stat(names[i]); !errors.Is(err, os.ErrNotExist) {
+					t.Errorf("Temporary file %s was left", names[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSplitterAtomicTempFile(t *testing.T) {
+	outputDir := t.TempDir() + "/"
+	// 中断した以前の実行が残した一時ファイルは使わず、削除もしない
+	stale := outputDir + ".xaa.tmp"
+	if err := os.WriteFile(stale, []byte("stale\n"), 0644); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	splitter := NewSplitter(ByLines, 2, strings.NewReader("1\n2\n3\n"), outputDir+"x")
+	splitter.Atomic = true
+	splitter.NoClobber = true
+	splitter.FileMode = 0640
+	if err := splitter.Split(); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if data, err := os.ReadFile(stale); err != nil || string(data) != "stale\n" {
+		t.Errorf("Unexpected content of %s: got %q (error: %v)", stale, data, err)
+	}
+	entries, err := os.ReadDir(outputDir)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	names := []string{}
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	if expected := ".xaa.tmp,xaa,xab"; strings.Join(names, ",") != expected {
+		t.Errorf("Unexpected files: got %v, expected %s", names, expected)
+	}
+	// 一時ファイルから名前を変更しても、直接作成する場合と同じ権限となる
+	direct, err := CreateFile(outputDir+"direct", 0640, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	direct.Close()
+	directInfo, err := os.Stat(outputDir + "direct")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	for _, name := range splitter.Created() {
+		info, err := os.Stat(name)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if expected := directInfo.Mode().Perm(); info.Mode().Perm() != expected {
+			t.Errorf("Unexpected mode of %s: got %s, expected %s", name, info.Mode().Perm(), expected)
+		}
+	}
+}
+
+func TestSplitterTotalSize(t *testing.T) {
+	tests := []struct {
+		valid     bool
+		splitType SplitType
+		input     string
+		totalSize uint64
+		expected  []string
+	}{
+		{true, ByFiles, "1\n2\n3\n4\n", 8, []string{"1\n2\n", "3\n4\n"}},
+		{false, ByFiles, "1\n2\n3\n", 8, nil},
+		{false, ByFiles, "1\n2\n3\n4\n5\n", 8, nil},
+		{true, ByLineFiles, "1\n2\n3\n4\n", 8, []string{"1\n2\n", "3\n4\n"}},
+		{false, ByLineFiles, "1\n2\n3\n", 8, nil},
+		{false, ByLineFiles, "1\n2\n3\n4\n5\n", 8, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("splitType: %v input: %q", test.splitType, test.input), func(t *testing.T) {
+			output := &memoryOutput{}
+			splitter := NewSplitter(test.splitType, 2, nonSeekableReader{strings.NewReader(test.input)}, "x")
+			splitter.WriterFactory = output.create
+			splitter.TotalSize = test.totalSize
+			err := splitter.Split()
+			if !test.valid {
+				if !errors.Is(err, TotalSizeMismatch) {
+					t.Errorf("Unexpected error: got %v, expected %s", err, TotalSizeMismatch)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if len(output.names) != len(test.expected) {
+				t.Fatalf("Unexpected number of outputs: got %v, expected %d", output.names, len(test.expected))
+			}
+			for i, expected := range test.expected {
+				if got := output.buffers[output.names[i]].String(); got != expected {
+					t.Errorf("Unexpected content of %s: got %q, expected %q", output.names[i], got, expected)
+				}
+			}
+		})
+	}
+}
+
+func TestSplitBySizes(t *testing.T) {
+	tests := []struct {
+		sizes    []uint64
+		input    string
+		expected []string
+	}{
+		{[]uint64{1, 3, 3}, "1234567", []string{"1", "234", "567"}},
+		{[]uint64{1, 3}, "123456789", []string{"1", "234", "567", "89"}},
+		{[]uint64{4, 10}, "12", []string{"12"}},
+		{[]uint64{2}, "", []string{}},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("sizes: %v input: %q", test.sizes, test.input), func(t *testing.T) {
+			output := &memoryOutput{}
+			splitter := NewSizesSplitter(test.sizes, strings.NewReader(test.input), "x")
+			splitter.WriterFactory = output.create
+			if err := splitter.Split(); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+
+			if len(output.names) != len(test.expected) {
+				t.Fatalf("Unexpected number of outputs: got %v, expected %d", output.names, len(test.expected))
+			}
+			for i, expected := range test.expected {
+				if got := output.buffers[output.names[i]].String(); got != expected {
+					t.Errorf("Unexpected content of %s: got %q, expected %q", output.names[i], got, expected)
+				}
+			}
+		})
+	}
+
+	for _, sizes := range [][]uint64{nil, {1, 0}} {
+		if err := NewSizesSplitter(sizes, strings.NewReader("1"), "x").Split(); !errors.Is(err, InvalidSplitSize) {
+			t.Errorf("Unexpected error for %v: got %v, expected %s", sizes, err, InvalidSplitSize)
+		}
+	}
+}
+
+func TestSplitterChecksum(t *testing.T) {
+	for _, algorithm := range []string{ChecksumMD5, ChecksumSHA256} {
+		t.Run(algorithm, func(t *testing.T) {
+			outputDir := t.TempDir() + "/"
+			splitter := NewSplitter(ByLines, 2, strings.NewReader("1\n2\n3\n4\n5\n"), outputDir+"x")
+			splitter.Checksum = algorithm
+			if err := splitter.Split(); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+
+			manifest, err := os.ReadFile(outputDir + "x." + algorithm)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			expected := ""
+			for _, name := range []string{"xaa", "xab", "xac"} {
+				sum, err := getFileHashWith(outputDir+name, algorithm)
+				if err != nil {
+					t.Fatalf("Unexpected error: %s", err)
+				}
+				expected += sum + "  " + name + "\n"
+			}
+			if string(manifest) != expected {
+				t.Errorf("Unexpected manifest: got %q, expected %q", manifest, expected)
+			}
+
+			// md5sum などがある環境では一覧をそのまま確認に使える
+			if command, err := exec.LookPath(algorithm + "sum"); err == nil {
+				cmd := exec.Command(command, "-c", "x."+algorithm)
+				cmd.Dir = outputDir
+				if output, err := cmd.CombinedOutput(); err != nil {
+					t.Errorf("Verification failed: %s: %s", err, output)
+				}
+
+				if err := os.WriteFile(outputDir+"xab", []byte("corrupted\n"), 0644); err != nil {
+					t.Fatalf("Unexpected error: %s", err)
+				}
+				cmd = exec.Command(command, "-c", "x."+algorithm)
+				cmd.Dir = outputDir
+				if err := cmd.Run(); err == nil {
+					t.Errorf("Verification succeeded for a corrupted file")
+				}
+			}
+		})
+	}
+
+	splitter := NewSplitter(ByLines, 2, strings.NewReader("1\n"), "x")
+	splitter.Checksum = "crc32"
+	if err := splitter.Split(); !errors.Is(err, InvalidChecksumAlgorithm) {
+		t.Errorf("Unexpected error: got %v, expected %s", err, InvalidChecksumAlgorithm)
+	}
+	splitter.Checksum = ChecksumMD5
+	splitter.WriterFactory = (&memoryOutput{}).create
+	if err := splitter.Split(); !errors.Is(err, ChecksumRequiresFiles) {
+		t.Errorf("Unexpected error: got %v, expected %s", err, ChecksumRequiresFiles)
+	}
+}
+
+func TestSplitterChecksumWhileWriting(t *testing.T) {
+	input := strings.Repeat("0123456789abcdef\n", 1000)
+	tests := []struct {
+		name      string
+		splitType SplitType
+		count     uint64
+		configure func(*Splitter)
+	}{
+		{"bytes", ByBytes, 4096, func(s *Splitter) {}},
+		{"gzip", ByLines, 300, func(s *Splitter) { s.Gzip = true }},
+		{"atomic", ByLines, 300, func(s *Splitter) { s.Atomic = true }},
+		{"header", ByLines, 300, func(s *Splitter) { s.HeaderLines = 1 }},
+		{"parallel", ByFiles, 5, func(s *Splitter) { s.Parallel = 3 }},
+		{"on chunk complete", ByLines, 300, func(s *Splitter) { s.OnChunkComplete = func(string, uint64, string) {} }},
+		// 開き直したファイルは以前の内容に続けて求める
+		{"round robin reopened", ByRoundRobin, maxOpenRoundRobinFiles + 2, func(s *Splitter) {}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			outputDir := t.TempDir() + "/"
+			inputFilePath := outputDir + "input"
+			if err := os.WriteFile(inputFilePath, []byte(input), 0644); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			inputFile, err := os.Open(inputFilePath)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			defer inputFile.Close()
+
+			splitter := NewSplitter(test.splitType, test.count, inputFile, outputDir+"x")
+			test.configure(splitter)
+			splitter.Checksum = ChecksumSHA256
+			if err := splitter.Split(); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+
+			// 書き込みながら求めたハッシュ値は、出力ファイルを読み直して求めたものと一致する
+			manifest, err := os.ReadFile(outputDir + "x." + ChecksumSHA256)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			expected := ""
+			for _, name := range splitter.Created() {
+				sum, err := getFileHashWith(name, ChecksumSHA256)
+				if err != nil {
+					t.Fatalf("Unexpected error: %s", err)
+				}
+				expected += sum + "  " + filepath.Base(name) + "\n"
+			}
+			if string(manifest) != expected {
+				t.Errorf("Unexpected manifest: got %q, expected %q", manifest, expected)
+			}
+		})
+	}
+}
+
+func TestSplitterChecksumNoClobber(t *testing.T) {
+	outputDir := t.TempDir() + "/"
+	manifestPath := outputDir + "x." + ChecksumMD5
+	if err := os.WriteFile(manifestPath, []byte("keep\n"), 0644); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	splitter := NewSplitter(ByLines, 2, strings.NewReader("1\n2\n3\n"), outputDir+"x")
+	splitter.Checksum = ChecksumMD5
+	splitter.NoClobber = true
+	if err := splitter.Split(); !errors.Is(err, OutputFileExists) || !strings.Contains(err.Error(), manifestPath) {
+		t.Errorf("Unexpected error: got %v, expected %s", err, OutputFileExists)
+	}
+
+	// 既存の一覧は上書きせず、作成した出力ファイルは削除する
+	if data, err := os.ReadFile(manifestPath); err != nil || string(data) != "keep\n" {
+		t.Errorf("Unexpected content of %s: got %q (error: %v)", manifestPath, data, err)
+	}
+	entries, err := os.ReadDir(outputDir)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("Unexpected files were left: %v", entries)
+	}
+}
+
+func TestCreateFile(t *testing.T) {
+	tests := []struct {
+		noClobber bool
+		expected  error
+		content   string
+	}{
+		{false, nil, ""},
+		{true, OutputFileExists, "keep\n"},
+	}
+	for _, test := range tests {
+		path := t.TempDir() + "/x"
+		if err := os.WriteFile(path, []byte("keep\n"), 0644); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		file, err := CreateFile(path, 0, test.noClobber)
+		if !errors.Is(err, test.expected) {
+			t.Errorf("Unexpected error: got %v, expected %v", err, test.expected)
+		}
+		if err == nil {
+			file.Close()
+		}
+		// 上書きする場合は既存の内容を切り詰める
+		if data, err := os.ReadFile(path); err != nil || string(data) != test.content {
+			t.Errorf("Unexpected content of %s: got %q (error: %v)", path, data, err)
+		}
+	}
+}
+
+func TestSplitByBalancedLines(t *testing.T) {
+	tests := []struct {
+		input    string
+		count    uint64
+		elide    bool
+		expected []string
+	}{
+		{"1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n", 4, false, []string{"1\n2\n3\n", "4\n5\n6\n", "7\n8\n", "9\n10\n"}},
+		{"1\n2\n3\n4\n", 2, false, []string{"1\n2\n", "3\n4\n"}},
+		{"1\n22222222\n3\n4", 3, false, []string{"1\n22222222\n", "3\n", "4"}},
+		{"1\n2\n", 3, false, []string{"1\n", "2\n", ""}},
+		{"1\n2\n", 3, true, []string{"1\n", "2\n"}},
+		{"", 2, false, []string{"", ""}},
+	}
+	for _, test := range tests {
+		for _, seekable := range []bool{true, false} {
+			t.Run(fmt.Sprintf("%q/%d/elide:%v/seekable:%v", test.input, test.count, test.elide, seekable), func(t *testing.T) {
+				var reader io.Reader = strings.NewReader(test.input)
+				if !seekable {
+					reader = oneByteReader{reader}
+				}
+				output := &memoryOutput{}
+				splitter := NewSplitter(ByBalancedLines, test.count, reader, "")
+				splitter.ElideEmptyFiles = test.elide
+				splitter.WriterFactory = output.create
+				if err := splitter.Split(); err != nil {
+					t.Fatalf("Unexpected error: %s", err)
+				}
+
+				if len(output.names) != len(test.expected) {
+					t.Fatalf("Unexpected number of files: got %v, expected %d", output.names, len(test.expected))
+				}
+				minLines, maxLines := -1, 0
+				for i, name := range output.names {
+					got := output.buffers[name].String()
+					if got != test.expected[i] {
+						t.Errorf("Unexpected content of %s: got %q, expected %q", name, got, test.expected[i])
+					}
+					lines := strings.Count(got, "\n")
+					if got != "" && !strings.HasSuffix(got, "\n") {
+						lines++
+					}
+					if minLines < 0 || lines < minLines {
+						minLines = lines
+					}
+					if lines > maxLines {
+						maxLines = lines
+					}
+				}
+				// 空のファイルを省略しない場合は各ファイルの行数の差が1以下になる
+				if !test.elide && maxLines-minLines > 1 {
+					t.Errorf("Line counts differ by %d", maxLines-minLines)
+				}
+			})
+		}
+	}
+}
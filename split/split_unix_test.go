@@ -0,0 +1,50 @@
+//go:build unix
+
+package split
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+// 出力ファイルを開いたままにすると、ファイルディスクリプタの上限を超えて失敗する
+func TestSplitterSplitManyFilesWithLowFileLimit(t *testing.T) {
+	var rlimit syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &rlimit); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	lowered := rlimit
+	lowered.Cur = 64
+	if err := syscall.Setrlimit(syscall.RLIMIT_NOFILE, &lowered); err != nil {
+		t.Skipf("Cannot lower the file descriptor limit: %s", err)
+	}
+	defer syscall.Setrlimit(syscall.RLIMIT_NOFILE, &rlimit)
+
+	fileCount := uint64(200)
+	input := strings.Repeat("x", int(fileCount))
+	for _, splitType := range []SplitType{ByBytes, ByFiles} {
+		t.Run(fmt.Sprintf("splitType: %v", splitType), func(t *testing.T) {
+			count := fileCount
+			if splitType == ByBytes {
+				count = 1
+			}
+
+			outputDir := t.TempDir() + "/"
+			splitter := NewSplitter(splitType, count, strings.NewReader(input), outputDir+"x")
+			if err := splitter.Split(); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+
+			entries, err := os.ReadDir(outputDir)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if uint64(len(entries)) != fileCount {
+				t.Errorf("Unexpected number of output files: got %d, expected %d", len(entries), fileCount)
+			}
+		})
+	}
+}
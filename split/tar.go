@@ -0,0 +1,89 @@
+package split
+
+import (
+	"archive/tar"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// TarArchive は出力ファイルを1つの tar アーカイブのエントリとして書き込む
+// Create を Splitter.WriterFactory に指定し、分割の後に Close でアーカイブを閉じる
+type TarArchive struct {
+	// エントリの権限
+	// 0の場合は 0644 とする
+	Mode   os.FileMode
+	mu     sync.Mutex
+	writer *tar.Writer
+}
+
+// NewTarArchive は writer に tar アーカイブを書き込む TarArchive を作成する
+func NewTarArchive(writer io.Writer) *TarArchive {
+	return &TarArchive{writer: tar.NewWriter(writer)}
+}
+
+// Create は name のエントリに書き込む出力先を作成する
+// tar のヘッダーにはエントリのサイズが必要なため、内容は Close まで一時ファイルに書き込む
+// ByRoundRobin のように複数の出力先へ同時に書き込む場合も、エントリは閉じた順に1つずつ書き込む
+func (a *TarArchive) Create(name string) (io.WriteCloser, error) {
+	spool, err := os.CreateTemp("", "split-tar-")
+	if err != nil {
+		return nil, err
+	}
+	return &tarEntryWriteCloser{File: spool, archive: a, name: filepath.ToSlash(name)}, nil
+}
+
+// Close はアーカイブの終端を書き込む
+// writer 自体は閉じない
+func (a *TarArchive) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.writer.Close()
+}
+
+func (a *TarArchive) writeEntry(name string, content *os.File) error {
+	info, err := content.Stat()
+	if err != nil {
+		return err
+	}
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	mode := a.Mode.Perm()
+	if mode == 0 {
+		mode = 0644
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	header := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Mode:     int64(mode),
+		Size:     info.Size(),
+		ModTime:  time.Now(),
+	}
+	if err := a.writer.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err = io.Copy(a.writer, content)
+	return err
+}
+
+// tarEntryWriteCloser は一時ファイルに書き込み、Close でアーカイブのエントリとする
+type tarEntryWriteCloser struct {
+	*os.File
+	archive *TarArchive
+	name    string
+}
+
+func (w *tarEntryWriteCloser) Close() error {
+	defer os.Remove(w.File.Name())
+	err := w.archive.writeEntry(w.name, w.File)
+	if closeErr := w.File.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
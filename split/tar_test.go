@@ -0,0 +1,65 @@
+package split
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestTarArchive(t *testing.T) {
+	input := "1\n22\n333\n4444\n55555\n"
+	tests := []struct {
+		name          string
+		splitter      func(r io.Reader) *Splitter
+		expectedNames []string
+	}{
+		{"bytes", func(r io.Reader) *Splitter { return NewSplitter(ByBytes, 8, r, "x") }, []string{"xaa", "xab", "xac"}},
+		{"lines", func(r io.Reader) *Splitter { return NewSplitter(ByLines, 2, r, "part-") }, []string{"part-aa", "part-ab", "part-ac"}},
+		{"files", func(r io.Reader) *Splitter { return NewSplitter(ByFiles, 2, r, "x") }, []string{"xaa", "xab"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			archiveBuffer := &bytes.Buffer{}
+			archive := NewTarArchive(archiveBuffer)
+			splitter := test.splitter(strings.NewReader(input))
+			splitter.WriterFactory = archive.Create
+			if err := splitter.Split(); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if err := archive.Close(); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+
+			// エントリを順に連結すると入力に戻る
+			reader := tar.NewReader(archiveBuffer)
+			names := []string{}
+			joined := &bytes.Buffer{}
+			for {
+				header, err := reader.Next()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				if err != nil {
+					t.Fatalf("Unexpected error: %s", err)
+				}
+				names = append(names, header.Name)
+				if n, err := io.Copy(joined, reader); err != nil || n != header.Size {
+					t.Fatalf("Unexpected entry %s: copied %d of %d bytes: %v", header.Name, n, header.Size, err)
+				}
+				if header.Mode != 0644 {
+					t.Errorf("Unexpected mode of %s: got %o, expected %o", header.Name, header.Mode, 0644)
+				}
+			}
+			if strings.Join(names, ",") != strings.Join(test.expectedNames, ",") {
+				t.Errorf("Unexpected entries: got %v, expected %v", names, test.expectedNames)
+			}
+			if joined.String() != input {
+				t.Errorf("Unexpected content: got %q, expected %q", joined.String(), input)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package split
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+)
+
+// SplitFileNames は prefix と suffix から生成される名前の出力ファイルを、存在する限り順番に列挙する
+// ファイル数の決まらない分割方法と同じ規則で名前を生成するため、-n で桁数が増えた場合は suffix.Length の指定が必要となる
+func SplitFileNames(prefix string, suffix SuffixOptions) ([]string, error) {
+	var names []string
+	for index := uint64(0); ; index++ {
+		name, err := GenFileName(prefix, index, 0, ByLines, suffix)
+		if errors.Is(err, OutputFileSuffixesExhausted) {
+			return names, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := os.Stat(name); errors.Is(err, fs.ErrNotExist) {
+			return names, nil
+		} else if err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+}
+
+// Verify は prefix の出力ファイルを順番に連結したもののハッシュ値を original のハッシュ値と比較する
+// 一致しない場合は VerificationFailed を返し、成功した場合は比較したファイルの数を返す
+func Verify(prefix string, original string, suffix SuffixOptions) (int, error) {
+	names, err := SplitFileNames(prefix, suffix)
+	if err != nil {
+		return 0, err
+	}
+	if len(names) == 0 {
+		return 0, fmt.Errorf("%w: %s", SplitFilesNotFound, PrefixOrDefault(prefix))
+	}
+
+	joined, err := getJoinedHash(names)
+	if err != nil {
+		return 0, err
+	}
+	expected, err := getFileHash(original)
+	if err != nil {
+		return 0, err
+	}
+	if joined != expected {
+		return 0, fmt.Errorf("%w: %s", VerificationFailed, original)
+	}
+	return len(names), nil
+}
+
+// ファイルを順番に連結したもののハッシュ値を求める
+func getJoinedHash(filePaths []string) (string, error) {
+	hash, err := newHash(ChecksumMD5)
+	if err != nil {
+		return "", err
+	}
+
+	if err := joinFiles(hash, filePaths); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%x", hash.Sum(nil)), nil
+}
@@ -0,0 +1,85 @@
+package split
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestVerify(t *testing.T) {
+	tests := []struct {
+		name      string
+		splitType SplitType
+		count     uint64
+		suffix    SuffixOptions
+		files     int
+	}{
+		{"lines", ByLines, 2, SuffixOptions{}, 3},
+		{"bytes with numeric suffixes", ByBytes, 3, SuffixOptions{Numeric: true, Start: 5}, 4},
+		{"files with suffix length", ByFiles, 4, SuffixOptions{Length: 3, Additional: ".txt"}, 4},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			outputDir := t.TempDir() + "/"
+			original := outputDir + "original.txt"
+			if err := os.WriteFile(original, []byte("1\n2\n3\n4\n5\n"), 0644); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			input, err := os.Open(original)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			defer input.Close()
+
+			splitter := NewSplitter(test.splitType, test.count, input, outputDir+"x")
+			splitter.Suffix = test.suffix
+			if err := splitter.Split(); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+
+			files, err := Verify(outputDir+"x", original, test.suffix)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if files != test.files {
+				t.Errorf("Unexpected number of files: got %d, expected %d", files, test.files)
+			}
+
+			// 分割したファイルの1つを書き換えると一致しない
+			names, err := SplitFileNames(outputDir+"x", test.suffix)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if err := os.WriteFile(names[1], []byte("corrupted"), 0644); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if _, err := Verify(outputDir+"x", original, test.suffix); !errors.Is(err, VerificationFailed) {
+				t.Errorf("Unexpected error: got %v, expected %s", err, VerificationFailed)
+			}
+		})
+	}
+
+	outputDir := t.TempDir() + "/"
+	if _, err := Verify(outputDir+"x", outputDir+"original.txt", SuffixOptions{}); !errors.Is(err, SplitFilesNotFound) {
+		t.Errorf("Unexpected error: got %v, expected %s", err, SplitFilesNotFound)
+	}
+}
+
+func TestSplitFileNames(t *testing.T) {
+	outputDir := t.TempDir() + "/"
+	// 途中のファイルがない場合はそこで列挙を終える
+	for _, name := range []string{"xaa", "xab", "xad"} {
+		if err := os.WriteFile(outputDir+name, nil, 0644); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+	}
+
+	names, err := SplitFileNames(outputDir+"x", SuffixOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if got := strings.Join(names, ","); got != outputDir+"xaa,"+outputDir+"xab" {
+		t.Errorf("Unexpected names: got %s", got)
+	}
+}